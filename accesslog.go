@@ -0,0 +1,424 @@
+package kono
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/starwalkn/kono/internal/redact"
+)
+
+const (
+	accessLogFormatJSON     = "json"
+	accessLogFormatCombined = "combined"
+	accessLogFormatLogfmt   = "logfmt"
+
+	accessLogOutputStdout = "stdout"
+	accessLogOutputFile   = "file"
+
+	accessLogTimeLayout     = "2006-01-02T15:04:05.000Z07:00"
+	accessLogCombinedLayout = "02/Jan/2006:15:04:05 -0700"
+)
+
+// Access log fields.
+const (
+	accessFieldTime       = "time"
+	accessFieldMethod     = "method"
+	accessFieldPath       = "path"
+	accessFieldRoute      = "route"
+	accessFieldStatus     = "status"
+	accessFieldDuration   = "duration_ms"
+	accessFieldBytesIn    = "bytes_in"
+	accessFieldBytesOut   = "bytes_out"
+	accessFieldClientIP   = "client_ip"
+	accessFieldRequestID  = "request_id"
+	accessFieldUser       = "user"
+	accessFieldTraceID    = "trace_id"
+	accessFieldUpstreams  = "upstreams"
+	accessFieldUserAgent  = "user_agent"
+	accessFieldReferer    = "referer"
+	accessFieldStatusText = "status_text"
+)
+
+var defaultAccessLogFields = []string{
+	accessFieldTime, accessFieldMethod, accessFieldPath, accessFieldRoute, accessFieldStatus, accessFieldDuration,
+	accessFieldBytesIn, accessFieldBytesOut, accessFieldClientIP, accessFieldRequestID, accessFieldUser,
+	accessFieldTraceID, accessFieldUpstreams,
+}
+
+// AccessLog writes one entry per request, separately from the application log. Requests are sampled
+// by the configured rate, server errors are always logged.
+type AccessLog struct {
+	format     string
+	fields     []string
+	sampleRate float64
+	userClaim  string
+
+	mu  sync.Mutex
+	out io.Writer
+
+	closer io.Closer
+}
+
+// NewAccessLog creates the access log writing to stdout or a rotating file. It must be closed to release the file.
+func NewAccessLog(cfg AccessLogConfig) (*AccessLog, error) {
+	l := &AccessLog{
+		format:     cfg.Format,
+		fields:     cfg.Fields,
+		sampleRate: cfg.SampleRate,
+		userClaim:  cfg.UserClaim,
+	}
+
+	switch cfg.Output {
+	case accessLogOutputFile:
+		if cfg.File.Path == "" {
+			return nil, errors.New("access log file path is required")
+		}
+
+		file := &lumberjack.Logger{
+			Filename:   cfg.File.Path,
+			MaxSize:    cfg.File.MaxSize,
+			MaxBackups: cfg.File.MaxBackups,
+			MaxAge:     cfg.File.MaxAge,
+			Compress:   cfg.File.Compress,
+		}
+
+		l.out, l.closer = file, file
+	default:
+		l.out = os.Stdout
+	}
+
+	return l, nil
+}
+
+// Close closes the access log file, if any.
+func (l *AccessLog) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+
+	return l.closer.Close()
+}
+
+type ctxKeyAccessEntry struct{}
+
+// accessEntry collects data of a request along the pipeline. The dispatcher adds upstreams concurrently.
+type accessEntry struct {
+	start time.Time
+	req   *http.Request
+
+	mu        sync.Mutex
+	route     string
+	requestID string
+	user      string
+	bytesIn   int64
+	upstreams []accessUpstream
+
+	// redactor masks query parameters and patterns in the logged URI, user agent and referer.
+	redactor *redact.Redactor
+}
+
+type accessUpstream struct {
+	Name      string  `json:"name"`
+	Status    int     `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// logAccess stores a new entry in the request context and wraps the writer to record the status and body size.
+// The returned func writes the entry. Without an access log the request and writer are returned as is.
+func (r *Router) logAccess(w http.ResponseWriter, req *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	if r.accessLog == nil {
+		return w, req, func() {}
+	}
+
+	entry := &accessEntry{
+		start:    time.Now(),
+		req:      req,
+		bytesIn:  max(req.ContentLength, 0),
+		redactor: r.redaction(),
+	}
+
+	req = req.WithContext(context.WithValue(req.Context(), ctxKeyAccessEntry{}, entry))
+	sw := &statusWriter{ResponseWriter: w}
+
+	return sw, req, func() {
+		r.accessLog.write(req, entry, sw)
+	}
+}
+
+// write writes the entry if it is sampled.
+func (l *AccessLog) write(req *http.Request, entry *accessEntry, w *statusWriter) {
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if status < http.StatusInternalServerError && l.sampleRate < 1 && rand.Float64() >= l.sampleRate { //nolint:gosec // sampling
+		return
+	}
+
+	entry.mu.Lock()
+	if entry.requestID == "" {
+		entry.requestID = cmp.Or(w.Header().Get("X-Request-ID"), req.Header.Get("X-Request-ID"))
+	}
+	entry.mu.Unlock()
+
+	traceID := ""
+	if sc := trace.SpanFromContext(req.Context()).SpanContext(); sc.HasTraceID() {
+		traceID = sc.TraceID().String()
+	}
+
+	var buf bytes.Buffer
+
+	switch l.format {
+	case accessLogFormatCombined:
+		l.writeCombined(&buf, entry, status, w.written)
+	case accessLogFormatLogfmt:
+		l.writeLogfmt(&buf, entry, status, w.written, traceID)
+	default:
+		l.writeJSON(&buf, entry, status, w.written, traceID)
+	}
+
+	buf.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, _ = l.out.Write(buf.Bytes())
+}
+
+func accessEntryFromContext(ctx context.Context) *accessEntry {
+	entry, _ := ctx.Value(ctxKeyAccessEntry{}).(*accessEntry)
+	return entry
+}
+
+func (e *accessEntry) setRoute(route string) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.route = route
+}
+
+// setRequest records data known once middlewares ran, e.g. the user authenticated by the auth middleware.
+func (e *accessEntry) setRequest(req *http.Request, requestID, userClaim string) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.requestID = requestID
+	e.user = claimValue(req, userClaim)
+}
+
+func (e *accessEntry) setBytesIn(n int) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.bytesIn = int64(n)
+}
+
+func (e *accessEntry) addUpstream(name string, resp *UpstreamResponse, latency time.Duration) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.upstreams = append(e.upstreams, accessUpstream{
+		Name:      name,
+		Status:    resp.Status,
+		LatencyMS: milliseconds(latency),
+		Error:     errorKindLabel(resp.Err),
+	})
+}
+
+// values returns the configured fields of the entry.
+func (l *AccessLog) values(e *accessEntry, status int, written int64, traceID string) []accessValue {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	values := make([]accessValue, 0, len(l.fields))
+
+	for _, field := range l.fields {
+		var v any
+
+		switch field {
+		case accessFieldTime:
+			v = e.start.Format(accessLogTimeLayout)
+		case accessFieldMethod:
+			v = e.req.Method
+		case accessFieldPath:
+			v = e.redactor.String(e.req.URL.Path)
+		case accessFieldRoute:
+			v = e.route
+		case accessFieldStatus:
+			v = status
+		case accessFieldStatusText:
+			v = http.StatusText(status)
+		case accessFieldDuration:
+			v = milliseconds(time.Since(e.start))
+		case accessFieldBytesIn:
+			v = e.bytesIn
+		case accessFieldBytesOut:
+			v = written
+		case accessFieldClientIP:
+			v = extractClientIP(e.req)
+		case accessFieldRequestID:
+			v = e.requestID
+		case accessFieldUser:
+			v = e.user
+		case accessFieldTraceID:
+			v = traceID
+		case accessFieldUpstreams:
+			v = append(make([]accessUpstream, 0, len(e.upstreams)), e.upstreams...)
+		case accessFieldUserAgent:
+			v = e.redactor.String(e.req.UserAgent())
+		case accessFieldReferer:
+			v = e.redactor.URL(e.req.Referer())
+		default:
+			continue
+		}
+
+		values = append(values, accessValue{key: field, value: v})
+	}
+
+	return values
+}
+
+type accessValue struct {
+	key   string
+	value any
+}
+
+func (l *AccessLog) writeJSON(buf *bytes.Buffer, e *accessEntry, status int, written int64, traceID string) {
+	buf.WriteByte('{')
+
+	for i, v := range l.values(e, status, written, traceID) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, _ := json.Marshal(v.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		value, err := json.Marshal(v.value)
+		if err != nil {
+			value = []byte("null")
+		}
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+}
+
+func (l *AccessLog) writeLogfmt(buf *bytes.Buffer, e *accessEntry, status int, written int64, traceID string) {
+	for i, v := range l.values(e, status, written, traceID) {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+
+		buf.WriteString(v.key)
+		buf.WriteByte('=')
+
+		switch value := v.value.(type) {
+		case string:
+			writeLogfmtValue(buf, value)
+		case []accessUpstream:
+			parts := make([]string, 0, len(value))
+			for _, u := range value {
+				part := u.Name + ":" + strconv.Itoa(u.Status) + ":" + strconv.FormatFloat(u.LatencyMS, 'f', -1, 64) + "ms"
+				if u.Error != "" {
+					part += ":" + u.Error
+				}
+
+				parts = append(parts, part)
+			}
+
+			writeLogfmtValue(buf, strings.Join(parts, ","))
+		case float64:
+			buf.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+		case int:
+			buf.WriteString(strconv.Itoa(value))
+		case int64:
+			buf.WriteString(strconv.FormatInt(value, 10))
+		}
+	}
+}
+
+// writeLogfmtValue writes the value, quoted if it is empty or contains spaces, quotes or '='.
+func writeLogfmtValue(buf *bytes.Buffer, value string) {
+	if value == "" || strings.ContainsAny(value, " \"=\t\n\\") {
+		buf.WriteString(strconv.Quote(value))
+		return
+	}
+
+	buf.WriteString(value)
+}
+
+// writeCombined writes the Apache combined log format. Configured fields do not apply to it.
+func (l *AccessLog) writeCombined(buf *bytes.Buffer, e *accessEntry, status int, written int64) {
+	e.mu.Lock()
+	user := e.user
+	e.mu.Unlock()
+
+	bytesOut := "-"
+	if written > 0 {
+		bytesOut = strconv.FormatInt(written, 10)
+	}
+
+	buf.WriteString(extractClientIP(e.req))
+	buf.WriteString(" - ")
+	buf.WriteString(combinedValue(user))
+	buf.WriteString(" [")
+	buf.WriteString(e.start.Format(accessLogCombinedLayout))
+	buf.WriteString("] \"")
+	buf.WriteString(e.req.Method + " " + e.redactor.URL(e.req.URL.RequestURI()) + " " + e.req.Proto)
+	buf.WriteString("\" ")
+	buf.WriteString(strconv.Itoa(status))
+	buf.WriteByte(' ')
+	buf.WriteString(bytesOut)
+	buf.WriteString(" \"")
+	buf.WriteString(combinedValue(e.redactor.URL(e.req.Referer())))
+	buf.WriteString("\" \"")
+	buf.WriteString(combinedValue(e.redactor.String(e.req.UserAgent())))
+	buf.WriteByte('"')
+}
+
+func combinedValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+// milliseconds returns the duration in milliseconds with microsecond precision.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000 //nolint:mnd // microseconds in a millisecond
+}
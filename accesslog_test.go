@@ -0,0 +1,250 @@
+package kono
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// claimsMiddleware authenticates every request as the given subject.
+type claimsMiddleware struct {
+	sub string
+}
+
+func (m *claimsMiddleware) Init(_ map[string]interface{}) error { return nil }
+func (m *claimsMiddleware) Name() string                        { return "claims" }
+func (m *claimsMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), map[string]any{"sub": m.sub})))
+	})
+}
+
+func newAccessLogTestRoute(t *testing.T) Route {
+	t.Helper()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	t.Cleanup(upstream.Close)
+
+	return Route{
+		Path:        "/orders/{id}",
+		Method:      http.MethodPost,
+		Middlewares: []Middleware{&claimsMiddleware{sub: "alice"}},
+		Upstreams: initUpstreams([]UpstreamConfig{
+			{Name: "orders", Hosts: []string{upstream.URL}, Method: http.MethodPost, Timeout: time.Second},
+		}, newTransportRegistry(), zap.NewNop()),
+		Aggregation:          AggregationConfig{Strategy: strategyMerge},
+		MaxParallelUpstreams: 1,
+	}
+}
+
+func newTestAccessLog(format string, fields []string, sampleRate float64) (*AccessLog, *bytes.Buffer) {
+	var buf bytes.Buffer
+
+	return &AccessLog{
+		format:     format,
+		fields:     fields,
+		sampleRate: sampleRate,
+		userClaim:  defaultAccessLogUserClaim,
+		out:        &buf,
+	}, &buf
+}
+
+func TestAccessLog_JSON(t *testing.T) {
+	accessLog, buf := newTestAccessLog(accessLogFormatJSON, defaultAccessLogFields, 1)
+	r := newTestRouter(newTestDispatcher(), newAccessLogTestRoute(t))
+	r.accessLog = accessLog
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/1", strings.NewReader(`{"qty":2}`))
+	req.Header.Set("X-Request-ID", "req-1")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+
+	var entry struct {
+		Method    string           `json:"method"`
+		Path      string           `json:"path"`
+		Route     string           `json:"route"`
+		Status    int              `json:"status"`
+		BytesIn   int64            `json:"bytes_in"`
+		BytesOut  int64            `json:"bytes_out"`
+		ClientIP  string           `json:"client_ip"`
+		RequestID string           `json:"request_id"`
+		User      string           `json:"user"`
+		Upstreams []accessUpstream `json:"upstreams"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid entry %q: %v", buf.String(), err)
+	}
+
+	if entry.Method != http.MethodPost || entry.Path != "/orders/1" || entry.Route != "/orders/{id}" {
+		t.Fatalf("unexpected request fields: %+v", entry)
+	}
+
+	if entry.Status != http.StatusOK || entry.BytesIn != 9 || entry.BytesOut != int64(rec.Body.Len()) {
+		t.Fatalf("unexpected response fields: %+v", entry)
+	}
+
+	if entry.ClientIP != "192.0.2.1" || entry.RequestID != "req-1" || entry.User != "alice" {
+		t.Fatalf("unexpected client fields: %+v", entry)
+	}
+
+	if len(entry.Upstreams) != 1 || entry.Upstreams[0].Name != "orders" || entry.Upstreams[0].Status != http.StatusOK {
+		t.Fatalf("unexpected upstreams: %+v", entry.Upstreams)
+	}
+}
+
+func TestAccessLog_Formats(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		fields []string
+		want   string
+	}{
+		{
+			name:   "logfmt",
+			format: accessLogFormatLogfmt,
+			fields: []string{accessFieldMethod, accessFieldRoute, accessFieldStatus, accessFieldUser, accessFieldTraceID},
+			want:   `method=POST route=/orders/{id} status=200 user=alice trace_id=""` + "\n",
+		},
+		{
+			name:   "combined",
+			format: accessLogFormatCombined,
+			want:   `192.0.2.1 - alice [`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessLog, buf := newTestAccessLog(tt.format, tt.fields, 1)
+			r := newTestRouter(newTestDispatcher(), newAccessLogTestRoute(t))
+			r.accessLog = accessLog
+
+			req := httptest.NewRequest(http.MethodPost, "/orders/1?x=1&api_key=secret", nil)
+			req.Header.Set("User-Agent", "test-agent")
+			req.Header.Set("Referer", "https://example.com/?token=secret")
+
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !strings.HasPrefix(buf.String(), tt.want) {
+				t.Fatalf("unexpected entry %q", buf.String())
+			}
+
+			if tt.format == accessLogFormatCombined &&
+				!strings.HasSuffix(buf.String(), `] "POST /orders/1?x=1&api_key=[REDACTED] HTTP/1.1" 200 17 `+
+					`"https://example.com/?token=[REDACTED]" "test-agent"`+"\n") {
+				t.Fatalf("unexpected combined entry %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestAccessLog_Sampling(t *testing.T) {
+	accessLog, buf := newTestAccessLog(accessLogFormatLogfmt, []string{accessFieldStatus}, math.SmallestNonzeroFloat64)
+	r := newTestRouter(newTestDispatcher(), newAccessLogTestRoute(t))
+	r.accessLog = accessLog
+
+	for range 10 {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders/1", nil))
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/unknown", nil))
+
+	if buf.Len() > 0 {
+		t.Fatalf("expected entries to be sampled out, got %q", buf.String())
+	}
+
+	// Server errors are logged regardless of the sample rate.
+	r.Routes[0].Upstreams = initUpstreams([]UpstreamConfig{
+		{Name: "down", Hosts: []string{"http://127.0.0.1:1"}, Method: http.MethodPost, Timeout: time.Second},
+	}, newTransportRegistry(), zap.NewNop())
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders/1", nil))
+
+	if buf.String() != "status=500\n" {
+		t.Fatalf("expected server error to be logged, got %q", buf.String())
+	}
+}
+
+func TestAccessLog_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+
+	accessLog, err := NewAccessLog(AccessLogConfig{
+		Format:     accessLogFormatLogfmt,
+		Fields:     []string{accessFieldRoute, accessFieldStatus},
+		SampleRate: 1,
+		Output:     accessLogOutputFile,
+		File:       AccessLogFileConfig{Path: path, MaxSize: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(newTestDispatcher(), newAccessLogTestRoute(t))
+	r.accessLog = accessLog
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders/1", nil))
+
+	if err = accessLog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "route=/orders/{id} status=200\n" {
+		t.Fatalf("unexpected file content %q", data)
+	}
+}
+
+func TestAccessLog_ConfigValidation(t *testing.T) {
+	const cfg = `
+config_version: v1
+name: test
+version: "1"
+server:
+  port: 8080
+  access_log:
+    enabled: true
+    format: xml
+    fields: [status, password]
+    output: file
+routes:
+  - path: /users
+    method: GET
+    upstreams:
+      - hosts: [http://localhost:8081]
+`
+
+	path := filepath.Join(t.TempDir(), "kono.yaml")
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error for access log config")
+	}
+
+	for _, field := range []string{"access_log.format", "access_log.fields[1]", "access_log.file.path"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Fatalf("expected error for %s, got: %v", field, err)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package kono
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/starwalkn/kono/internal/circuitbreaker"
+	"github.com/starwalkn/kono/internal/ratelimit"
+)
+
+// Errors of runtime control operations.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrInvalidState = errors.New("invalid state")
+)
+
+// Circuit breaker states accepted by SetCircuitBreaker. Auto releases a forced breaker.
+const (
+	BreakerStateOpen   = "open"
+	BreakerStateClosed = "closed"
+	BreakerStateAuto   = "auto"
+)
+
+// RouteInfo describes a route with its resolved middlewares, plugins and upstreams.
+type RouteInfo struct {
+	Path        string         `json:"path"`
+	Method      string         `json:"method"`
+	Type        string         `json:"type"`
+	Middlewares []string       `json:"middlewares"`
+	Plugins     []PluginState  `json:"plugins"`
+	Upstreams   []UpstreamInfo `json:"upstreams"`
+}
+
+// PluginState is a plugin of a route.
+type PluginState struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Version string `json:"version,omitempty"`
+}
+
+// UpstreamInfo describes an upstream with the health of its hosts and its circuit breaker.
+// Canary variants are listed as separate upstreams named "upstream/variant".
+type UpstreamInfo struct {
+	Name           string              `json:"name"`
+	CircuitBreaker *CircuitBreakerInfo `json:"circuit_breaker,omitempty"`
+	Hosts          []HostInfo          `json:"hosts"`
+}
+
+// CircuitBreakerInfo is the state of an upstream circuit breaker.
+type CircuitBreakerInfo struct {
+	State  string `json:"state"`
+	Forced bool   `json:"forced"`
+}
+
+// RateLimitBucket is the state of a rate limit key in the current window.
+type RateLimitBucket = ratelimit.Bucket
+
+// RateLimitInfo describes a rate limiter with the buckets of the current windows, the fullest first.
+// Route and Rule are empty for the gateway rate limiter.
+type RateLimitInfo struct {
+	Route   string            `json:"route,omitempty"`
+	Method  string            `json:"method,omitempty"`
+	Rule    string            `json:"rule,omitempty"`
+	Limit   int               `json:"limit"`
+	Window  time.Duration     `json:"window"`
+	Buckets []RateLimitBucket `json:"buckets"`
+}
+
+// RoutesInfo returns the routes of the router.
+func (r *Router) RoutesInfo() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(r.Routes))
+
+	for i := range r.Routes {
+		route := &r.Routes[i]
+
+		info := RouteInfo{
+			Path:        route.Path,
+			Method:      route.Method,
+			Type:        route.Type,
+			Middlewares: make([]string, 0, len(route.Middlewares)),
+			Plugins:     make([]PluginState, 0, len(route.Plugins)),
+			Upstreams:   make([]UpstreamInfo, 0, len(route.Upstreams)),
+		}
+
+		for _, mw := range route.Middlewares {
+			info.Middlewares = append(info.Middlewares, mw.Name())
+		}
+
+		for _, p := range route.Plugins {
+			pluginType := "request"
+			if p.Type() == PluginTypeResponse {
+				pluginType = "response"
+			}
+
+			info.Plugins = append(info.Plugins, PluginState{Name: p.Info().Name, Type: pluginType, Version: p.Info().Version})
+		}
+
+		for _, u := range route.Upstreams {
+			info.Upstreams = append(info.Upstreams, upstreamInfos(u)...)
+		}
+
+		routes = append(routes, info)
+	}
+
+	return routes
+}
+
+// upstreamInfos returns the info of the upstream, or of its variants for canary upstreams.
+// Static upstreams have no hosts.
+func upstreamInfos(u Upstream) []UpstreamInfo {
+	httpUps := httpUpstreams([]Upstream{u})
+	if len(httpUps) == 0 {
+		return []UpstreamInfo{{Name: u.Name(), Hosts: []HostInfo{}}}
+	}
+
+	infos := make([]UpstreamInfo, 0, len(httpUps))
+
+	for _, hu := range httpUps {
+		info := UpstreamInfo{
+			Name:  hu.name,
+			Hosts: make([]HostInfo, 0, len(hu.hosts)),
+		}
+
+		if hu.circuitBreaker != nil {
+			info.CircuitBreaker = &CircuitBreakerInfo{
+				State:  hu.circuitBreaker.State().String(),
+				Forced: hu.circuitBreaker.Forced(),
+			}
+		}
+
+		for i, host := range hu.hosts {
+			if i < len(hu.health) {
+				info.Hosts = append(info.Hosts, hu.health[i].info())
+				continue
+			}
+
+			info.Hosts = append(info.Hosts, HostInfo{URL: host, Healthy: true})
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+// RateLimitsInfo returns the gateway rate limiter, if enabled, and the route rate limit rules.
+func (r *Router) RateLimitsInfo() []RateLimitInfo {
+	var infos []RateLimitInfo
+
+	if r.rateLimiter != nil {
+		limit, window := r.rateLimiter.Limit()
+
+		infos = append(infos, RateLimitInfo{Limit: limit, Window: window, Buckets: r.rateLimiter.Buckets()})
+	}
+
+	for i := range r.Routes {
+		route := &r.Routes[i]
+
+		for _, rule := range route.RateLimits {
+			infos = append(infos, RateLimitInfo{
+				Route:   route.Path,
+				Method:  route.Method,
+				Rule:    rule.Name(),
+				Limit:   rule.limit,
+				Window:  rule.window,
+				Buckets: rule.limiter.Buckets(),
+			})
+		}
+	}
+
+	return infos
+}
+
+// SetCircuitBreaker forces the circuit breaker of the upstream open or closed, or releases it with BreakerStateAuto.
+// An empty method matches routes of any method.
+func (r *Router) SetCircuitBreaker(path, method, upstream, state string) error {
+	var apply func(b *circuitbreaker.CircuitBreaker)
+
+	switch state {
+	case BreakerStateOpen:
+		apply = func(b *circuitbreaker.CircuitBreaker) { b.Force(circuitbreaker.Open) }
+	case BreakerStateClosed:
+		apply = func(b *circuitbreaker.CircuitBreaker) { b.Force(circuitbreaker.Closed) }
+	case BreakerStateAuto:
+		apply = (*circuitbreaker.CircuitBreaker).Release
+	default:
+		return fmt.Errorf("%w: %q, must be one of %s, %s, %s", ErrInvalidState, state,
+			BreakerStateOpen, BreakerStateClosed, BreakerStateAuto)
+	}
+
+	found := false
+
+	for _, u := range r.findUpstreams(path, method, upstream) {
+		if u.circuitBreaker == nil {
+			continue
+		}
+
+		apply(u.circuitBreaker)
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("%w: circuit breaker of upstream %q on route %s", ErrNotFound, upstream, path)
+	}
+
+	return nil
+}
+
+// DrainHost stops (or with drained false, resumes) sending requests to the host of the upstream.
+// Requests in flight are not affected. If every host of an upstream is drained, they are used anyway.
+func (r *Router) DrainHost(path, method, upstream, host string, drained bool) error {
+	found := false
+
+	for _, u := range r.findUpstreams(path, method, upstream) {
+		for i, h := range u.hosts {
+			if h != host || i >= len(u.health) {
+				continue
+			}
+
+			u.health[i].drained.Store(drained)
+			found = true
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%w: host %q of upstream %q on route %s", ErrNotFound, host, upstream, path)
+	}
+
+	return nil
+}
+
+// ResetRateLimit removes the bucket of the key from the rate limit rule of the route, or from the gateway rate
+// limiter if path is empty. Route bucket keys are the key parts of the rule joined with "|", as listed by
+// RateLimitsInfo.
+func (r *Router) ResetRateLimit(path, method, rule, key string) error {
+	if path == "" {
+		if r.rateLimiter == nil || !r.rateLimiter.Reset(key) {
+			return fmt.Errorf("%w: global rate limit key %q", ErrNotFound, key)
+		}
+
+		return nil
+	}
+
+	found := false
+
+	for i := range r.Routes {
+		route := &r.Routes[i]
+
+		if !routeMatches(route, path, method) {
+			continue
+		}
+
+		for _, rl := range route.RateLimits {
+			if rule != "" && rl.Name() != rule {
+				continue
+			}
+
+			if rl.limiter.Reset(key) {
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%w: rate limit key %q on route %s", ErrNotFound, key, path)
+	}
+
+	return nil
+}
+
+// findUpstreams returns the HTTP upstreams with the name on the matching routes.
+func (r *Router) findUpstreams(path, method, name string) []*httpUpstream {
+	var found []*httpUpstream
+
+	for i := range r.Routes {
+		route := &r.Routes[i]
+
+		if !routeMatches(route, path, method) {
+			continue
+		}
+
+		for _, u := range httpUpstreams(route.Upstreams) {
+			if u.name == name {
+				found = append(found, u)
+			}
+		}
+	}
+
+	return found
+}
+
+func routeMatches(route *Route, path, method string) bool {
+	return route.Path == path && (method == "" || strings.EqualFold(route.Method, method))
+}
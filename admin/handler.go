@@ -0,0 +1,202 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono"
+)
+
+const maxRequestSize = 64 << 10 // 64KB
+
+// Handler serves the admin API:
+//
+//	GET  /routes            routes with middlewares, plugins, upstream hosts health and circuit breakers
+//	GET  /ratelimits        rate limit buckets of the gateway and route rate limiters
+//	POST /breakers          force a circuit breaker open or closed, or release it ("auto")
+//	POST /hosts/drain       drain or resume an upstream host
+//	POST /ratelimits/reset  reset a rate limit key
+//	GET  /loglevel          current log level
+//	PUT  /loglevel          change the log level, e.g. {"level":"debug"}
+//
+// Every request must carry one of the tokens as a bearer token. Operations apply to the router returned by
+// router, which changes when a new config is applied.
+type Handler struct {
+	router func() *kono.Router
+	level  zap.AtomicLevel
+	tokens [][]byte
+	log    *zap.Logger
+
+	mux *http.ServeMux
+}
+
+func NewHandler(router func() *kono.Router, level zap.AtomicLevel, tokens []string, log *zap.Logger) *Handler {
+	h := &Handler{
+		router: router,
+		level:  level,
+		tokens: make([][]byte, 0, len(tokens)),
+		log:    log,
+		mux:    http.NewServeMux(),
+	}
+
+	for _, token := range tokens {
+		h.tokens = append(h.tokens, []byte(token))
+	}
+
+	h.mux.HandleFunc("GET /routes", h.routes)
+	h.mux.HandleFunc("GET /ratelimits", h.rateLimits)
+	h.mux.HandleFunc("POST /breakers", h.setBreaker)
+	h.mux.HandleFunc("POST /hosts/drain", h.drainHost)
+	h.mux.HandleFunc("POST /ratelimits/reset", h.resetRateLimit)
+	h.mux.Handle("GET /loglevel", level)
+	h.mux.HandleFunc("PUT /loglevel", h.setLogLevel)
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="kono-admin"`)
+		kono.WriteError(w, kono.ErrorCodeUnauthorized, "unauthorized", "", http.StatusUnauthorized)
+
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+// authorized compares the bearer token with every configured token in constant time.
+func (h *Handler) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+
+	authorized := false
+
+	for _, t := range h.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), t) == 1 {
+			authorized = true
+		}
+	}
+
+	return authorized
+}
+
+func (h *Handler) routes(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, h.router().RoutesInfo())
+}
+
+func (h *Handler) rateLimits(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, h.router().RateLimitsInfo())
+}
+
+type breakerRequest struct {
+	Route    string `json:"route"`
+	Method   string `json:"method"`
+	Upstream string `json:"upstream"`
+	State    string `json:"state"`
+}
+
+func (h *Handler) setBreaker(w http.ResponseWriter, r *http.Request) {
+	var req breakerRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	err := h.router().SetCircuitBreaker(req.Route, req.Method, req.Upstream, req.State)
+	h.respond(w, "circuit breaker changed", err,
+		zap.String("route", req.Route), zap.String("upstream", req.Upstream), zap.String("state", req.State))
+}
+
+type drainRequest struct {
+	Route    string `json:"route"`
+	Method   string `json:"method"`
+	Upstream string `json:"upstream"`
+	Host     string `json:"host"`
+	Drained  *bool  `json:"drained"`
+}
+
+func (h *Handler) drainHost(w http.ResponseWriter, r *http.Request) {
+	var req drainRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	// Hosts are drained unless explicitly resumed.
+	drained := req.Drained == nil || *req.Drained
+
+	err := h.router().DrainHost(req.Route, req.Method, req.Upstream, req.Host, drained)
+	h.respond(w, "host drain changed", err,
+		zap.String("route", req.Route), zap.String("upstream", req.Upstream), zap.String("host", req.Host),
+		zap.Bool("drained", drained))
+}
+
+type resetRateLimitRequest struct {
+	Route  string `json:"route"`
+	Method string `json:"method"`
+	Rule   string `json:"rule"`
+	Key    string `json:"key"`
+}
+
+func (h *Handler) resetRateLimit(w http.ResponseWriter, r *http.Request) {
+	var req resetRateLimitRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	err := h.router().ResetRateLimit(req.Route, req.Method, req.Rule, req.Key)
+	h.respond(w, "rate limit key reset", err, zap.String("route", req.Route), zap.String("rule", req.Rule))
+}
+
+// setLogLevel changes the level, the zap handler responds with the new level. Only actual changes are logged,
+// rejected requests leave the level as is.
+func (h *Handler) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+
+	previous := h.level.Level()
+
+	h.level.ServeHTTP(w, r)
+
+	if current := h.level.Level(); current != previous {
+		h.log.Warn("log level changed via admin api", zap.Stringer("from", previous), zap.Stringer("level", current))
+	}
+}
+
+// respond writes the result of an operation. Operations change the gateway behaviour, so they are logged.
+func (h *Handler) respond(w http.ResponseWriter, msg string, err error, fields ...zap.Field) {
+	switch {
+	case errors.Is(err, kono.ErrNotFound):
+		kono.WriteError(w, kono.ErrorCodeNotFound, err.Error(), "", http.StatusNotFound)
+	case err != nil:
+		kono.WriteError(w, kono.ErrorCodeBadRequest, err.Error(), "", http.StatusBadRequest)
+	default:
+		h.log.Warn(msg+" via admin api", fields...)
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		kono.WriteError(w, kono.ErrorCodeBadRequest, "invalid request body: "+err.Error(), "", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	//nolint:errcheck,gosec // client went away
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,171 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/starwalkn/kono"
+)
+
+const testToken = "s3cr3t"
+
+func newTestHandler(t *testing.T) (*Handler, zap.AtomicLevel) {
+	t.Helper()
+
+	router := kono.NewRouter(kono.RouterConfigSet{
+		Routes: []kono.RouteConfig{
+			{
+				Path:   "/users",
+				Method: http.MethodGet,
+				Upstreams: []kono.UpstreamConfig{
+					{
+						Name:  "users",
+						Hosts: []string{"http://users.local"},
+						Policy: kono.PolicyConfig{
+							CircuitBreakerConfig: kono.CircuitBreakerConfig{Enabled: true, MaxFailures: 3},
+						},
+					},
+				},
+			},
+		},
+	}, zap.NewNop())
+
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+
+	return NewHandler(func() *kono.Router { return router }, level, []string{"other", testToken}, zap.NewNop()), level
+}
+
+func do(h http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestHandler_Unauthorized(t *testing.T) {
+	h, _ := newTestHandler(t)
+
+	for _, token := range []string{"", "wrong"} {
+		rec := do(h, http.MethodGet, "/routes", token, "")
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("token %q: expected 401, got %d", token, rec.Code)
+		}
+
+		if rec.Header().Get("WWW-Authenticate") == "" {
+			t.Fatal("expected WWW-Authenticate header")
+		}
+	}
+}
+
+func TestHandler_Routes(t *testing.T) {
+	h, _ := newTestHandler(t)
+
+	rec := do(h, http.MethodGet, "/routes", testToken, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var routes []kono.RouteInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(routes) != 1 || routes[0].Upstreams[0].Hosts[0].URL != "http://users.local" {
+		t.Fatalf("unexpected routes: %+v", routes)
+	}
+}
+
+func TestHandler_Breaker(t *testing.T) {
+	h, _ := newTestHandler(t)
+
+	rec := do(h, http.MethodPost, "/breakers", testToken, `{"route":"/users","upstream":"users","state":"open"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if state := h.router().RoutesInfo()[0].Upstreams[0].CircuitBreaker.State; state != "open" {
+		t.Fatalf("expected open breaker, got %s", state)
+	}
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{body: `{"route":"/users","upstream":"orders","state":"open"}`, want: http.StatusNotFound},
+		{body: `{"route":"/users","upstream":"users","state":"broken"}`, want: http.StatusBadRequest},
+		{body: `{"route":"/users","unknown":true}`, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		if rec = do(h, http.MethodPost, "/breakers", testToken, tt.body); rec.Code != tt.want {
+			t.Fatalf("%s: expected %d, got %d", tt.body, tt.want, rec.Code)
+		}
+	}
+}
+
+func TestHandler_DrainHost(t *testing.T) {
+	h, _ := newTestHandler(t)
+
+	rec := do(h, http.MethodPost, "/hosts/drain", testToken, `{"route":"/users","upstream":"users","host":"http://users.local"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if !h.router().RoutesInfo()[0].Upstreams[0].Hosts[0].Drained {
+		t.Fatal("expected drained host")
+	}
+}
+
+func TestHandler_LogLevel(t *testing.T) {
+	h, level := newTestHandler(t)
+
+	rec := do(h, http.MethodPut, "/loglevel", testToken, `{"level":"debug"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if level.Level() != zapcore.DebugLevel {
+		t.Fatalf("expected debug level, got %s", level.Level())
+	}
+
+	rec = do(h, http.MethodGet, "/loglevel", testToken, "")
+	if !strings.Contains(rec.Body.String(), `"debug"`) {
+		t.Fatalf("unexpected level response: %s", rec.Body)
+	}
+}
+
+func TestHandler_LogLevelAudit(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	h := NewHandler(func() *kono.Router { return nil }, level, []string{testToken}, zap.New(core))
+
+	if rec := do(h, http.MethodPut, "/loglevel", testToken, `{"level":"loud"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := do(h, http.MethodPut, "/loglevel", testToken, `{"level":"info"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if logs.Len() != 0 {
+		t.Fatalf("expected no audit entries without a change, got %v", logs.All())
+	}
+
+	do(h, http.MethodPut, "/loglevel", testToken, `{"level":"debug"}`)
+
+	if entries := logs.FilterMessage("log level changed via admin api").All(); len(entries) != 1 {
+		t.Fatalf("expected one audit entry, got %d", len(entries))
+	}
+}
@@ -0,0 +1,220 @@
+package kono
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newAdminTestRoute(t *testing.T, hosts ...string) Route {
+	t.Helper()
+
+	rule, err := newRateLimitRule(RateLimitConfig{
+		Name:   "per-ip",
+		Keys:   []RateLimitKeyConfig{{Type: rateLimitKeyIP}},
+		Limit:  1,
+		Window: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Route{
+		Path:        "/users",
+		Method:      http.MethodGet,
+		Middlewares: []Middleware{&mockMiddleware{}},
+		Plugins:     []Plugin{&mockPlugin{name: "enrich", typ: PluginTypeResponse, fn: func(Context) {}}},
+		Upstreams: initUpstreams([]UpstreamConfig{
+			{
+				Name:    "users",
+				Hosts:   hosts,
+				Method:  http.MethodGet,
+				Timeout: time.Second,
+				Policy: PolicyConfig{
+					CircuitBreakerConfig: CircuitBreakerConfig{Enabled: true, MaxFailures: 5, ResetTimeout: time.Minute},
+				},
+			},
+		}, newTransportRegistry(), zap.NewNop()),
+		RateLimits:           []*RateLimitRule{rule},
+		Aggregation:          AggregationConfig{Strategy: strategyMerge},
+		MaxParallelUpstreams: 1,
+	}
+}
+
+func newCountingUpstream(t *testing.T, status int) (*httptest.Server, *int) {
+	t.Helper()
+
+	var calls int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &calls
+}
+
+func TestRouter_RoutesInfo(t *testing.T) {
+	healthy, _ := newCountingUpstream(t, http.StatusOK)
+	failing, _ := newCountingUpstream(t, http.StatusBadGateway)
+
+	r := newTestRouter(newTestDispatcher(), newAdminTestRoute(t, healthy.URL, failing.URL))
+
+	for range 6 {
+		u := r.Routes[0].Upstreams[0]
+		u.Call(t.Context(), httptest.NewRequest(http.MethodGet, "/users", nil), nil)
+	}
+
+	routes := r.RoutesInfo()
+	if len(routes) != 1 {
+		t.Fatalf("expected 1 route, got %d", len(routes))
+	}
+
+	route := routes[0]
+	if route.Path != "/users" || len(route.Middlewares) != 1 || route.Middlewares[0] != "mockmw" {
+		t.Fatalf("unexpected route: %+v", route)
+	}
+
+	if len(route.Plugins) != 1 || route.Plugins[0].Name != "enrich" || route.Plugins[0].Type != "response" {
+		t.Fatalf("unexpected plugins: %+v", route.Plugins)
+	}
+
+	upstream := route.Upstreams[0]
+	if upstream.Name != "users" || upstream.CircuitBreaker == nil || upstream.CircuitBreaker.State != "closed" {
+		t.Fatalf("unexpected upstream: %+v", upstream)
+	}
+
+	hosts := upstream.Hosts
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %+v", hosts)
+	}
+
+	if !hosts[0].Healthy || hosts[0].Successes != 3 || hosts[0].LastStatus != http.StatusOK {
+		t.Fatalf("unexpected healthy host: %+v", hosts[0])
+	}
+
+	if hosts[1].Healthy || hosts[1].Failures != 3 || hosts[1].LastStatus != http.StatusBadGateway {
+		t.Fatalf("unexpected failing host: %+v", hosts[1])
+	}
+}
+
+func TestRouter_SetCircuitBreaker(t *testing.T) {
+	srv, calls := newCountingUpstream(t, http.StatusOK)
+	r := newTestRouter(newTestDispatcher(), newAdminTestRoute(t, srv.URL))
+
+	if err := r.SetCircuitBreaker("/users", "", "users", BreakerStateOpen); err != nil {
+		t.Fatal(err)
+	}
+
+	u := r.Routes[0].Upstreams[0]
+
+	resp := u.Call(t.Context(), httptest.NewRequest(http.MethodGet, "/users", nil), nil)
+	if resp.Err == nil || resp.Err.Kind != UpstreamCircuitOpen || *calls != 0 {
+		t.Fatalf("expected forced open breaker to deny requests, got %+v", resp)
+	}
+
+	if info := r.RoutesInfo()[0].Upstreams[0].CircuitBreaker; info.State != "open" || !info.Forced {
+		t.Fatalf("unexpected breaker info: %+v", info)
+	}
+
+	if err := r.SetCircuitBreaker("/users", "", "users", BreakerStateAuto); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp = u.Call(t.Context(), httptest.NewRequest(http.MethodGet, "/users", nil), nil); resp.Err != nil {
+		t.Fatalf("expected released breaker to allow requests, got %+v", resp.Err)
+	}
+
+	if err := r.SetCircuitBreaker("/users", "", "orders", BreakerStateOpen); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	if err := r.SetCircuitBreaker("/users", "", "users", "half_open"); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected invalid state error, got %v", err)
+	}
+}
+
+func TestRouter_DrainHost(t *testing.T) {
+	first, firstCalls := newCountingUpstream(t, http.StatusOK)
+	second, secondCalls := newCountingUpstream(t, http.StatusOK)
+
+	r := newTestRouter(newTestDispatcher(), newAdminTestRoute(t, first.URL, second.URL))
+
+	if err := r.DrainHost("/users", http.MethodGet, "users", first.URL, true); err != nil {
+		t.Fatal(err)
+	}
+
+	u := r.Routes[0].Upstreams[0]
+
+	for range 4 {
+		u.Call(t.Context(), httptest.NewRequest(http.MethodGet, "/users", nil), nil)
+	}
+
+	if *firstCalls != 0 || *secondCalls != 4 {
+		t.Fatalf("expected drained host to be skipped, got %d and %d calls", *firstCalls, *secondCalls)
+	}
+
+	if err := r.DrainHost("/users", http.MethodGet, "users", first.URL, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 4 {
+		u.Call(t.Context(), httptest.NewRequest(http.MethodGet, "/users", nil), nil)
+	}
+
+	if *firstCalls != 2 {
+		t.Fatalf("expected resumed host to receive requests, got %d calls", *firstCalls)
+	}
+
+	if err := r.DrainHost("/users", http.MethodGet, "users", "http://unknown", true); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestRouter_ResetRateLimit(t *testing.T) {
+	srv, _ := newCountingUpstream(t, http.StatusOK)
+	r := newTestRouter(newTestDispatcher(), newAdminTestRoute(t, srv.URL))
+
+	serve := func() int {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+		return rec.Code
+	}
+
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("unexpected status %d", code)
+	}
+
+	if code := serve(); code != http.StatusTooManyRequests {
+		t.Fatalf("expected rate limited request, got %d", code)
+	}
+
+	infos := r.RateLimitsInfo()
+	if len(infos) != 1 || infos[0].Rule != "per-ip" || len(infos[0].Buckets) != 1 {
+		t.Fatalf("unexpected rate limits: %+v", infos)
+	}
+
+	bucket := infos[0].Buckets[0]
+	if bucket.Key != "192.0.2.1" || bucket.Count != 1 {
+		t.Fatalf("unexpected bucket: %+v", bucket)
+	}
+
+	if err := r.ResetRateLimit("/users", "", "per-ip", bucket.Key); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("expected reset key to be allowed, got %d", code)
+	}
+
+	if err := r.ResetRateLimit("", "", "", bucket.Key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found error without gateway rate limiter, got %v", err)
+	}
+}
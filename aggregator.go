@@ -34,7 +34,7 @@ type defaultAggregator struct {
 // if allowed; otherwise a single error response is returned.
 func (a *defaultAggregator) aggregate(responses []UpstreamResponse, aggregation AggregationConfig) AggregatedResponse {
 	if len(responses) == 1 {
-		return a.rawResponse(responses)
+		return a.rawResponse(responses, aggregation.AllowPartialResults)
 	}
 
 	switch aggregation.Strategy {
@@ -48,16 +48,24 @@ func (a *defaultAggregator) aggregate(responses []UpstreamResponse, aggregation
 	}
 }
 
-func (a *defaultAggregator) rawResponse(responses []UpstreamResponse) AggregatedResponse {
+func (a *defaultAggregator) rawResponse(responses []UpstreamResponse, allowPartialResults bool) AggregatedResponse {
 	if len(responses) > 1 {
 		return internalAggregationError()
 	}
 
 	resp := responses[0]
 	if resp.Err != nil {
+		if allowPartialResults && hasPartialData(resp) {
+			return AggregatedResponse{
+				Data:    resp.Body,
+				Errors:  a.responseErrors(resp.Err),
+				Partial: true,
+			}
+		}
+
 		return AggregatedResponse{
 			Data:    nil,
-			Errors:  []JSONError{a.mapUpstreamError(resp.Err)},
+			Errors:  a.responseErrors(resp.Err),
 			Partial: false,
 		}
 	}
@@ -76,33 +84,36 @@ func (a *defaultAggregator) rawResponse(responses []UpstreamResponse) Aggregated
 func (a *defaultAggregator) mergeResponses(responses []UpstreamResponse, allowPartialResults bool) AggregatedResponse {
 	merged := make(map[string]interface{})
 
-	var aggregationErrors []JSONError
+	var aggregationErrors errorList
 
 	for _, resp := range responses {
 		var obj map[string]interface{}
 
 		// Handle upstream error.
 		if resp.Err != nil {
-			mapped := a.mapUpstreamError(resp.Err)
+			mapped := a.upstreamErrors(resp.Err)
 
 			a.log.Warn(
 				"upstream has errors",
 				zap.Bool("allow_partial_results", allowPartialResults),
 				zap.String("upstream_error", resp.Err.Unwrap().Error()),
-				zap.String("mapped_error", mapped.Message),
+				zap.String("mapped_error", mapped[0].Message),
 			)
 
 			if !allowPartialResults {
 				return AggregatedResponse{
 					Data:    nil,
-					Errors:  []JSONError{mapped},
+					Errors:  a.responseErrors(resp.Err),
 					Partial: false,
 				}
 			}
 
-			aggregationErrors = append(aggregationErrors, mapped)
+			aggregationErrors.addUpstream(resp.Err, mapped)
 
-			continue
+			// Partial upstream data is merged along with its errors.
+			if !hasPartialData(resp) {
+				continue
+			}
 		}
 
 		if resp.Body == nil {
@@ -121,7 +132,7 @@ func (a *defaultAggregator) mergeResponses(responses []UpstreamResponse, allowPa
 				return jsonParseError()
 			}
 
-			aggregationErrors = append(aggregationErrors, JSONError{
+			aggregationErrors.mapped = append(aggregationErrors.mapped, JSONError{
 				Code:    ErrorCodeUpstreamMalformed,
 				Message: "upstream malformed",
 			})
@@ -139,8 +150,8 @@ func (a *defaultAggregator) mergeResponses(responses []UpstreamResponse, allowPa
 
 	aggregationResponse := AggregatedResponse{
 		Data:    data,
-		Errors:  dedupeErrors(aggregationErrors),
-		Partial: len(aggregationErrors) > 0,
+		Errors:  aggregationErrors.errors(),
+		Partial: !aggregationErrors.empty(),
 	}
 
 	return aggregationResponse
@@ -149,31 +160,34 @@ func (a *defaultAggregator) mergeResponses(responses []UpstreamResponse, allowPa
 func (a *defaultAggregator) arrayOfResponses(responses []UpstreamResponse, allowPartialResults bool) AggregatedResponse {
 	var arr []json.RawMessage
 
-	var aggregationErrors []JSONError
+	var aggregationErrors errorList
 
 	for _, resp := range responses {
 		// Handle upstream error
 		if resp.Err != nil {
-			mapped := a.mapUpstreamError(resp.Err)
+			mapped := a.upstreamErrors(resp.Err)
 
 			a.log.Warn(
 				"upstream has errors",
 				zap.Bool("allow_partial_results", allowPartialResults),
 				zap.String("upstream_error", resp.Err.Unwrap().Error()),
-				zap.String("mapped_error", mapped.Message),
+				zap.String("mapped_error", mapped[0].Message),
 			)
 
 			if !allowPartialResults {
 				return AggregatedResponse{
 					Data:    nil,
-					Errors:  []JSONError{mapped},
+					Errors:  a.responseErrors(resp.Err),
 					Partial: false,
 				}
 			}
 
-			aggregationErrors = append(aggregationErrors, mapped)
+			aggregationErrors.addUpstream(resp.Err, mapped)
 
-			continue
+			// Partial upstream data is merged along with its errors.
+			if !hasPartialData(resp) {
+				continue
+			}
 		}
 
 		if resp.Body == nil {
@@ -190,13 +204,62 @@ func (a *defaultAggregator) arrayOfResponses(responses []UpstreamResponse, allow
 
 	aggregationResponse := AggregatedResponse{
 		Data:    data,
-		Errors:  dedupeErrors(aggregationErrors),
-		Partial: len(aggregationErrors) > 0,
+		Errors:  aggregationErrors.errors(),
+		Partial: !aggregationErrors.empty(),
 	}
 
 	return aggregationResponse
 }
 
+// upstreamErrors returns the errors reported by the upstream itself, e.g. GraphQL errors,
+// or the error mapped from the upstream error kind.
+func (a *defaultAggregator) upstreamErrors(err *UpstreamError) []JSONError {
+	if len(err.Details) > 0 {
+		return err.Details
+	}
+
+	return []JSONError{a.mapUpstreamError(err)}
+}
+
+// responseErrors returns the deduplicated errors of a single failed upstream.
+func (a *defaultAggregator) responseErrors(err *UpstreamError) []JSONError {
+	var errs errorList
+
+	errs.addUpstream(err, a.upstreamErrors(err))
+
+	return errs.errors()
+}
+
+// errorList collects the errors of an aggregated response. Errors mapped by the gateway are deduplicated by
+// code, errors reported by the upstream itself, e.g. GraphQL errors, by code and message since they share codes.
+type errorList struct {
+	mapped   []JSONError
+	reported []JSONError
+}
+
+// addUpstream adds the errors returned by upstreamErrors for err.
+func (l *errorList) addUpstream(err *UpstreamError, errs []JSONError) {
+	if len(err.Details) > 0 {
+		l.reported = append(l.reported, errs...)
+		return
+	}
+
+	l.mapped = append(l.mapped, errs...)
+}
+
+func (l *errorList) empty() bool {
+	return len(l.mapped) == 0 && len(l.reported) == 0
+}
+
+func (l *errorList) errors() []JSONError {
+	return append(dedupeErrors(l.mapped), dedupeReportedErrors(l.reported)...)
+}
+
+// hasPartialData reports whether the failed upstream response still carries data.
+func hasPartialData(resp UpstreamResponse) bool {
+	return resp.Err != nil && resp.Err.Kind == UpstreamPartial && resp.Body != nil
+}
+
 func (a *defaultAggregator) mapUpstreamError(err error) JSONError {
 	var ue *UpstreamError
 
@@ -213,7 +276,7 @@ func (a *defaultAggregator) mapUpstreamError(err error) JSONError {
 			Code:    ErrorCodeUpstreamUnavailable,
 			Message: "service temporarily unavailable",
 		}
-	case UpstreamBadStatus:
+	case UpstreamBadStatus, UpstreamClientError:
 		return JSONError{
 			Code:    ErrorCodeUpstreamError,
 			Message: "upstream error",
@@ -267,3 +330,22 @@ func dedupeErrors(errs []JSONError) []JSONError {
 
 	return out
 }
+
+func dedupeReportedErrors(errs []JSONError) []JSONError {
+	type errorKey struct{ code, message string }
+
+	seen := make(map[errorKey]struct{})
+	out := make([]JSONError, 0, len(errs))
+
+	for _, e := range errs {
+		key := errorKey{e.Code, e.Message}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		out = append(out, e)
+	}
+
+	return out
+}
@@ -2,12 +2,15 @@ package kono
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect"
 	"testing"
 
 	"go.uber.org/zap"
 )
 
+var errUpstream = errors.New("upstream failed")
+
 func newTestAggregator() *defaultAggregator {
 	return &defaultAggregator{log: zap.NewNop()}
 }
@@ -153,3 +156,58 @@ func TestAggregator_RawResponse(t *testing.T) {
 		t.Errorf("got %s, want %s", string(aggregated.Data), string([]byte(`{"a":1}`)))
 	}
 }
+
+func TestAggregator_DedupesErrorsByCode(t *testing.T) {
+	agg := newTestAggregator()
+
+	responses := makeUpstreamResponses([][]byte{nil, nil, nil, []byte(`{"a":1}`)}, []*UpstreamError{
+		{Kind: UpstreamTimeout, Err: errUpstream},
+		{Kind: UpstreamConnection, Err: errUpstream},
+		{Kind: UpstreamBadStatus, Err: errUpstream},
+		nil,
+	})
+
+	aggregated := agg.aggregate(responses, AggregationConfig{
+		Strategy:            strategyArray,
+		AllowPartialResults: true,
+	})
+
+	want := []JSONError{
+		{Code: ErrorCodeUpstreamUnavailable, Message: "service temporarily unavailable"},
+		{Code: ErrorCodeUpstreamError, Message: "upstream error"},
+	}
+
+	if !reflect.DeepEqual(aggregated.Errors, want) {
+		t.Errorf("got %+v, want %+v", aggregated.Errors, want)
+	}
+}
+
+func TestAggregator_DedupesReportedErrorsByMessage(t *testing.T) {
+	agg := newTestAggregator()
+
+	reported := []JSONError{
+		{Code: ErrorCodeUpstreamError, Message: "orders unavailable"},
+		{Code: ErrorCodeUpstreamError, Message: "users unavailable"},
+		{Code: ErrorCodeUpstreamError, Message: "orders unavailable"},
+	}
+
+	responses := makeUpstreamResponses([][]byte{[]byte(`{"a":1}`), nil}, []*UpstreamError{
+		{Kind: UpstreamPartial, Err: errUpstream, Details: reported},
+		{Kind: UpstreamBadStatus, Err: errUpstream},
+	})
+
+	aggregated := agg.aggregate(responses, AggregationConfig{
+		Strategy:            strategyMerge,
+		AllowPartialResults: true,
+	})
+
+	want := []JSONError{
+		{Code: ErrorCodeUpstreamError, Message: "upstream error"},
+		reported[0],
+		reported[1],
+	}
+
+	if !reflect.DeepEqual(aggregated.Errors, want) {
+		t.Errorf("got %+v, want %+v", aggregated.Errors, want)
+	}
+}
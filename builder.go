@@ -4,7 +4,6 @@ import (
 	"net/http"
 	"slices"
 	"strings"
-	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -90,19 +89,16 @@ func initPlugins(cfgs []PluginConfig, log *zap.Logger) []Plugin {
 	return plugins
 }
 
-func initUpstreams(cfgs []UpstreamConfig) []Upstream {
+func initUpstreams(cfgs []UpstreamConfig, transports *transportRegistry, log *zap.Logger) []Upstream {
 	upstreams := make([]Upstream, 0, len(cfgs))
 
-	//nolint:mnd // be configurable in future
-	transport := &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		IdleConnTimeout:     90 * time.Second,
-		ForceAttemptHTTP2:   true,
-	}
-
 	// Build upstream policy
 	for _, cfg := range cfgs {
+		if len(cfg.Canary.Variants) > 0 {
+			upstreams = append(upstreams, newCanaryUpstream(cfg, transports, log))
+			continue
+		}
+
 		policy := Policy{
 			AllowedStatuses:     cfg.Policy.AllowedStatuses,
 			RequireBody:         cfg.Policy.RequireBody,
@@ -127,22 +123,80 @@ func initUpstreams(cfgs []UpstreamConfig) []Upstream {
 
 		name := cfg.Name
 		if name == "" {
-			makeUpstreamName(cfg.Method, cfg.Hosts)
+			method := cfg.Method
+			if method == "" {
+				method = cfg.Type
+			}
+
+			name = makeUpstreamName(method, cfg.Hosts)
+		}
+
+		if cfg.Type == UpstreamTypeStatic || cfg.Type == UpstreamTypeMock {
+			static, err := newStaticUpstream(name, cfg.Timeout, policy, cfg.Static)
+			if err != nil {
+				log.Error("cannot initialize static upstream", zap.String("upstream", name), zap.Error(err))
+				panic("cannot initialize static upstream")
+			}
+
+			upstreams = append(upstreams, static)
+
+			continue
+		}
+
+		transportCfg := cfg.Transport
+		if cfg.Type == UpstreamTypeGRPC {
+			// gRPC requires HTTP/2, plain text hosts are dialed with prior knowledge.
+			transportCfg.UnencryptedHTTP2 = true
+			transportCfg.DisableHTTP2 = false
+		}
+
+		transport, err := transports.get(cfg.TLS, transportCfg)
+		if err != nil {
+			log.Error("cannot initialize upstream transport", zap.String("upstream", name), zap.Error(err))
+			panic("cannot initialize upstream transport")
 		}
 
+		health := newHostHealth(cfg.Hosts)
+
 		upstream := &httpUpstream{
 			id:                  uuid.NewString(),
 			name:                name,
 			hosts:               cfg.Hosts,
+			health:              health,
 			method:              cfg.Method,
 			timeout:             cfg.Timeout,
 			forwardHeaders:      cfg.ForwardHeaders,
 			forwardQueryStrings: cfg.ForwardQueryStrings,
 			policy:              policy,
 			client: &http.Client{
-				Transport: transport,
+				Transport: &healthTransport{next: transport, hosts: health},
 			},
 			circuitBreaker: circuitBreaker,
+			faults:         newFaultInjector(cfg.Faults),
+			log:            log.Named("upstream"),
+		}
+
+		switch cfg.Type {
+		case UpstreamTypeGRPC, UpstreamTypeGRPCWeb:
+			grpc, err := newGRPCUpstream(upstream, cfg.Type, cfg.GRPC)
+			if err != nil {
+				log.Error("cannot initialize grpc upstream", zap.String("upstream", name), zap.Error(err))
+				panic("cannot initialize grpc upstream")
+			}
+
+			upstreams = append(upstreams, grpc)
+
+			continue
+		case UpstreamTypeGraphQL:
+			graphql, err := newGraphQLUpstream(upstream, cfg.GraphQL)
+			if err != nil {
+				log.Error("cannot initialize graphql upstream", zap.String("upstream", name), zap.Error(err))
+				panic("cannot initialize graphql upstream")
+			}
+
+			upstreams = append(upstreams, graphql)
+
+			continue
 		}
 
 		upstreams = append(upstreams, upstream)
@@ -155,7 +209,10 @@ func makeUpstreamName(method string, hosts []string) string {
 	sb := strings.Builder{}
 
 	sb.WriteString(strings.ToUpper(method))
-	sb.WriteString("-")
+
+	if len(hosts) > 0 {
+		sb.WriteString("-")
+	}
 
 	for i, host := range hosts {
 		sb.WriteString(host)
@@ -168,7 +225,13 @@ func makeUpstreamName(method string, hosts []string) string {
 	return sb.String()
 }
 
-func initRoute(cfg RouteConfig, globalMiddlewares []Middleware, globalMiddlewareIndices map[string]int, log *zap.Logger) Route {
+func initRoute(
+	cfg RouteConfig,
+	globalMiddlewares []Middleware,
+	globalMiddlewareIndices map[string]int,
+	transports *transportRegistry,
+	log *zap.Logger,
+) Route {
 	var (
 		globalMiddlewaresCopy = append([]Middleware(nil), globalMiddlewares...)
 		localMiddlewares      = make([]Middleware, 0, len(cfg.Middlewares))
@@ -200,13 +263,30 @@ func initRoute(cfg RouteConfig, globalMiddlewares []Middleware, globalMiddleware
 
 	middlewares := append(globalMiddlewaresCopy, localMiddlewares...) //nolint:gocritic // because i am retard
 
+	rateLimits, err := initRateLimits(cfg.RateLimits)
+	if err != nil {
+		log.Error("cannot initialize route rate limits", zap.String("route", cfg.Method+" "+cfg.Path), zap.Error(err))
+		panic("cannot initialize route rate limits")
+	}
+
+	var websocket *WebSocketProxy
+	if cfg.Type == RouteTypeWebSocket {
+		websocket = newWebSocketProxy(cfg.WebSocket)
+	}
+
 	return Route{
 		Path:                 cfg.Path,
 		Method:               cfg.Method,
-		Upstreams:            initUpstreams(cfg.Upstreams),
+		Type:                 cfg.Type,
+		Upstreams:            initUpstreams(cfg.Upstreams, transports, log),
 		Aggregation:          cfg.Aggregation,
 		MaxParallelUpstreams: cfg.MaxParallelUpstreams,
 		Plugins:              initPlugins(cfg.Plugins, log),
 		Middlewares:          middlewares,
+		RateLimits:           rateLimits,
+		Concurrency:          newConcurrencyLimit(cfg.Concurrency),
+		ClientCert:           cfg.ClientCert,
+		Mirror:               newMirror(cfg.Mirror, transports, log),
+		WebSocket:            websocket,
 	}
 }
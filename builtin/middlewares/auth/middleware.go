@@ -157,6 +157,7 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 		}
 
 		ctx := context.WithValue(r.Context(), ctxKeyClaims{}, claims)
+		ctx = kono.WithClaims(ctx, *claims)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -58,14 +58,15 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !strings.Contains(r.Header.Get("Accept-Encoding"), m.alg) {
+		// Upgraded connections are proxied as is.
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), m.alg) || r.Header.Get("Upgrade") != "" {
 			next.ServeHTTP(w, r)
 			return
 		}
 
 		w.Header().Set("Content-Encoding", m.alg)
 
-		var writer io.WriteCloser
+		var writer flushWriteCloser
 		var err error
 
 		switch m.alg {
@@ -96,11 +97,35 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 	})
 }
 
+type flushWriteCloser interface {
+	io.WriteCloser
+	Flush() error
+}
+
 type compressorResponseWriter struct {
 	http.ResponseWriter
-	Writer io.Writer
+	Writer flushWriteCloser
+}
+
+// WriteHeader drops the upstream Content-Length, it does not match the compressed body.
+func (w *compressorResponseWriter) WriteHeader(code int) {
+	w.ResponseWriter.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(code)
 }
 
 func (w *compressorResponseWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
+
+// Flush writes pending compressed data to the client, so streamed responses are not held back.
+func (w *compressorResponseWriter) Flush() {
+	if err := w.Writer.Flush(); err != nil {
+		return
+	}
+
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *compressorResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
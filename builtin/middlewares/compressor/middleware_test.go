@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"compress/flate"
 	"compress/gzip"
 	"io"
@@ -101,3 +102,50 @@ func TestCompressorMiddleware_NoEncodingHeader(t *testing.T) {
 		t.Fatalf("unexpected body: %s", rec.Body.String())
 	}
 }
+
+func TestCompressorMiddleware_FlushStreamsChunks(t *testing.T) {
+	m := &Middleware{
+		enabled: true,
+		alg:     "gzip",
+	}
+
+	rec := httptest.NewRecorder()
+
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("data: first\n\n"))
+
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Fatalf("flush: %v", err)
+		}
+
+		// The first chunk must be decodable before the handler returns.
+		r, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to create gzip reader: %v", err)
+		}
+
+		chunk := make([]byte, len("data: first\n\n"))
+		if _, err = io.ReadFull(r, chunk); err != nil {
+			t.Fatalf("flushed chunk is not decodable: %v", err)
+		}
+
+		if string(chunk) != "data: first\n\n" {
+			t.Fatalf("unexpected flushed chunk: %q", chunk)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	handler.ServeHTTP(rec, req)
+
+	if !rec.Flushed {
+		t.Fatal("expected the response to be flushed")
+	}
+
+	if rec.Header().Get("Content-Length") != "" {
+		t.Fatal("expected Content-Length to be dropped for compressed responses")
+	}
+}
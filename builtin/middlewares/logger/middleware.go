@@ -12,10 +12,12 @@ import (
 	"github.com/starwalkn/kono/internal/logger"
 )
 
+// Middleware logs requests. Headers and bodies are masked by the router redactor configured in server.redaction.
 type Middleware struct {
-	enabled bool
-	logBody bool
-	log     *zap.Logger
+	enabled    bool
+	logBody    bool
+	logHeaders bool
+	log        *zap.Logger
 }
 
 func NewMiddleware() kono.Middleware {
@@ -37,6 +39,10 @@ func (m *Middleware) Init(cfg map[string]interface{}) error {
 		m.logBody = val
 	}
 
+	if val, ok := cfg["log_headers"].(bool); ok {
+		m.logHeaders = val
+	}
+
 	m.log = logger.New(false)
 
 	return nil
@@ -49,6 +55,7 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		redactor := kono.RedactorFromContext(r.Context())
 
 		var bodyCopy []byte
 		if m.logBody && r.Body != nil {
@@ -56,11 +63,17 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 			r.Body = io.NopCloser(bytes.NewReader(bodyCopy))
 		}
 
-		m.log.Info("request started",
+		startFields := []zap.Field{
 			zap.String("method", r.Method),
-			zap.String("path", r.URL.Path),
+			zap.String("path", redactor.String(r.URL.Path)),
 			zap.String("remote_addr", r.RemoteAddr),
-		)
+		}
+
+		if m.logHeaders {
+			startFields = append(startFields, zap.Any("headers", redactor.Header(r.Header)))
+		}
+
+		m.log.Info("request started", startFields...)
 
 		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
 		next.ServeHTTP(rec, r)
@@ -71,11 +84,11 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 			zap.Int("status", rec.status),
 			zap.Duration("duration", duration),
 			zap.String("method", r.Method),
-			zap.String("path", r.URL.Path),
+			zap.String("path", redactor.String(r.URL.Path)),
 		}
 
 		if m.logBody && len(bodyCopy) > 0 {
-			fields = append(fields, zap.ByteString("body", bodyCopy))
+			fields = append(fields, zap.String("body", redactor.Body(bodyCopy)))
 		}
 
 		m.log.Info("request completed", fields...)
@@ -91,3 +104,8 @@ func (r *responseRecorder) WriteHeader(code int) {
 	r.status = code
 	r.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap allows http.ResponseController to reach the underlying writer, e.g. to hijack websocket connections.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
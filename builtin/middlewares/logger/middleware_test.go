@@ -10,6 +10,9 @@ import (
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/starwalkn/kono"
+	"github.com/starwalkn/kono/internal/redact"
 )
 
 func newTestLogger(buf *bytes.Buffer) *zap.Logger {
@@ -110,3 +113,52 @@ func TestLoggerMiddleware_LogsBody(t *testing.T) {
 		t.Errorf("expected request body in logs, got: %s", logOutput)
 	}
 }
+
+func TestLoggerMiddleware_Redacts(t *testing.T) {
+	buf := new(bytes.Buffer)
+	m := &Middleware{}
+
+	err := m.Init(map[string]interface{}{
+		"log_body":    true,
+		"log_headers": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.log = newTestLogger(buf)
+
+	// The router stores the redactor of server.redaction in the request context.
+	redactor, err := redact.New(redact.Config{
+		Headers:   []string{"X-Session"},
+		JSONPaths: []string{"*.password", "card.number"},
+		Patterns:  []string{`\d{3}-\d{2}-\d{4}`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	body := `{"user":{"password":"hunter2"},"card":{"number":"4111"},"ssn":"123-45-6789"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("X-Session", "session-id")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(kono.WithRedactor(req.Context(), redactor)))
+
+	logOutput := buf.String()
+
+	for _, secret := range []string{"secret-token", "session-id", "hunter2", "4111", "123-45-6789"} {
+		if strings.Contains(logOutput, secret) {
+			t.Errorf("expected %q to be redacted, got: %s", secret, logOutput)
+		}
+	}
+
+	if !strings.Contains(logOutput, "[REDACTED]") {
+		t.Errorf("expected masked values in logs, got: %s", logOutput)
+	}
+}
@@ -0,0 +1,156 @@
+package kono
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Sticky key sources of canary upstreams.
+const (
+	stickyKeyHeader = "header"
+	stickyKeyCookie = "cookie"
+	stickyKeyClaim  = "claim"
+)
+
+// canaryUpstream splits traffic between weighted variants of an upstream, e.g. 95% to v1 and 5% to v2
+// of a service. Requests with the same sticky key always get the same variant, the override header
+// selects a variant by name. Responses carry the variant name for metrics.
+type canaryUpstream struct {
+	name           string
+	variants       []canaryVariant
+	totalWeight    int
+	stickyType     string
+	stickyName     string
+	overrideHeader string
+}
+
+type canaryVariant struct {
+	name     string
+	weight   int
+	upstream Upstream
+}
+
+func newCanaryUpstream(cfg UpstreamConfig, transports *transportRegistry, log *zap.Logger) *canaryUpstream {
+	name := cfg.Name
+	if name == "" {
+		method := cfg.Method
+		if method == "" {
+			method = cfg.Type
+		}
+
+		var hosts []string
+		for _, v := range cfg.Canary.Variants {
+			hosts = append(hosts, v.Hosts...)
+		}
+
+		name = makeUpstreamName(method, hosts)
+	}
+
+	c := &canaryUpstream{
+		name:           name,
+		stickyType:     cfg.Canary.Sticky.Type,
+		stickyName:     cfg.Canary.Sticky.Name,
+		overrideHeader: cfg.Canary.OverrideHeader,
+	}
+
+	for _, v := range cfg.Canary.Variants {
+		variantCfg := cfg
+		variantCfg.Name = name + "/" + v.Name
+		variantCfg.Hosts = v.Hosts
+		variantCfg.Canary = CanaryConfig{}
+
+		c.variants = append(c.variants, canaryVariant{
+			name:     v.Name,
+			weight:   v.Weight,
+			upstream: initUpstreams([]UpstreamConfig{variantCfg}, transports, log)[0],
+		})
+		c.totalWeight += v.Weight
+	}
+
+	return c
+}
+
+func (c *canaryUpstream) Name() string { return c.name }
+
+// Policy returns the policy shared by all variants.
+func (c *canaryUpstream) Policy() Policy { return c.variants[0].upstream.Policy() }
+
+func (c *canaryUpstream) Call(ctx context.Context, original *http.Request, originalBody []byte) *UpstreamResponse {
+	variant := c.pick(original)
+
+	resp := variant.upstream.Call(ctx, original, originalBody)
+	resp.Variant = variant.name
+
+	return resp
+}
+
+// pick returns the variant named by the override header, otherwise a variant chosen by weight
+// using the sticky key hash, or randomly if the request has no sticky key.
+func (c *canaryUpstream) pick(req *http.Request) *canaryVariant {
+	if c.overrideHeader != "" {
+		if name := req.Header.Get(c.overrideHeader); name != "" {
+			for i := range c.variants {
+				if c.variants[i].name == name {
+					return &c.variants[i]
+				}
+			}
+		}
+	}
+
+	var n int
+
+	if key := c.stickyKey(req); key != "" {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(key))
+		n = int(h.Sum32() % uint32(c.totalWeight)) //nolint:gosec // total weight is positive
+	} else {
+		n = rand.IntN(c.totalWeight) //nolint:gosec // not security sensitive
+	}
+
+	for i := range c.variants {
+		if n < c.variants[i].weight {
+			return &c.variants[i]
+		}
+
+		n -= c.variants[i].weight
+	}
+
+	return &c.variants[len(c.variants)-1]
+}
+
+func (c *canaryUpstream) stickyKey(req *http.Request) string {
+	switch c.stickyType {
+	case stickyKeyHeader:
+		return req.Header.Get(c.stickyName)
+	case stickyKeyCookie:
+		cookie, err := req.Cookie(c.stickyName)
+		if err != nil {
+			return ""
+		}
+
+		return cookie.Value
+	case stickyKeyClaim:
+		return claimValue(req, c.stickyName)
+	default:
+		return ""
+	}
+}
+
+// responseVariants returns the canary variants that served the responses, for the route metrics label.
+func responseVariants(responses []UpstreamResponse) string {
+	var variants []string
+
+	for _, resp := range responses {
+		if resp.Variant != "" && !slices.Contains(variants, resp.Variant) {
+			variants = append(variants, resp.Variant)
+		}
+	}
+
+	return strings.Join(variants, ",")
+}
@@ -0,0 +1,214 @@
+package kono
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+func newVariantServer(t *testing.T, name string) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"version":"` + name + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+func newTestCanaryUpstream(t *testing.T, canary CanaryConfig) Upstream {
+	t.Helper()
+
+	for i := range canary.Variants {
+		canary.Variants[i].Hosts = []string{newVariantServer(t, canary.Variants[i].Name)}
+	}
+
+	return initUpstreams([]UpstreamConfig{
+		{Name: "orders", Method: http.MethodGet, Timeout: time.Second, Canary: canary},
+	}, newTransportRegistry(), zap.NewNop())[0]
+}
+
+func callVariant(t *testing.T, upstream Upstream, req *http.Request) string {
+	t.Helper()
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err != nil {
+		t.Fatalf("unexpected error: %v", resp.Err.Unwrap())
+	}
+
+	if string(resp.Body) != `{"version":"`+resp.Variant+`"}` {
+		t.Fatalf("variant %q does not match the response %s", resp.Variant, resp.Body)
+	}
+
+	return resp.Variant
+}
+
+func TestCanary_WeightedSplit(t *testing.T) {
+	upstream := newTestCanaryUpstream(t, CanaryConfig{
+		Variants: []UpstreamVariantConfig{{Name: "v1", Weight: 80}, {Name: "v2", Weight: 20}},
+	})
+
+	if upstream.Name() != "orders" {
+		t.Fatalf("unexpected name %q", upstream.Name())
+	}
+
+	counts := make(map[string]int)
+
+	for range 500 {
+		counts[callVariant(t, upstream, httptest.NewRequest(http.MethodGet, "/orders", nil))]++
+	}
+
+	if counts["v2"] < 50 || counts["v2"] > 150 {
+		t.Fatalf("expected about 20%% of requests on v2, got %v", counts)
+	}
+}
+
+func TestCanary_Sticky(t *testing.T) {
+	tests := []struct {
+		name   string
+		sticky StickyConfig
+		with   func(req *http.Request, key string) *http.Request
+	}{
+		{
+			name:   "header",
+			sticky: StickyConfig{Type: "header", Name: "X-User-ID"},
+			with: func(req *http.Request, key string) *http.Request {
+				req.Header.Set("X-User-ID", key)
+				return req
+			},
+		},
+		{
+			name:   "cookie",
+			sticky: StickyConfig{Type: "cookie", Name: "session"},
+			with: func(req *http.Request, key string) *http.Request {
+				req.AddCookie(&http.Cookie{Name: "session", Value: key})
+				return req
+			},
+		},
+		{
+			name:   "claim",
+			sticky: StickyConfig{Type: "claim", Name: "sub"},
+			with: func(req *http.Request, key string) *http.Request {
+				return req.WithContext(WithClaims(req.Context(), map[string]any{"sub": key}))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := newTestCanaryUpstream(t, CanaryConfig{
+				Variants: []UpstreamVariantConfig{{Name: "v1", Weight: 50}, {Name: "v2", Weight: 50}},
+				Sticky:   tt.sticky,
+			})
+
+			seen := make(map[string]bool)
+
+			for _, key := range []string{"alice", "bob", "carol", "dave", "erin", "frank"} {
+				first := callVariant(t, upstream, tt.with(httptest.NewRequest(http.MethodGet, "/", nil), key))
+				seen[first] = true
+
+				for range 10 {
+					if got := callVariant(t, upstream, tt.with(httptest.NewRequest(http.MethodGet, "/", nil), key)); got != first {
+						t.Fatalf("expected %s to stick to %s, got %s", key, first, got)
+					}
+				}
+			}
+
+			if len(seen) != 2 {
+				t.Fatalf("expected keys to be spread over both variants, got %v", seen)
+			}
+		})
+	}
+}
+
+func TestCanary_OverrideHeader(t *testing.T) {
+	upstream := newTestCanaryUpstream(t, CanaryConfig{
+		Variants:       []UpstreamVariantConfig{{Name: "v1", Weight: 100}, {Name: "v2", Weight: 0}},
+		OverrideHeader: "X-Kono-Variant",
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := callVariant(t, upstream, req); got != "v1" {
+		t.Fatalf("expected v1 without override, got %s", got)
+	}
+
+	req.Header.Set("X-Kono-Variant", "v2")
+	if got := callVariant(t, upstream, req); got != "v2" {
+		t.Fatalf("expected the override to select v2, got %s", got)
+	}
+
+	req.Header.Set("X-Kono-Variant", "v3")
+	if got := callVariant(t, upstream, req); got != "v1" {
+		t.Fatalf("expected unknown variants to be ignored, got %s", got)
+	}
+}
+
+type variantMetrics struct {
+	metric.Metrics
+
+	mu        sync.Mutex
+	latency   []string
+	responses []string
+}
+
+func (m *variantMetrics) UpdateUpstreamLatency(_, _, upstream, variant string, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.latency = append(m.latency, upstream+"/"+variant)
+}
+
+func (m *variantMetrics) IncResponsesTotal(_ string, _ int, variant string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.responses = append(m.responses, variant)
+}
+
+func TestCanary_MetricsLabels(t *testing.T) {
+	metrics := &variantMetrics{Metrics: metric.NewNop()}
+
+	r := &Router{
+		dispatcher: &defaultDispatcher{log: zap.NewNop(), metrics: metrics},
+		aggregator: &defaultAggregator{log: zap.NewNop()},
+		Routes: []Route{
+			{
+				Path:   "/orders",
+				Method: http.MethodGet,
+				Upstreams: []Upstream{newTestCanaryUpstream(t, CanaryConfig{
+					Variants:       []UpstreamVariantConfig{{Name: "v1", Weight: 1}, {Name: "v2", Weight: 0}},
+					OverrideHeader: "X-Kono-Variant",
+				})},
+				Aggregation:          AggregationConfig{Strategy: strategyMerge},
+				MaxParallelUpstreams: 1,
+			},
+		},
+		log:     zap.NewNop(),
+		metrics: metrics,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("X-Kono-Variant", "v2")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"data":{"version":"v2"}}` {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+
+	if len(metrics.latency) != 1 || metrics.latency[0] != "orders/v2" {
+		t.Fatalf("expected latency labeled with the variant, got %v", metrics.latency)
+	}
+
+	if len(metrics.responses) != 1 || metrics.responses[0] != "v2" {
+		t.Fatalf("expected responses labeled with the variant, got %v", metrics.responses)
+	}
+}
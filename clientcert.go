@@ -0,0 +1,117 @@
+package kono
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	headerClientCertSubject     = "X-Client-Cert-Subject"
+	headerClientCertSAN         = "X-Client-Cert-San"
+	headerClientCertFingerprint = "X-Client-Cert-Fingerprint"
+)
+
+// ClientCertificate describes a verified client certificate of a mutual TLS connection.
+type ClientCertificate struct {
+	Subject    string   // Full subject in RFC 2253 form.
+	CommonName string   // Subject common name.
+	SANs       []string // DNS names, emails, URIs and IP addresses.
+	// Fingerprint is a lowercase hex SHA-256 of the DER encoded certificate.
+	Fingerprint string
+}
+
+// ClientCertificateFromRequest returns the verified client certificate of the request.
+// Certificates that were presented but not verified against the client CA are never returned.
+func ClientCertificateFromRequest(req *http.Request) (*ClientCertificate, bool) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	return newClientCertificate(req.TLS.VerifiedChains[0][0]), true
+}
+
+func newClientCertificate(cert *x509.Certificate) *ClientCertificate {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.URIs)+len(cert.IPAddresses))
+
+	sans = append(sans, cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	sum := sha256.Sum256(cert.Raw)
+
+	return &ClientCertificate{
+		Subject:     cert.Subject.String(),
+		CommonName:  cert.Subject.CommonName,
+		SANs:        sans,
+		Fingerprint: hex.EncodeToString(sum[:]),
+	}
+}
+
+// checkClientCert verifies the request against the route client certificate requirements.
+// It returns the error code and status to respond with, or an empty code if the request is allowed.
+func checkClientCert(cfg ClientCertConfig, req *http.Request) (string, int) {
+	restricted := len(cfg.AllowedSubjects) > 0 || len(cfg.AllowedSANs) > 0 || len(cfg.AllowedFingerprints) > 0
+
+	if !cfg.Required && !restricted {
+		return "", 0
+	}
+
+	cert, ok := ClientCertificateFromRequest(req)
+	if !ok {
+		return ErrorCodeClientCertRequired, http.StatusUnauthorized
+	}
+
+	if len(cfg.AllowedSubjects) > 0 &&
+		!slices.Contains(cfg.AllowedSubjects, cert.Subject) && !slices.Contains(cfg.AllowedSubjects, cert.CommonName) {
+		return ErrorCodeForbidden, http.StatusForbidden
+	}
+
+	if len(cfg.AllowedSANs) > 0 && !slices.ContainsFunc(cert.SANs, func(san string) bool {
+		return slices.Contains(cfg.AllowedSANs, san)
+	}) {
+		return ErrorCodeForbidden, http.StatusForbidden
+	}
+
+	if len(cfg.AllowedFingerprints) > 0 && !slices.ContainsFunc(cfg.AllowedFingerprints, func(fp string) bool {
+		return normalizeFingerprint(fp) == cert.Fingerprint
+	}) {
+		return ErrorCodeForbidden, http.StatusForbidden
+	}
+
+	return "", 0
+}
+
+// normalizeFingerprint accepts fingerprints in "AB:CD:..." form as printed by openssl.
+func normalizeFingerprint(fp string) string {
+	return strings.ToLower(strings.ReplaceAll(fp, ":", ""))
+}
+
+// setClientCertHeaders removes client certificate headers sent by the client and, if cert is given,
+// sets them from the verified certificate.
+func setClientCertHeaders(header http.Header, cert *ClientCertificate) {
+	header.Del(headerClientCertSubject)
+	header.Del(headerClientCertSAN)
+	header.Del(headerClientCertFingerprint)
+
+	if cert == nil {
+		return
+	}
+
+	header.Set(headerClientCertSubject, cert.Subject)
+	header.Set(headerClientCertFingerprint, cert.Fingerprint)
+
+	if len(cert.SANs) > 0 {
+		header.Set(headerClientCertSAN, strings.Join(cert.SANs, ","))
+	}
+}
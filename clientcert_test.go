@@ -0,0 +1,178 @@
+package kono
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+func (ca *testCA) issue(t *testing.T, cn string, serial int64) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn + ".partners.local"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestRouter_ClientCertRequirements(t *testing.T) {
+	ca := newTestCA(t)
+
+	var upstreamHeaders http.Header
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHeaders = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	router := &Router{
+		dispatcher: &defaultDispatcher{log: zap.NewNop(), metrics: metric.NewNop()},
+		aggregator: &defaultAggregator{log: zap.NewNop()},
+		Routes: []Route{
+			{
+				Path:   "/partners",
+				Method: http.MethodGet,
+				Upstreams: []Upstream{
+					&httpUpstream{
+						hosts:          []string{upstream.URL},
+						timeout:        time.Second,
+						forwardHeaders: []string{"*"},
+						log:            zap.NewNop(),
+						client:         http.DefaultClient,
+					},
+				},
+				MaxParallelUpstreams: maxParallelUpstreams,
+				Aggregation:          AggregationConfig{Strategy: strategyMerge},
+				ClientCert:           ClientCertConfig{AllowedSubjects: []string{"partner-a"}},
+			},
+		},
+		log:               zap.NewNop(),
+		metrics:           metric.NewNop(),
+		forwardClientCert: true,
+	}
+
+	server := httptest.NewUnstartedServer(router)
+	server.TLS = &tls.Config{
+		ClientCAs:  ca.pool,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	get := func(t *testing.T, cert *tls.Certificate) int {
+		t.Helper()
+
+		transport := server.Client().Transport.(*http.Transport).Clone()
+		if cert != nil {
+			transport.TLSClientConfig.Certificates = []tls.Certificate{*cert}
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/partners", nil)
+		req.Header.Set(headerClientCertSubject, "CN=spoofed")
+
+		resp, err := (&http.Client{Transport: transport}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	if got := get(t, nil); got != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without certificate, got %d", got)
+	}
+
+	other := ca.issue(t, "partner-b", 2)
+	if got := get(t, &other); got != http.StatusForbidden {
+		t.Fatalf("expected 403 for not allowed subject, got %d", got)
+	}
+
+	allowed := ca.issue(t, "partner-a", 3)
+	if got := get(t, &allowed); got != http.StatusOK {
+		t.Fatalf("expected 200 for allowed subject, got %d", got)
+	}
+
+	if subject := upstreamHeaders.Get(headerClientCertSubject); subject != "CN=partner-a" {
+		t.Errorf("unexpected forwarded subject: %q", subject)
+	}
+
+	if san := upstreamHeaders.Get(headerClientCertSAN); san != "partner-a.partners.local" {
+		t.Errorf("unexpected forwarded SAN: %q", san)
+	}
+
+	leaf, _ := x509.ParseCertificate(allowed.Certificate[0])
+	if fp := upstreamHeaders.Get(headerClientCertFingerprint); fp != newClientCertificate(leaf).Fingerprint {
+		t.Errorf("unexpected forwarded fingerprint: %q", fp)
+	}
+}
@@ -0,0 +1,251 @@
+package kono
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultClientIPHeader is the forwarding header walked to resolve client addresses by default.
+const defaultClientIPHeader = "X-Forwarded-For"
+
+// clientIPResolver resolves the real client address of requests that came through trusted proxies.
+type clientIPResolver struct {
+	trusted []netip.Prefix
+
+	// header is the forwarding header set by the trusted proxies, other forwarding headers are ignored.
+	header string
+}
+
+// forwardingInfo is the result of client address resolution stored in the request context.
+type forwardingInfo struct {
+	clientIP    string
+	peerTrusted bool // The immediate peer is a trusted proxy, so its forwarding headers can be kept.
+
+	// chain holds the hops of the configured forwarding header sent by a trusted peer, farthest first.
+	chain []string
+
+	clientCert *ClientCertificate // Verified client certificate to forward to upstreams, if enabled.
+}
+
+type ctxKeyForwardingInfo struct{}
+
+// newClientIPResolver parses trusted proxies given as CIDRs or single addresses. The header is the forwarding header
+// they set, X-Forwarded-For if empty.
+func newClientIPResolver(proxies []string, header string) (*clientIPResolver, error) {
+	resolver := &clientIPResolver{
+		trusted: make([]netip.Prefix, 0, len(proxies)),
+		header:  http.CanonicalHeaderKey(cmp.Or(header, defaultClientIPHeader)),
+	}
+
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+			}
+
+			resolver.trusted = append(resolver.trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+
+		resolver.trusted = append(resolver.trusted, prefix.Masked())
+	}
+
+	return resolver, nil
+}
+
+// resolve returns the client address of the request.
+//
+// If the immediate peer is not a trusted proxy, its address is the client address and forwarding
+// headers are ignored. Otherwise, the forwarding chain of the configured header only is walked
+// right-to-left, skipping trusted hops. The first untrusted hop is the client. If every hop is
+// trusted, the leftmost one is used. Other forwarding headers may be set by the client and are
+// never consulted.
+func (r *clientIPResolver) resolve(req *http.Request) forwardingInfo {
+	peer, ok := parseIP(req.RemoteAddr)
+	if !ok {
+		return forwardingInfo{clientIP: remoteHost(req.RemoteAddr)}
+	}
+
+	if !r.isTrusted(peer) {
+		return forwardingInfo{clientIP: peer.String()}
+	}
+
+	info := forwardingInfo{
+		clientIP:    peer.String(),
+		peerTrusted: true,
+	}
+
+	chain := forwardedFor(req.Header, r.header)
+	info.chain = chain
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		hop, valid := parseIP(chain[i])
+		if !valid {
+			// Obfuscated or unknown hop, nothing to the left of it can be verified.
+			return info
+		}
+
+		info.clientIP = hop.String()
+
+		if !r.isTrusted(hop) {
+			return info
+		}
+	}
+
+	return info
+}
+
+func (r *clientIPResolver) isTrusted(addr netip.Addr) bool {
+	if r == nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withForwardingInfo resolves the client address and stores it in the request context.
+// If forwardClientCert is set, the verified client certificate is stored for upstreams as well.
+func (r *clientIPResolver) withForwardingInfo(req *http.Request, forwardClientCert bool) *http.Request {
+	info := r.resolve(req)
+
+	if forwardClientCert {
+		info.clientCert, _ = ClientCertificateFromRequest(req)
+	}
+
+	return req.WithContext(context.WithValue(req.Context(), ctxKeyForwardingInfo{}, info))
+}
+
+func forwardingInfoFromContext(ctx context.Context) (forwardingInfo, bool) {
+	info, ok := ctx.Value(ctxKeyForwardingInfo{}).(forwardingInfo)
+	return info, ok
+}
+
+// ClientIPFromContext returns the client address resolved by the router with respect to trusted proxies.
+func ClientIPFromContext(ctx context.Context) (string, bool) {
+	info, ok := forwardingInfoFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	return info.clientIP, true
+}
+
+// forwardedFor returns the forwarding chain of the header, the leftmost entry being the farthest hop.
+// The Forwarded header is parsed as RFC 7239, other headers as comma separated addresses.
+func forwardedFor(header http.Header, name string) []string {
+	var chain []string
+
+	for _, value := range header.Values(name) {
+		for _, element := range strings.Split(value, ",") {
+			if name == "Forwarded" {
+				chain = append(chain, forwardedElementFor(element))
+			} else {
+				chain = append(chain, strings.TrimSpace(element))
+			}
+		}
+	}
+
+	return chain
+}
+
+// forwardedElementFor returns the "for" parameter of a single Forwarded element,
+// e.g. `for="[2001:db8::17]:4711";proto=https` yields "[2001:db8::17]:4711".
+func forwardedElementFor(element string) string {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || !strings.EqualFold(key, "for") {
+			continue
+		}
+
+		return strings.Trim(value, `"`)
+	}
+
+	return ""
+}
+
+// forwardedElement formats a Forwarded element as RFC 7239, e.g. `for="[2001:db8::17]";proto=https;host=example.com`.
+func forwardedElement(peer, proto, host string) string {
+	if addr, err := netip.ParseAddr(peer); err == nil && addr.Is6() {
+		peer = "[" + peer + "]"
+	}
+
+	var parts []string
+
+	if peer != "" {
+		parts = append(parts, "for="+forwardedValue(peer))
+	}
+
+	parts = append(parts, "proto="+proto)
+
+	if host != "" {
+		parts = append(parts, "host="+forwardedValue(host))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// forwardedValue quotes a Forwarded parameter value unless it is a token.
+func forwardedValue(value string) string {
+	for _, c := range value {
+		if !isTokenChar(c) {
+			return strconv.Quote(value)
+		}
+	}
+
+	return value
+}
+
+func isTokenChar(c rune) bool {
+	return c < utf8.RuneSelf && (c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+		strings.ContainsRune("!#$%&'*+-.^_`|~", c))
+}
+
+// parseIP parses an address with an optional port, IPv6 addresses may be in brackets.
+func parseIP(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return netip.Addr{}, false
+	}
+
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(value, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
+
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err == nil {
+		return host
+	}
+
+	return remoteAddr
+}
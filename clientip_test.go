@@ -0,0 +1,221 @@
+package kono
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+func TestClientIPResolver_Resolve(t *testing.T) {
+	proxies := []string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"}
+
+	tests := []struct {
+		name       string
+		header     string
+		remoteAddr string
+		headers    map[string]string
+		wantIP     string
+		wantPeer   bool
+	}{
+		{
+			name:       "untrusted peer ignores forwarding headers",
+			remoteAddr: "203.0.113.5:1234",
+			headers:    map[string]string{"X-Forwarded-For": "1.1.1.1", "X-Real-IP": "2.2.2.2"},
+			wantIP:     "203.0.113.5",
+		},
+		{
+			name:       "spoofed leftmost entry is skipped",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.7, 10.1.1.1"},
+			wantIP:     "198.51.100.7",
+			wantPeer:   true,
+		},
+		{
+			name:       "all hops trusted yields leftmost",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "192.168.1.1, 10.1.1.1"},
+			wantIP:     "192.168.1.1",
+			wantPeer:   true,
+		},
+		{
+			name:       "client forwarded header is ignored",
+			remoteAddr: "10.0.0.1:1234",
+			headers: map[string]string{
+				"Forwarded":       "for=1.2.3.4",
+				"X-Real-IP":       "5.6.7.8",
+				"X-Forwarded-For": "198.51.100.7",
+			},
+			wantIP:   "198.51.100.7",
+			wantPeer: true,
+		},
+		{
+			name:       "configured forwarded header",
+			header:     "Forwarded",
+			remoteAddr: "10.0.0.1:1234",
+			headers: map[string]string{
+				"Forwarded":       `for="[2001:db8:cafe::17]:4711", for=198.51.100.9;proto=https`,
+				"X-Forwarded-For": "1.1.1.1",
+			},
+			wantIP:   "198.51.100.9",
+			wantPeer: true,
+		},
+		{
+			name:       "unknown hop stops the walk",
+			header:     "forwarded",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"Forwarded": "for=198.51.100.9, for=unknown, for=10.2.2.2"},
+			wantIP:     "10.2.2.2",
+			wantPeer:   true,
+		},
+		{
+			name:       "configured x-real-ip",
+			header:     "X-Real-IP",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.10", "X-Forwarded-For": "1.1.1.1"},
+			wantIP:     "198.51.100.10",
+			wantPeer:   true,
+		},
+		{
+			name:       "no forwarding header yields peer",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.10"},
+			wantIP:     "10.0.0.1",
+			wantPeer:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, err := newClientIPResolver(proxies, tt.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			info := resolver.resolve(req)
+			if info.clientIP != tt.wantIP {
+				t.Errorf("expected client ip %s, got %s", tt.wantIP, info.clientIP)
+			}
+
+			if info.peerTrusted != tt.wantPeer {
+				t.Errorf("expected peer trusted %v, got %v", tt.wantPeer, info.peerTrusted)
+			}
+		})
+	}
+}
+
+func TestNewClientIPResolver_Invalid(t *testing.T) {
+	if _, err := newClientIPResolver([]string{"10.0.0.0/33"}, ""); err == nil {
+		t.Fatal("expected error for invalid CIDR")
+	}
+}
+
+func TestDispatcher_ForwardingHeaders(t *testing.T) {
+	var got http.Header
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	resolver, err := newClientIPResolver([]string{"10.0.0.0/8"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &defaultDispatcher{log: zap.NewNop(), metrics: metric.NewNop()}
+	route := &Route{
+		Upstreams: []Upstream{
+			&httpUpstream{
+				hosts:          []string{upstream.URL},
+				timeout:        time.Second,
+				forwardHeaders: []string{"*"},
+				log:            zap.NewNop(),
+				client:         http.DefaultClient,
+			},
+		},
+		MaxParallelUpstreams: maxParallelUpstreams,
+	}
+
+	t.Run("trusted peer appends", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://gateway.local/test", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.7")
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Header.Set("X-Forwarded-Host", "api.example.com")
+
+		d.dispatch(route, resolver.withForwardingInfo(req, false))
+
+		if xff := got.Get("X-Forwarded-For"); xff != "198.51.100.7, 10.0.0.1" {
+			t.Errorf("unexpected X-Forwarded-For: %q", xff)
+		}
+
+		if xfp := got.Get("X-Forwarded-Proto"); xfp != "https" {
+			t.Errorf("unexpected X-Forwarded-Proto: %q", xfp)
+		}
+
+		if xfh := got.Get("X-Forwarded-Host"); xfh != "api.example.com" {
+			t.Errorf("unexpected X-Forwarded-Host: %q", xfh)
+		}
+	})
+
+	t.Run("untrusted peer replaces", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://gateway.local/test", nil)
+		req.RemoteAddr = "203.0.113.5:1234"
+		req.Header.Set("X-Forwarded-For", "1.1.1.1")
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Header.Set("Forwarded", "for=1.1.1.1")
+
+		d.dispatch(route, resolver.withForwardingInfo(req, false))
+
+		if xff := got.Get("X-Forwarded-For"); xff != "203.0.113.5" {
+			t.Errorf("unexpected X-Forwarded-For: %q", xff)
+		}
+
+		if xfp := got.Get("X-Forwarded-Proto"); xfp != "http" {
+			t.Errorf("unexpected X-Forwarded-Proto: %q", xfp)
+		}
+
+		if xfh := got.Get("X-Forwarded-Host"); xfh != "gateway.local" {
+			t.Errorf("unexpected X-Forwarded-Host: %q", xfh)
+		}
+
+		if fwd := got.Get("Forwarded"); fwd != "for=203.0.113.5;proto=http;host=gateway.local" {
+			t.Errorf("expected Forwarded to be replaced, got %q", fwd)
+		}
+	})
+
+	t.Run("trusted peer sends only Forwarded", func(t *testing.T) {
+		forwardedResolver, ferr := newClientIPResolver([]string{"10.0.0.0/8"}, "Forwarded")
+		if ferr != nil {
+			t.Fatal(ferr)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "http://gateway.local:8080/test", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("Forwarded", `for="[2001:db8::17]:4711";proto=https`)
+
+		d.dispatch(route, forwardedResolver.withForwardingInfo(req, false))
+
+		if xff := got.Get("X-Forwarded-For"); xff != "2001:db8::17, 10.0.0.1" {
+			t.Errorf("unexpected X-Forwarded-For: %q", xff)
+		}
+
+		want := `for="[2001:db8::17]:4711";proto=https, for=10.0.0.1;proto=http;host="gateway.local:8080"`
+		if fwd := got.Get("Forwarded"); fwd != want {
+			t.Errorf("unexpected Forwarded: %q", fwd)
+		}
+	})
+}
@@ -43,12 +43,13 @@ func runServe() error {
 		return err
 	}
 
-	log := logger.New(cfg.Debug)
+	level := logger.Level(cfg.Debug)
+	log := logger.NewWithLevel(level, cfg.Debug)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	server := app.NewServer(cfg, log)
+	server := app.NewServer(cfg, log, level)
 
 	go func() {
 		if err = server.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
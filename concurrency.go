@@ -0,0 +1,116 @@
+package kono
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/starwalkn/kono/internal/concurrency"
+)
+
+// ConcurrencyLimit limits in-flight requests of a route or of the whole gateway and sheds the excess load.
+type ConcurrencyLimit struct {
+	limiter    *concurrency.Limiter
+	retryAfter time.Duration
+}
+
+// newConcurrencyLimit returns nil if the limit is disabled.
+func newConcurrencyLimit(cfg ConcurrencyConfig) *ConcurrencyLimit {
+	if cfg.MaxInFlight <= 0 {
+		return nil
+	}
+
+	var algorithm concurrency.Algorithm
+
+	switch cfg.Adaptive.Algorithm {
+	case "aimd":
+		algorithm = &concurrency.AIMD{
+			BackoffRatio:     cfg.Adaptive.BackoffRatio,
+			LatencyThreshold: cfg.Adaptive.LatencyThreshold,
+		}
+	case "gradient":
+		algorithm = &concurrency.Gradient{
+			Smoothing: cfg.Adaptive.Smoothing,
+		}
+	}
+
+	maxLimit := cfg.MaxInFlight
+	if algorithm != nil {
+		maxLimit = cfg.Adaptive.MaxLimit
+	}
+
+	return &ConcurrencyLimit{
+		limiter: concurrency.New(concurrency.Options{
+			Limit:        cfg.MaxInFlight,
+			MinLimit:     cfg.Adaptive.MinLimit,
+			MaxLimit:     maxLimit,
+			QueueSize:    cfg.QueueSize,
+			QueueTimeout: cfg.QueueTimeout,
+			Algorithm:    algorithm,
+		}),
+		retryAfter: cfg.RetryAfter,
+	}
+}
+
+// Limit returns the current in-flight limit.
+func (c *ConcurrencyLimit) Limit() int { return c.limiter.Limit() }
+
+// InFlight returns the number of in-flight requests.
+func (c *ConcurrencyLimit) InFlight() int { return c.limiter.InFlight() }
+
+// Queued returns the number of requests waiting for a slot.
+func (c *ConcurrencyLimit) Queued() int { return c.limiter.Queued() }
+
+// acquire takes an in-flight slot. If the load is shed, it writes 503 with Retry-After and returns false.
+// A nil limit always allows.
+func (c *ConcurrencyLimit) acquire(w http.ResponseWriter, req *http.Request, requestID string) (*concurrency.Token, bool) {
+	if c == nil {
+		return nil, true
+	}
+
+	token, err := c.limiter.Acquire(req.Context())
+	if err == nil {
+		return token, true
+	}
+
+	if errors.Is(err, concurrency.ErrLimitExceeded) || errors.Is(err, concurrency.ErrQueueTimeout) {
+		retryAfter := int(math.Ceil(c.retryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		WriteError(w, ErrorCodeOverloaded, "service overloaded", requestID, http.StatusServiceUnavailable)
+
+		return nil, false
+	}
+
+	// The client has gone while waiting in the queue, nobody reads the response.
+	return nil, false
+}
+
+// releaseSlot releases a token returned by acquire. Nil tokens are ignored.
+func releaseSlot(token *concurrency.Token, dropped bool) {
+	if token != nil {
+		token.Release(dropped)
+	}
+}
+
+// isOverloadResponse reports whether the upstream responses signal overload,
+// so adaptive limits decrease on them.
+func isOverloadResponse(responses []UpstreamResponse) bool {
+	for _, resp := range responses {
+		if resp.Err == nil {
+			continue
+		}
+
+		switch resp.Err.Kind { //nolint:exhaustive // only overload related kinds
+		case UpstreamTimeout, UpstreamConnection, UpstreamCircuitOpen:
+			return true
+		}
+
+		if resp.Status == http.StatusServiceUnavailable || resp.Status == http.StatusTooManyRequests {
+			return true
+		}
+	}
+
+	return false
+}
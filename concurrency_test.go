@@ -0,0 +1,204 @@
+package kono
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/starwalkn/kono/internal/concurrency"
+)
+
+type blockingDispatcher struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingDispatcher) dispatch(_ *Route, _ *http.Request) []UpstreamResponse {
+	b.started <- struct{}{}
+	<-b.release
+
+	return []UpstreamResponse{{Status: http.StatusOK, Body: []byte(`{}`)}}
+}
+
+func newBlockingDispatcher() *blockingDispatcher {
+	return &blockingDispatcher{
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func newConcurrencyTestRoute(cfg ConcurrencyConfig) Route {
+	return Route{
+		Path:        "/slow",
+		Method:      http.MethodGet,
+		Aggregation: AggregationConfig{Strategy: strategyMerge},
+		Concurrency: newConcurrencyLimit(cfg),
+	}
+}
+
+func TestConcurrency_ShedsWithoutQueue(t *testing.T) {
+	d := newBlockingDispatcher()
+	r := newTestRouter(d, newConcurrencyTestRoute(ConcurrencyConfig{MaxInFlight: 1, RetryAfter: 2 * time.Second}))
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		serveStatus(r, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+
+	<-d.started
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+
+	if ra := rec.Header().Get("Retry-After"); ra != "2" {
+		t.Fatalf("expected Retry-After 2, got %q", ra)
+	}
+
+	close(d.release)
+	wg.Wait()
+
+	if got := serveStatus(r, httptest.NewRequest(http.MethodGet, "/slow", nil)); got != http.StatusOK {
+		t.Fatalf("expected 200 after the slot is released, got %d", got)
+	}
+}
+
+func TestConcurrency_QueueWaitsForSlot(t *testing.T) {
+	d := newBlockingDispatcher()
+	r := newTestRouter(d, newConcurrencyTestRoute(ConcurrencyConfig{
+		MaxInFlight:  1,
+		QueueSize:    1,
+		QueueTimeout: time.Second,
+	}))
+
+	codes := make(chan int, 2)
+
+	for range 2 {
+		go func() {
+			codes <- serveStatus(r, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		}()
+	}
+
+	<-d.started
+
+	// The second request is queued until the first one completes.
+	select {
+	case <-d.started:
+		t.Fatal("queued request must not be dispatched before a slot is free")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	d.release <- struct{}{}
+	<-d.started
+	d.release <- struct{}{}
+
+	for range 2 {
+		if code := <-codes; code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+	}
+}
+
+func TestConcurrency_QueueTimeout(t *testing.T) {
+	d := newBlockingDispatcher()
+	r := newTestRouter(d, newConcurrencyTestRoute(ConcurrencyConfig{
+		MaxInFlight:  1,
+		QueueSize:    1,
+		QueueTimeout: 20 * time.Millisecond,
+		RetryAfter:   time.Second,
+	}))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		serveStatus(r, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+
+	<-d.started
+
+	if got := serveStatus(r, httptest.NewRequest(http.MethodGet, "/slow", nil)); got != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 after queue timeout, got %d", got)
+	}
+
+	close(d.release)
+	<-done
+}
+
+func TestConcurrency_AIMDAdaptsLimit(t *testing.T) {
+	limit := newConcurrencyLimit(ConcurrencyConfig{
+		MaxInFlight: 10,
+		Adaptive: AdaptiveConcurrencyConfig{
+			Algorithm:    "aimd",
+			MinLimit:     2,
+			MaxLimit:     20,
+			BackoffRatio: 0.5,
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	token, ok := limit.acquire(httptest.NewRecorder(), req, "")
+	if !ok {
+		t.Fatal("expected slot")
+	}
+
+	releaseSlot(token, true)
+
+	if got := limit.Limit(); got != 5 {
+		t.Fatalf("expected limit 5 after drop, got %d", got)
+	}
+
+	for range 10 {
+		token, _ = limit.acquire(httptest.NewRecorder(), req, "")
+		releaseSlot(token, true)
+	}
+
+	if got := limit.Limit(); got != 2 {
+		t.Fatalf("expected limit clamped to 2, got %d", got)
+	}
+
+	// Growth happens only when the limit is utilized.
+	tokens := make([]*concurrency.Token, 0, 2)
+	for range 2 {
+		token, ok = limit.acquire(httptest.NewRecorder(), req, "")
+		if !ok {
+			t.Fatal("expected slot")
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	for _, tk := range tokens {
+		releaseSlot(tk, false)
+	}
+
+	if got := limit.Limit(); got != 3 {
+		t.Fatalf("expected limit 3 after successful utilized requests, got %d", got)
+	}
+}
+
+func TestConcurrency_AdaptiveMaxLimitDefault(t *testing.T) {
+	cfg := ConcurrencyConfig{MaxInFlight: 10, Adaptive: AdaptiveConcurrencyConfig{Algorithm: "gradient"}}
+	ensureConcurrencyDefaults(&cfg)
+
+	if cfg.Adaptive.MaxLimit != 10 {
+		t.Fatalf("expected max limit 10, got %d", cfg.Adaptive.MaxLimit)
+	}
+
+	cfg = ConcurrencyConfig{MaxInFlight: 10}
+	ensureConcurrencyDefaults(&cfg)
+
+	if cfg.Adaptive.MaxLimit != 0 {
+		t.Fatalf("expected no max limit without adaptive mode, got %d", cfg.Adaptive.MaxLimit)
+	}
+}
@@ -1,24 +1,49 @@
 package kono
 
 import (
+	"cmp"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v3"
 )
 
 const (
 	defaultUpstreamTimeout = 3 * time.Second
 	defaultServerTimeout   = 5 * time.Second
+	defaultRateLimitWindow = time.Minute
+	defaultRetryAfter      = time.Second
+	defaultTLSReload       = 30 * time.Second
+
+	defaultMetricsHost         = "127.0.0.1"
+	defaultMetricsPath         = "/metrics"
+	defaultMetricsPushPath     = "/api/v1/import/prometheus"
+	defaultMetricsPushInterval = 10 * time.Second
+
+	defaultAccessLogUserClaim = "sub"
+
+	defaultDashboardJWTMiddleware = "auth"
+	defaultDashboardRoleClaim     = "role"
+
+	defaultDialTimeout         = 30 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 10
 )
 
 type Config struct {
@@ -28,6 +53,7 @@ type Config struct {
 	Debug         bool               `json:"debug" yaml:"debug" toml:"debug"`
 	Server        ServerConfig       `json:"server" yaml:"server" toml:"server"`
 	Dashboard     DashboardConfig    `json:"dashboard" yaml:"dashboard" toml:"dashboard"`
+	Admin         AdminConfig        `json:"admin" yaml:"admin" toml:"admin"`
 	Features      []FeatureConfig    `json:"features" yaml:"features" toml:"features"`
 	Middlewares   []MiddlewareConfig `json:"middlewares" yaml:"middlewares" toml:"middlewares"`
 	Routes        []RouteConfig      `json:"routes" yaml:"routes" toml:"routes" validate:"min=1,dive"`
@@ -37,51 +63,396 @@ type ServerConfig struct {
 	Port    int           `json:"port" yaml:"port" toml:"port" validate:"required,min=1,max=65535"`
 	Timeout time.Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
 	Metrics MetricsConfig `json:"metrics" yaml:"metrics" toml:"metrics"`
+
+	// TrustedProxies lists CIDRs or addresses of proxies whose forwarding headers are trusted
+	// when resolving the client address.
+	TrustedProxies []string `json:"trusted_proxies" yaml:"trusted_proxies" toml:"trusted_proxies" validate:"dive,cidr|ip"`
+
+	// ClientIPHeader is the forwarding header set by the trusted proxies, X-Forwarded-For by default.
+	// Forwarded is parsed as RFC 7239, e.g. X-Real-IP holds a single address.
+	ClientIPHeader string `json:"client_ip_header" yaml:"client_ip_header" toml:"client_ip_header"`
+
+	// Concurrency limits in-flight requests of the whole gateway.
+	Concurrency ConcurrencyConfig `json:"concurrency" yaml:"concurrency" toml:"concurrency"`
+
+	TLS ServerTLSConfig `json:"tls" yaml:"tls" toml:"tls"`
+
+	Tracing TracingConfig `json:"tracing" yaml:"tracing" toml:"tracing"`
+
+	AccessLog AccessLogConfig `json:"access_log" yaml:"access_log" toml:"access_log"`
+
+	// Redaction masks sensitive data in logged headers and bodies.
+	Redaction RedactionConfig `json:"redaction" yaml:"redaction" toml:"redaction"`
+}
+
+// RedactionConfig configures masking of logged data in debug logs, the access log and the logger middleware.
+// Headers and query parameters are added to the default deny-lists (Authorization, Cookie, api_key, token, ...).
+// JSON paths are dot separated, "*" matches any nesting, e.g. "*.password". MaxBodySize limits logged bodies in
+// bytes (default 4096), -1 disables truncation.
+type RedactionConfig struct {
+	Headers     []string `json:"headers" yaml:"headers" toml:"headers"`
+	QueryParams []string `json:"query_params" yaml:"query_params" toml:"query_params" validate:"dive,required"`
+	JSONPaths   []string `json:"json_paths" yaml:"json_paths" toml:"json_paths" validate:"dive,required"`
+	Patterns    []string `json:"patterns" yaml:"patterns" toml:"patterns" validate:"dive,regexp"`
+	MaxBodySize int      `json:"max_body_size" yaml:"max_body_size" toml:"max_body_size" validate:"min=-1"`
+}
+
+// AccessLogConfig enables the access log with one entry per request, written separately from the application log.
+// Fields apply to the json and logfmt formats, combined is the fixed Apache combined format. SampleRate is the share
+// of logged requests, responses with 5xx status are always logged.
+type AccessLogConfig struct {
+	Enabled    bool                `json:"enabled" yaml:"enabled" toml:"enabled"`
+	Format     string              `json:"format" yaml:"format" toml:"format" validate:"omitempty,oneof=json combined logfmt"`
+	Fields     []string            `json:"fields" yaml:"fields" toml:"fields" validate:"dive,oneof=time method path route status status_text duration_ms bytes_in bytes_out client_ip request_id user trace_id upstreams user_agent referer"`
+	SampleRate float64             `json:"sample_rate" yaml:"sample_rate" toml:"sample_rate" validate:"min=0,max=1"`
+	Output     string              `json:"output" yaml:"output" toml:"output" validate:"omitempty,oneof=stdout file"`
+	File       AccessLogFileConfig `json:"file" yaml:"file" toml:"file"`
+
+	// UserClaim is the JWT claim logged as the user, nested claims are addressed with dots.
+	UserClaim string `json:"user_claim" yaml:"user_claim" toml:"user_claim"`
+}
+
+// AccessLogFileConfig configures the rotating access log file. MaxSize is in megabytes, MaxAge in days.
+type AccessLogFileConfig struct {
+	Path       string `json:"path" yaml:"path" toml:"path"`
+	MaxSize    int    `json:"max_size" yaml:"max_size" toml:"max_size" validate:"min=0"`
+	MaxBackups int    `json:"max_backups" yaml:"max_backups" toml:"max_backups" validate:"min=0"`
+	MaxAge     int    `json:"max_age" yaml:"max_age" toml:"max_age" validate:"min=0"`
+	Compress   bool   `json:"compress" yaml:"compress" toml:"compress"`
+}
+
+// TracingConfig enables OpenTelemetry tracing. Spans are exported to an OTLP/HTTP collector, e.g.
+// http://otel-collector:4318/v1/traces. SampleRatio applies to traces started by the gateway, traces
+// continued from incoming propagation headers keep the caller's sampling decision.
+type TracingConfig struct {
+	Enabled     bool              `json:"enabled" yaml:"enabled" toml:"enabled"`
+	Endpoint    string            `json:"endpoint" yaml:"endpoint" toml:"endpoint" validate:"omitempty,url"`
+	Headers     map[string]string `json:"headers" yaml:"headers" toml:"headers"`
+	Timeout     time.Duration     `json:"timeout" yaml:"timeout" toml:"timeout"`
+	ServiceName string            `json:"service_name" yaml:"service_name" toml:"service_name"`
+	SampleRatio float64           `json:"sample_ratio" yaml:"sample_ratio" toml:"sample_ratio" validate:"min=0,max=1"`
+	Propagators []string          `json:"propagators" yaml:"propagators" toml:"propagators" validate:"dive,oneof=tracecontext baggage b3 b3multi"`
+}
+
+// ServerTLSConfig enables TLS termination on the gateway listener. CertFile/KeyFile is the default certificate,
+// Certificates are additional ones selected by SNI.
+type ServerTLSConfig struct {
+	Enabled        bool                   `json:"enabled" yaml:"enabled" toml:"enabled"`
+	CertFile       string                 `json:"cert_file" yaml:"cert_file" toml:"cert_file" validate:"required_with=KeyFile"`
+	KeyFile        string                 `json:"key_file" yaml:"key_file" toml:"key_file" validate:"required_with=CertFile"`
+	Certificates   []TLSCertificateConfig `json:"certificates" yaml:"certificates" toml:"certificates" validate:"dive"`
+	MinVersion     string                 `json:"min_version" yaml:"min_version" toml:"min_version" validate:"omitempty,oneof=1.0 1.1 1.2 1.3"`
+	CipherSuites   []string               `json:"cipher_suites" yaml:"cipher_suites" toml:"cipher_suites"`
+	DisableHTTP2   bool                   `json:"disable_http2" yaml:"disable_http2" toml:"disable_http2"`
+	ReloadInterval time.Duration          `json:"reload_interval" yaml:"reload_interval" toml:"reload_interval"`
+	RedirectPort   int                    `json:"redirect_port" yaml:"redirect_port" toml:"redirect_port" validate:"min=0,max=65535"`
+	ClientAuth     ClientAuthConfig       `json:"client_auth" yaml:"client_auth" toml:"client_auth"`
+}
+
+// ClientAuthConfig enables mutual TLS. With the optional mode certificates are verified if presented,
+// so routes can require them selectively.
+type ClientAuthConfig struct {
+	Mode           string `json:"mode" yaml:"mode" toml:"mode" validate:"omitempty,oneof=none optional require"`
+	CAFile         string `json:"ca_file" yaml:"ca_file" toml:"ca_file"`
+	ForwardHeaders bool   `json:"forward_headers" yaml:"forward_headers" toml:"forward_headers"`
+}
+
+type TLSCertificateConfig struct {
+	CertFile string `json:"cert_file" yaml:"cert_file" toml:"cert_file" validate:"required"`
+	KeyFile  string `json:"key_file" yaml:"key_file" toml:"key_file" validate:"required"`
+}
+
+// ConcurrencyConfig limits the number of in-flight requests. Requests above the limit wait in a bounded
+// queue and are shed with 503 when the queue is full or the queue timeout expires. Zero MaxInFlight disables it.
+type ConcurrencyConfig struct {
+	MaxInFlight  int                       `json:"max_in_flight" yaml:"max_in_flight" toml:"max_in_flight" validate:"min=0"`
+	QueueSize    int                       `json:"queue_size" yaml:"queue_size" toml:"queue_size" validate:"min=0"`
+	QueueTimeout time.Duration             `json:"queue_timeout" yaml:"queue_timeout" toml:"queue_timeout"`
+	RetryAfter   time.Duration             `json:"retry_after" yaml:"retry_after" toml:"retry_after"`
+	Adaptive     AdaptiveConcurrencyConfig `json:"adaptive" yaml:"adaptive" toml:"adaptive"`
+}
+
+// AdaptiveConcurrencyConfig enables adjusting the limit by observed upstream latency.
+// MaxInFlight of the parent config is used as the initial limit, the limit stays between MinLimit and MaxLimit.
+// MaxLimit defaults to MaxInFlight, so the adaptive limit only sheds below the configured cap.
+type AdaptiveConcurrencyConfig struct {
+	Algorithm        string        `json:"algorithm" yaml:"algorithm" toml:"algorithm" validate:"omitempty,oneof=aimd gradient"`
+	MinLimit         int           `json:"min_limit" yaml:"min_limit" toml:"min_limit" validate:"min=0"`
+	MaxLimit         int           `json:"max_limit" yaml:"max_limit" toml:"max_limit" validate:"min=0"`
+	LatencyThreshold time.Duration `json:"latency_threshold" yaml:"latency_threshold" toml:"latency_threshold"`
+	BackoffRatio     float64       `json:"backoff_ratio" yaml:"backoff_ratio" toml:"backoff_ratio" validate:"min=0,max=1"`
+	Smoothing        float64       `json:"smoothing" yaml:"smoothing" toml:"smoothing" validate:"min=0,max=1"`
 }
 
+// MetricsConfig enables metrics served on Path. With Port set, metrics are served on a dedicated listener
+// bound to Host instead of the gateway one, e.g. to keep them off the public network. Host defaults to
+// the loopback address.
 type MetricsConfig struct {
 	Enabled  bool   `json:"enabled" yaml:"enabled" toml:"enabled"`
-	Provider string `json:"provider" yaml:"provider" toml:"provider"`
+	Provider string `json:"provider" yaml:"provider" toml:"provider" validate:"omitempty,oneof=prometheus victoriametrics"`
+	Host     string `json:"host" yaml:"host" toml:"host" validate:"omitempty,ip|hostname"`
+	Port     int    `json:"port" yaml:"port" toml:"port" validate:"min=0,max=65535"`
+	Path     string `json:"path" yaml:"path" toml:"path"`
 
 	VictoriaMetrics VictoriaMetricsConfig `json:"victoria_metrics" yaml:"victoria_metrics" toml:"victoria_metrics"`
 }
 
+// VictoriaMetricsConfig enables pushing metrics of the victoriametrics provider to Host every Interval.
+// Metrics are served on /metrics regardless of it.
 type VictoriaMetricsConfig struct {
 	Host     string        `json:"host" yaml:"host" toml:"host"`
-	Port     int           `json:"port" yaml:"port" toml:"port"`
+	Port     int           `json:"port" yaml:"port" toml:"port" validate:"min=0,max=65535"`
 	Path     string        `json:"path" yaml:"path" toml:"path"`
 	Interval time.Duration `json:"interval" yaml:"interval" toml:"interval"`
 }
 
 type DashboardConfig struct {
+	Enabled bool                `json:"enabled" yaml:"enabled" toml:"enabled"`
+	Port    int                 `json:"port" yaml:"port" toml:"port"`
+	Timeout time.Duration       `json:"timeout" yaml:"timeout" toml:"timeout"`
+	Auth    DashboardAuthConfig `json:"auth" yaml:"auth" toml:"auth"`
+}
+
+// DashboardAuthConfig authenticates dashboard users with static users, JWTs or both. Without users and JWT
+// the dashboard is open to anyone who can reach its port.
+type DashboardAuthConfig struct {
+	Users []DashboardUserConfig `json:"users" yaml:"users" toml:"users" validate:"dive"`
+	JWT   DashboardJWTConfig    `json:"jwt" yaml:"jwt" toml:"jwt"`
+}
+
+// DashboardUserConfig is a static dashboard user authenticated by HTTP basic auth. PasswordHash is a bcrypt hash,
+// e.g. produced by `htpasswd -nbBC 10 "" password | cut -d: -f2`.
+type DashboardUserConfig struct {
+	Name         string `json:"name" yaml:"name" toml:"name" validate:"required"`
+	PasswordHash string `json:"password_hash" yaml:"password_hash" toml:"password_hash" validate:"required"`
+	Role         string `json:"role" yaml:"role" toml:"role" validate:"required,oneof=viewer admin"`
+}
+
+// DashboardJWTConfig accepts bearer tokens verified with the issuer, audience, alg and key of the global
+// middleware named Middleware ("auth" by default). The role is read from RoleClaim ("role" by default), whose
+// value must be "viewer" or "admin", or a list containing them.
+type DashboardJWTConfig struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled" toml:"enabled"`
+	Middleware string `json:"middleware" yaml:"middleware" toml:"middleware"`
+	RoleClaim  string `json:"role_claim" yaml:"role_claim" toml:"role_claim"`
+}
+
+// AdminConfig enables the admin API for runtime inspection and control on its own port.
+// Requests must carry one of the Tokens as a bearer token.
+type AdminConfig struct {
 	Enabled bool          `json:"enabled" yaml:"enabled" toml:"enabled"`
-	Port    int           `json:"port" yaml:"port" toml:"port"`
+	Port    int           `json:"port" yaml:"port" toml:"port" validate:"min=0,max=65535"`
 	Timeout time.Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+	Tokens  []string      `json:"tokens" yaml:"tokens" toml:"tokens" validate:"dive,required"`
 }
 
 type RouteConfig struct {
 	Path                 string             `json:"path" yaml:"path" toml:"path" validate:"required"`
 	Method               string             `json:"method" yaml:"method" toml:"method" validate:"required"`
+	Type                 string             `json:"type" yaml:"type" toml:"type" validate:"omitempty,oneof=http websocket stream"`
 	Plugins              []PluginConfig     `json:"plugins" yaml:"plugins" toml:"plugins"`
 	Middlewares          []MiddlewareConfig `json:"middlewares" yaml:"middlewares" toml:"middlewares"`
 	Upstreams            []UpstreamConfig   `json:"upstreams" yaml:"upstreams" toml:"upstreams" validate:"required,min=1,dive"`
 	Aggregation          AggregationConfig  `json:"aggregation" yaml:"aggregation" toml:"aggregation"`
 	MaxParallelUpstreams int64              `json:"max_parallel_upstreams" yaml:"max_parallel_upstreams" toml:"max_parallel_upstreams"`
+	RateLimits           []RateLimitConfig  `json:"rate_limits" yaml:"rate_limits" toml:"rate_limits" validate:"dive"`
+	Concurrency          ConcurrencyConfig  `json:"concurrency" yaml:"concurrency" toml:"concurrency"`
+	ClientCert           ClientCertConfig   `json:"client_cert" yaml:"client_cert" toml:"client_cert"`
+	WebSocket            WebSocketConfig    `json:"websocket" yaml:"websocket" toml:"websocket"`
+	Mirror               MirrorConfig       `json:"mirror" yaml:"mirror" toml:"mirror"`
+}
+
+// MirrorConfig sends a copy of the route traffic to shadow upstreams whose responses are discarded.
+type MirrorConfig struct {
+	Upstreams []UpstreamConfig `json:"upstreams" yaml:"upstreams" toml:"upstreams" validate:"dive"`
+	// Percentage of requests mirrored, 100 by default.
+	Percentage float64 `json:"percentage" yaml:"percentage" toml:"percentage" validate:"min=0,max=100"`
+	// MaxConcurrent bounds in-flight mirror calls, 10 by default. Requests over the limit are not mirrored.
+	MaxConcurrent int64 `json:"max_concurrent" yaml:"max_concurrent" toml:"max_concurrent" validate:"min=0"`
+	// LogDiffs logs differences between mirror responses and the response of the first route upstream.
+	LogDiffs bool `json:"log_diffs" yaml:"log_diffs" toml:"log_diffs"`
+}
+
+// WebSocketConfig configures websocket routes. Connections without frames in either direction
+// for IdleTimeout are closed. Zero values disable the limits.
+type WebSocketConfig struct {
+	IdleTimeout    time.Duration `json:"idle_timeout" yaml:"idle_timeout" toml:"idle_timeout"`
+	MaxConnections int64         `json:"max_connections" yaml:"max_connections" toml:"max_connections" validate:"min=0"`
+}
+
+// ClientCertConfig restricts a route to clients with a verified certificate. Any allow list implies Required.
+// Subjects match either the full subject or the common name; fingerprints are SHA-256.
+type ClientCertConfig struct {
+	Required            bool     `json:"required" yaml:"required" toml:"required"`
+	AllowedSubjects     []string `json:"allowed_subjects" yaml:"allowed_subjects" toml:"allowed_subjects"`
+	AllowedSANs         []string `json:"allowed_sans" yaml:"allowed_sans" toml:"allowed_sans"`
+	AllowedFingerprints []string `json:"allowed_fingerprints" yaml:"allowed_fingerprints" toml:"allowed_fingerprints"`
+}
+
+// RateLimitConfig describes a single route rate limit rule. The bucket key is composed of all Keys values,
+// so e.g. [claim:tenant, header:X-Client] limits every tenant/client pair separately.
+type RateLimitConfig struct {
+	Name    string                `json:"name" yaml:"name" toml:"name"`
+	Keys    []RateLimitKeyConfig  `json:"keys" yaml:"keys" toml:"keys" validate:"required,min=1,dive"`
+	Limit   int                   `json:"limit" yaml:"limit" toml:"limit" validate:"required,min=1"`
+	Window  time.Duration         `json:"window" yaml:"window" toml:"window"`
+	TierKey *RateLimitKeyConfig   `json:"tier_key,omitempty" yaml:"tier_key,omitempty" toml:"tier_key,omitempty" validate:"required_with=Tiers"`
+	Tiers   []RateLimitTierConfig `json:"tiers" yaml:"tiers" toml:"tiers" validate:"dive"`
+}
+
+// RateLimitKeyConfig describes a request value extractor. Name is the header, claim, query or path parameter name
+// depending on Type; it is ignored for the ip type.
+type RateLimitKeyConfig struct {
+	Type string `json:"type" yaml:"type" toml:"type" validate:"required,oneof=ip header claim api_key path_param"`
+	Name string `json:"name" yaml:"name" toml:"name"`
+}
+
+// RateLimitTierConfig overrides the rule limit for consumers whose tier key value equals Name.
+type RateLimitTierConfig struct {
+	Name   string        `json:"name" yaml:"name" toml:"name" validate:"required"`
+	Limit  int           `json:"limit" yaml:"limit" toml:"limit" validate:"required,min=1"`
+	Window time.Duration `json:"window" yaml:"window" toml:"window"`
 }
 
 type AggregationConfig struct {
-	Strategy            string `json:"strategy" yaml:"strategy" toml:"strategy" validate:"required,oneof=array merge"`
+	Strategy            string `json:"strategy" yaml:"strategy" toml:"strategy" validate:"omitempty,oneof=array merge"`
 	AllowPartialResults bool   `json:"allow_partial_results" yaml:"allow_partial_results" toml:"allow_partial_results"`
 }
 
 type UpstreamConfig struct {
 	Name                string        `json:"name" yaml:"name" toml:"name"`
-	Hosts               []string      `json:"hosts" yaml:"hosts" toml:"hosts" validate:"required,hosts"`
-	Method              string        `json:"method" yaml:"method" toml:"method" validate:"required"`
+	Type                string        `json:"type" yaml:"type" toml:"type" validate:"omitempty,oneof=http grpc grpc-web graphql static mock"`
+	Hosts               []string      `json:"hosts" yaml:"hosts" toml:"hosts" validate:"omitempty,hosts"`
+	Method              string        `json:"method" yaml:"method" toml:"method"`
 	Timeout             time.Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
 	ForwardHeaders      []string      `json:"forward_headers" yaml:"forward_headers" toml:"forward_headers"`
 	ForwardQueryStrings []string      `json:"forward_query_strings" yaml:"forward_query_strings" toml:"forward_query_strings"`
 	Policy              PolicyConfig  `json:"policy" yaml:"policy" toml:"policy"`
+
+	TLS       UpstreamTLSConfig `json:"tls" yaml:"tls" toml:"tls"`
+	Transport TransportConfig   `json:"transport" yaml:"transport" toml:"transport"`
+
+	GRPC    GRPCConfig    `json:"grpc" yaml:"grpc" toml:"grpc"`
+	GraphQL GraphQLConfig `json:"graphql" yaml:"graphql" toml:"graphql"`
+	Static  StaticConfig  `json:"static" yaml:"static" toml:"static"`
+
+	Faults FaultInjectionConfig `json:"faults" yaml:"faults" toml:"faults"`
+	Canary CanaryConfig         `json:"canary" yaml:"canary" toml:"canary"`
+}
+
+// CanaryConfig splits the upstream traffic between weighted variants, e.g. two versions of a service.
+// Variants share all upstream settings except hosts.
+type CanaryConfig struct {
+	Variants []UpstreamVariantConfig `json:"variants" yaml:"variants" toml:"variants" validate:"dive"`
+	// Sticky assigns requests with the same key to the same variant.
+	Sticky StickyConfig `json:"sticky" yaml:"sticky" toml:"sticky"`
+	// OverrideHeader selects a variant by name, e.g. for testers.
+	OverrideHeader string `json:"override_header" yaml:"override_header" toml:"override_header"`
+}
+
+type UpstreamVariantConfig struct {
+	Name   string   `json:"name" yaml:"name" toml:"name" validate:"required"`
+	Weight int      `json:"weight" yaml:"weight" toml:"weight" validate:"min=0"`
+	Hosts  []string `json:"hosts" yaml:"hosts" toml:"hosts" validate:"required,hosts"`
+}
+
+// StickyConfig names the request value variants are assigned by: a header, a cookie or a JWT claim path.
+type StickyConfig struct {
+	Type string `json:"type" yaml:"type" toml:"type" validate:"omitempty,oneof=header cookie claim"`
+	Name string `json:"name" yaml:"name" toml:"name"`
+}
+
+// GRPCConfig configures grpc and grpc-web upstreams. Request and response messages are transcoded
+// from and to JSON using the descriptor set, e.g. produced by
+// `protoc --include_imports --descriptor_set_out=api.pb api.proto`.
+type GRPCConfig struct {
+	DescriptorSet string `json:"descriptor_set" yaml:"descriptor_set" toml:"descriptor_set"`
+	// Method is the full name of a unary method, e.g. "users.v1.UserService/GetUser".
+	Method string `json:"method" yaml:"method" toml:"method"`
+	// UseProtoNames makes responses use field names from the proto files instead of lowerCamelCase.
+	UseProtoNames bool `json:"use_proto_names" yaml:"use_proto_names" toml:"use_proto_names"`
+	// EmitDefaults includes fields with default values in responses.
+	EmitDefaults bool `json:"emit_defaults" yaml:"emit_defaults" toml:"emit_defaults"`
+}
+
+// GraphQLConfig configures graphql upstreams. The configured query is sent on every request,
+// variables are rendered from request data with {{ source.name }} templates.
+type GraphQLConfig struct {
+	Query string `json:"query" yaml:"query" toml:"query"`
+	// QueryFile is read instead of Query when set.
+	QueryFile     string         `json:"query_file" yaml:"query_file" toml:"query_file"`
+	OperationName string         `json:"operation_name" yaml:"operation_name" toml:"operation_name"`
+	Variables     map[string]any `json:"variables" yaml:"variables" toml:"variables"`
+}
+
+// StaticConfig configures static (mock) upstreams that return a canned response without calling a backend.
+type StaticConfig struct {
+	Status  int               `json:"status" yaml:"status" toml:"status" validate:"omitempty,min=100,max=599"`
+	Headers map[string]string `json:"headers" yaml:"headers" toml:"headers"`
+	Body    string            `json:"body" yaml:"body" toml:"body"`
+	// BodyFile is read instead of Body when set.
+	BodyFile string `json:"body_file" yaml:"body_file" toml:"body_file"`
+	// Template renders {{ source.name }} placeholders in the body and headers from the request.
+	Template bool `json:"template" yaml:"template" toml:"template"`
+	// Latency delays every response, e.g. to simulate a slow backend.
+	Latency time.Duration `json:"latency" yaml:"latency" toml:"latency"`
+}
+
+// FaultInjectionConfig makes a percentage of upstream attempts fail on purpose, for resilience testing.
+// Each fault is rolled independently per attempt, in the order connection error, abort, delay, truncated body.
+type FaultInjectionConfig struct {
+	// Header restricts faults to requests carrying the header, e.g. X-Kono-Fault.
+	Header          string           `json:"header" yaml:"header" toml:"header"`
+	Delay           DelayFaultConfig `json:"delay" yaml:"delay" toml:"delay"`
+	Abort           AbortFaultConfig `json:"abort" yaml:"abort" toml:"abort"`
+	ConnectionError FaultConfig      `json:"connection_error" yaml:"connection_error" toml:"connection_error"`
+	// TruncatedBody cuts successful response bodies in half.
+	TruncatedBody FaultConfig `json:"truncated_body" yaml:"truncated_body" toml:"truncated_body"`
+}
+
+type FaultConfig struct {
+	Percentage float64 `json:"percentage" yaml:"percentage" toml:"percentage" validate:"min=0,max=100"`
+}
+
+type DelayFaultConfig struct {
+	Percentage float64       `json:"percentage" yaml:"percentage" toml:"percentage" validate:"min=0,max=100"`
+	Duration   time.Duration `json:"duration" yaml:"duration" toml:"duration"`
+}
+
+type AbortFaultConfig struct {
+	Percentage float64 `json:"percentage" yaml:"percentage" toml:"percentage" validate:"min=0,max=100"`
+	// Status is returned instead of calling the upstream, 503 by default.
+	Status int `json:"status" yaml:"status" toml:"status" validate:"omitempty,min=100,max=599"`
+}
+
+// TransportConfig configures connections and the connection pool of an upstream.
+// Upstreams with equal transport and TLS configs share a pool, even across routes.
+type TransportConfig struct {
+	DialTimeout           time.Duration `json:"dial_timeout" yaml:"dial_timeout" toml:"dial_timeout"`
+	KeepAlive             time.Duration `json:"keep_alive" yaml:"keep_alive" toml:"keep_alive"`
+	TLSHandshakeTimeout   time.Duration `json:"tls_handshake_timeout" yaml:"tls_handshake_timeout" toml:"tls_handshake_timeout"`
+	ResponseHeaderTimeout time.Duration `json:"response_header_timeout" yaml:"response_header_timeout" toml:"response_header_timeout"`
+	IdleConnTimeout       time.Duration `json:"idle_conn_timeout" yaml:"idle_conn_timeout" toml:"idle_conn_timeout"`
+	MaxIdleConns          int           `json:"max_idle_conns" yaml:"max_idle_conns" toml:"max_idle_conns" validate:"min=0"`
+	MaxIdleConnsPerHost   int           `json:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host" toml:"max_idle_conns_per_host" validate:"min=0"`
+	MaxConnsPerHost       int           `json:"max_conns_per_host" yaml:"max_conns_per_host" toml:"max_conns_per_host" validate:"min=0"`
+	DisableKeepAlives     bool          `json:"disable_keep_alives" yaml:"disable_keep_alives" toml:"disable_keep_alives"`
+	DisableHTTP2          bool          `json:"disable_http2" yaml:"disable_http2" toml:"disable_http2"`
+	// UnencryptedHTTP2 makes the transport use HTTP/2 with prior knowledge (h2c) for http:// hosts.
+	UnencryptedHTTP2     bool   `json:"unencrypted_http2" yaml:"unencrypted_http2" toml:"unencrypted_http2"`
+	ProxyURL             string `json:"proxy_url" yaml:"proxy_url" toml:"proxy_url" validate:"omitempty,url"`
+	ProxyFromEnvironment bool   `json:"proxy_from_environment" yaml:"proxy_from_environment" toml:"proxy_from_environment"`
+}
+
+// UpstreamTLSConfig configures TLS toward an upstream: a private CA bundle, a client certificate for
+// upstreams requiring mutual TLS and the expected server name. Upstreams with equal configs share a transport.
+type UpstreamTLSConfig struct {
+	CAFile             string `json:"ca_file" yaml:"ca_file" toml:"ca_file"`
+	CertFile           string `json:"cert_file" yaml:"cert_file" toml:"cert_file" validate:"required_with=KeyFile"`
+	KeyFile            string `json:"key_file" yaml:"key_file" toml:"key_file" validate:"required_with=CertFile"`
+	ServerName         string `json:"server_name" yaml:"server_name" toml:"server_name"`
+	MinVersion         string `json:"min_version" yaml:"min_version" toml:"min_version" validate:"omitempty,oneof=1.0 1.1 1.2 1.3"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify" toml:"insecure_skip_verify"`
 }
 
 type PolicyConfig struct {
@@ -151,11 +522,39 @@ func LoadConfig(path string) (Config, error) {
 		return Config{}, fmt.Errorf("unknown configuration file extension: %s", filepath.Ext(path))
 	}
 
-	ensureDefaults(&cfg)
+	if err = validateConfig(&cfg, strings.TrimPrefix(filepath.Ext(path), ".")); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// ValidateConfig applies defaults and validates the config like LoadConfig does, e.g. for a config edited at
+// runtime. Fields are named by their json keys in errors.
+func ValidateConfig(cfg *Config) error {
+	return validateConfig(cfg, "json")
+}
+
+func validateConfig(cfg *Config, tagName string) error {
+	ensureDefaults(cfg)
+
+	v, err := newValidator(tagName)
+	if err != nil {
+		return err
+	}
+
+	if err = v.Struct(cfg); err != nil {
+		return fmt.Errorf("invalid configuration: %w", formatValidationError(err))
+	}
+
+	return nil
+}
 
+// newValidator creates the config validator naming fields by the struct tag of the config format, e.g. "yaml".
+func newValidator(tagName string) (*validator.Validate, error) {
 	v := validator.New()
 	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		name := fld.Tag.Get(strings.TrimPrefix(filepath.Ext(path), "."))
+		name := fld.Tag.Get(tagName)
 		if name == "" || name == "-" {
 			return strings.ToLower(fld.Name)
 		}
@@ -163,11 +562,26 @@ func LoadConfig(path string) (Config, error) {
 		return strings.ToLower(strings.Split(name, ",")[0])
 	})
 
-	if err = v.Struct(&cfg); err != nil {
-		return Config{}, fmt.Errorf("invalid configuration: %w", formatValidationError(err))
+	if err := v.RegisterValidation("hosts", validateHosts); err != nil {
+		return nil, fmt.Errorf("cannot register validation: %w", err)
 	}
 
-	return cfg, nil
+	if err := v.RegisterValidation("regexp", validateRegexp); err != nil {
+		return nil, fmt.Errorf("cannot register validation: %w", err)
+	}
+
+	v.RegisterStructValidation(validateRoute, RouteConfig{})
+	v.RegisterStructValidation(validateUpstream, UpstreamConfig{})
+	v.RegisterStructValidation(validateRateLimitKey, RateLimitKeyConfig{})
+	v.RegisterStructValidation(validateServerTLS, ServerTLSConfig{})
+	v.RegisterStructValidation(validateClientAuth, ClientAuthConfig{})
+	v.RegisterStructValidation(validateTracing, TracingConfig{})
+	v.RegisterStructValidation(validateMetrics, MetricsConfig{})
+	v.RegisterStructValidation(validateAccessLog, AccessLogConfig{})
+	v.RegisterStructValidation(validateAdmin, AdminConfig{})
+	v.RegisterStructValidation(validateDashboardAuth, Config{})
+
+	return v, nil
 }
 
 // ensureDefaults ensures that default values are used in required configuration fields if they are not explicitly set.
@@ -176,19 +590,372 @@ func ensureDefaults(cfg *Config) {
 		cfg.Server.Timeout = defaultServerTimeout
 	}
 
+	if cfg.Server.TLS.Enabled && cfg.Server.TLS.ReloadInterval == 0 {
+		cfg.Server.TLS.ReloadInterval = defaultTLSReload
+	}
+
+	ensureConcurrencyDefaults(&cfg.Server.Concurrency)
+
+	if cfg.Admin.Timeout == 0 {
+		cfg.Admin.Timeout = defaultServerTimeout
+	}
+
+	cfg.Server.ClientIPHeader = cmp.Or(cfg.Server.ClientIPHeader, defaultClientIPHeader)
+
+	if jwtCfg := &cfg.Dashboard.Auth.JWT; jwtCfg.Enabled {
+		jwtCfg.Middleware = cmp.Or(jwtCfg.Middleware, defaultDashboardJWTMiddleware)
+		jwtCfg.RoleClaim = cmp.Or(jwtCfg.RoleClaim, defaultDashboardRoleClaim)
+	}
+
+	if cfg.Server.Metrics.Path == "" {
+		cfg.Server.Metrics.Path = defaultMetricsPath
+	}
+
+	cfg.Server.Metrics.Host = cmp.Or(cfg.Server.Metrics.Host, defaultMetricsHost)
+
+	if vm := &cfg.Server.Metrics.VictoriaMetrics; vm.Host != "" {
+		if vm.Path == "" {
+			vm.Path = defaultMetricsPushPath
+		}
+
+		if vm.Interval == 0 {
+			vm.Interval = defaultMetricsPushInterval
+		}
+	}
+
+	if cfg.Server.Tracing.Enabled {
+		if cfg.Server.Tracing.SampleRatio == 0 {
+			cfg.Server.Tracing.SampleRatio = 1
+		}
+
+		if len(cfg.Server.Tracing.Propagators) == 0 {
+			cfg.Server.Tracing.Propagators = []string{propagatorTraceContext, propagatorB3Multi}
+		}
+	}
+
+	if al := &cfg.Server.AccessLog; al.Enabled {
+		if al.Format == "" {
+			al.Format = accessLogFormatJSON
+		}
+
+		if len(al.Fields) == 0 {
+			al.Fields = defaultAccessLogFields
+		}
+
+		if al.SampleRate == 0 {
+			al.SampleRate = 1
+		}
+
+		if al.Output == "" {
+			al.Output = accessLogOutputStdout
+		}
+
+		if al.UserClaim == "" {
+			al.UserClaim = defaultAccessLogUserClaim
+		}
+	}
+
 	for i := range cfg.Routes {
+		if cfg.Routes[i].Type == "" {
+			cfg.Routes[i].Type = RouteTypeHTTP
+		}
+
+		ensureConcurrencyDefaults(&cfg.Routes[i].Concurrency)
+
 		if cfg.Routes[i].MaxParallelUpstreams < 1 {
 			cfg.Routes[i].MaxParallelUpstreams = int64(2 * runtime.NumCPU()) //nolint:mnd // shut up mnt
 		}
 
 		for j := range cfg.Routes[i].Upstreams {
-			if cfg.Routes[i].Upstreams[j].Timeout == 0 {
-				cfg.Routes[i].Upstreams[j].Timeout = defaultUpstreamTimeout
+			ensureUpstreamDefaults(&cfg.Routes[i].Upstreams[j])
+		}
+
+		for j := range cfg.Routes[i].Mirror.Upstreams {
+			ensureUpstreamDefaults(&cfg.Routes[i].Mirror.Upstreams[j])
+		}
+
+		for j := range cfg.Routes[i].RateLimits {
+			rl := &cfg.Routes[i].RateLimits[j]
+
+			if rl.Window == 0 {
+				rl.Window = defaultRateLimitWindow
+			}
+
+			for k := range rl.Tiers {
+				if rl.Tiers[k].Window == 0 {
+					rl.Tiers[k].Window = rl.Window
+				}
+			}
+		}
+	}
+}
+
+// validateRegexp checks that the field is a valid regular expression.
+func validateRegexp(fl validator.FieldLevel) bool {
+	_, err := regexp.Compile(fl.Field().String())
+	return err == nil
+}
+
+// validateHosts checks that every upstream host is an absolute http(s) URL.
+func validateHosts(fl validator.FieldLevel) bool {
+	hosts, ok := fl.Field().Interface().([]string)
+	if !ok || len(hosts) == 0 {
+		return false
+	}
+
+	for _, host := range hosts {
+		u, err := url.Parse(host)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateRoute checks requirements that depend on the route type. Aggregating routes need a strategy,
+// websocket and stream routes pass the connection through to exactly one upstream.
+func validateRoute(sl validator.StructLevel) {
+	route, ok := sl.Current().Interface().(RouteConfig)
+	if !ok {
+		return
+	}
+
+	switch route.Type {
+	case "", RouteTypeHTTP:
+		if route.Aggregation.Strategy == "" {
+			sl.ReportError(route.Aggregation.Strategy, "aggregation.strategy", "Strategy", "required", "")
+		}
+	case RouteTypeWebSocket, RouteTypeStream:
+		if len(route.Upstreams) != 1 {
+			sl.ReportError(route.Upstreams, "upstreams", "Upstreams", "len", "1")
+		}
+
+		if len(route.Mirror.Upstreams) > 0 {
+			sl.ReportError(route.Mirror.Upstreams, "mirror.upstreams", "Upstreams", "len", "0")
+		}
+
+		for _, upstream := range route.Upstreams {
+			if upstream.Type != "" && upstream.Type != UpstreamTypeHTTP {
+				sl.ReportError(upstream.Type, "upstreams.type", "Type", "oneof", UpstreamTypeHTTP)
+			}
+
+			if len(upstream.Canary.Variants) > 0 {
+				sl.ReportError(upstream.Canary.Variants, "upstreams.canary.variants", "Variants", "len", "0")
 			}
 		}
 	}
 }
 
+// validateUpstream checks the settings required by the upstream type.
+func validateUpstream(sl validator.StructLevel) {
+	upstream, ok := sl.Current().Interface().(UpstreamConfig)
+	if !ok {
+		return
+	}
+
+	isStatic := upstream.Type == UpstreamTypeStatic || upstream.Type == UpstreamTypeMock
+	if len(upstream.Hosts) == 0 && len(upstream.Canary.Variants) == 0 && !isStatic {
+		sl.ReportError(upstream.Hosts, "hosts", "Hosts", "required", "")
+	}
+
+	if len(upstream.Canary.Variants) > 0 {
+		totalWeight := 0
+		for _, v := range upstream.Canary.Variants {
+			totalWeight += v.Weight
+		}
+
+		if totalWeight == 0 {
+			sl.ReportError(upstream.Canary.Variants, "canary.variants", "Variants", "weights", "")
+		}
+	}
+
+	if upstream.Canary.Sticky.Type != "" && upstream.Canary.Sticky.Name == "" {
+		sl.ReportError(upstream.Canary.Sticky.Name, "canary.sticky.name", "Name", "required", "")
+	}
+
+	switch upstream.Type {
+	case "", UpstreamTypeHTTP:
+		if upstream.Method == "" {
+			sl.ReportError(upstream.Method, "method", "Method", "required", "")
+		}
+	case UpstreamTypeGRPC, UpstreamTypeGRPCWeb:
+		if upstream.GRPC.DescriptorSet == "" {
+			sl.ReportError(upstream.GRPC.DescriptorSet, "grpc.descriptor_set", "DescriptorSet", "required", "")
+		}
+
+		if upstream.GRPC.Method == "" {
+			sl.ReportError(upstream.GRPC.Method, "grpc.method", "Method", "required", "")
+		}
+	case UpstreamTypeGraphQL:
+		if upstream.GraphQL.Query == "" && upstream.GraphQL.QueryFile == "" {
+			sl.ReportError(upstream.GraphQL.Query, "graphql.query", "Query", "required", "")
+		}
+	}
+}
+
+// validateRateLimitKey requires a name for key types that read a named request value.
+func validateRateLimitKey(sl validator.StructLevel) {
+	key, ok := sl.Current().Interface().(RateLimitKeyConfig)
+	if !ok {
+		return
+	}
+
+	switch key.Type {
+	case rateLimitKeyHeader, rateLimitKeyClaim, rateLimitKeyPathParam:
+		if key.Name == "" {
+			sl.ReportError(key.Name, "name", "Name", "required", "")
+		}
+	}
+}
+
+// validateServerTLS requires at least one certificate if TLS is enabled.
+func validateServerTLS(sl validator.StructLevel) {
+	tlsCfg, ok := sl.Current().Interface().(ServerTLSConfig)
+	if !ok || !tlsCfg.Enabled {
+		return
+	}
+
+	if tlsCfg.CertFile == "" && len(tlsCfg.Certificates) == 0 {
+		sl.ReportError(tlsCfg.CertFile, "cert_file", "CertFile", "required", "")
+	}
+}
+
+// validateClientAuth requires a client CA bundle if client certificates are verified.
+func validateClientAuth(sl validator.StructLevel) {
+	clientAuth, ok := sl.Current().Interface().(ClientAuthConfig)
+	if !ok || clientAuth.Mode == "" || clientAuth.Mode == "none" {
+		return
+	}
+
+	if clientAuth.CAFile == "" {
+		sl.ReportError(clientAuth.CAFile, "ca_file", "CAFile", "required", "")
+	}
+}
+
+// validateMetrics requires a provider if metrics are enabled.
+func validateMetrics(sl validator.StructLevel) {
+	metrics, ok := sl.Current().Interface().(MetricsConfig)
+	if !ok || !metrics.Enabled {
+		return
+	}
+
+	if metrics.Provider == "" {
+		sl.ReportError(metrics.Provider, "provider", "Provider", "required", "")
+	}
+}
+
+// validateTracing requires a collector endpoint if tracing is enabled.
+func validateTracing(sl validator.StructLevel) {
+	tracing, ok := sl.Current().Interface().(TracingConfig)
+	if !ok || !tracing.Enabled {
+		return
+	}
+
+	if tracing.Endpoint == "" {
+		sl.ReportError(tracing.Endpoint, "endpoint", "Endpoint", "required", "")
+	}
+}
+
+// validateAdmin requires a port and at least one token if the admin API is enabled.
+func validateAdmin(sl validator.StructLevel) {
+	admin, ok := sl.Current().Interface().(AdminConfig)
+	if !ok || !admin.Enabled {
+		return
+	}
+
+	if admin.Port == 0 {
+		sl.ReportError(admin.Port, "port", "Port", "required", "")
+	}
+
+	if len(admin.Tokens) == 0 {
+		sl.ReportError(admin.Tokens, "tokens", "Tokens", "required", "")
+	}
+}
+
+// validateDashboardAuth requires bcrypt password hashes and a global middleware to take the JWT settings from.
+func validateDashboardAuth(sl validator.StructLevel) {
+	cfg, ok := sl.Current().Interface().(Config)
+	if !ok {
+		return
+	}
+
+	auth := cfg.Dashboard.Auth
+
+	for _, user := range auth.Users {
+		if _, err := bcrypt.Cost([]byte(user.PasswordHash)); err != nil {
+			sl.ReportError(user.PasswordHash, "dashboard.auth.users.password_hash", "PasswordHash", "bcrypt", "")
+		}
+	}
+
+	if !auth.JWT.Enabled {
+		return
+	}
+
+	if !slices.ContainsFunc(cfg.Middlewares, func(m MiddlewareConfig) bool { return m.Name == auth.JWT.Middleware }) {
+		sl.ReportError(auth.JWT.Middleware, "dashboard.auth.jwt.middleware", "Middleware", "middleware", "")
+	}
+}
+
+// validateAccessLog requires a file path if the access log is written to a file.
+func validateAccessLog(sl validator.StructLevel) {
+	accessLog, ok := sl.Current().Interface().(AccessLogConfig)
+	if !ok || !accessLog.Enabled {
+		return
+	}
+
+	if accessLog.Output == accessLogOutputFile && accessLog.File.Path == "" {
+		sl.ReportError(accessLog.File.Path, "file.path", "Path", "required", "")
+	}
+}
+
+// ensureConcurrencyDefaults sets the Retry-After default and caps an adaptive limit without MaxLimit at MaxInFlight.
+func ensureConcurrencyDefaults(cfg *ConcurrencyConfig) {
+	if cfg.RetryAfter == 0 {
+		cfg.RetryAfter = defaultRetryAfter
+	}
+
+	if cfg.Adaptive.Algorithm != "" && cfg.Adaptive.MaxLimit == 0 {
+		cfg.Adaptive.MaxLimit = cfg.MaxInFlight
+	}
+}
+
+// ensureUpstreamDefaults sets the upstream timeout and transport defaults.
+func ensureUpstreamDefaults(upstream *UpstreamConfig) {
+	if upstream.Timeout == 0 {
+		upstream.Timeout = defaultUpstreamTimeout
+	}
+
+	ensureTransportDefaults(&upstream.Transport)
+}
+
+// ensureTransportDefaults sets the connection pool defaults the gateway used before they became configurable.
+func ensureTransportDefaults(cfg *TransportConfig) {
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = defaultDialTimeout
+	}
+
+	if cfg.KeepAlive == 0 {
+		cfg.KeepAlive = defaultKeepAlive
+	}
+
+	if cfg.TLSHandshakeTimeout == 0 {
+		cfg.TLSHandshakeTimeout = defaultTLSHandshakeTimeout
+	}
+
+	if cfg.IdleConnTimeout == 0 {
+		cfg.IdleConnTimeout = defaultIdleConnTimeout
+	}
+
+	if cfg.MaxIdleConns == 0 {
+		cfg.MaxIdleConns = defaultMaxIdleConns
+	}
+
+	if cfg.MaxIdleConnsPerHost == 0 {
+		cfg.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+}
+
 func formatValidationError(err error) error {
 	var ves validator.ValidationErrors
 
@@ -222,9 +989,30 @@ func humanMessage(fe validator.FieldError) string {
 	case "oneof":
 		return fmt.Sprintf("must be one of [%s]", fe.Param())
 
-	case "hosts":
+	case "len":
+		return fmt.Sprintf("must have exactly %s item(s)", fe.Param())
+
+	case "hosts", "url":
 		return "must be a valid URL"
 
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+
+	case "weights":
+		return "must have a variant with a positive weight"
+
+	case "cidr|ip":
+		return "must be a valid CIDR or IP address"
+
+	case "regexp":
+		return "must be a valid regular expression"
+
+	case "bcrypt":
+		return "must be a bcrypt hash"
+
+	case "middleware":
+		return "must name a global middleware"
+
 	default:
 		return fmt.Sprintf("validation failed on '%s'", fe.Tag())
 	}
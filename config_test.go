@@ -0,0 +1,55 @@
+package kono
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const hostsTestConfig = `config_version: v1
+name: test
+version: "1"
+server:
+  port: 8080
+routes:
+  - path: /users
+    method: GET
+    aggregation:
+      strategy: merge
+    upstreams:
+      - method: GET
+        hosts:
+          - %s
+`
+
+func TestLoadConfig_Hosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		wantErr string
+	}{
+		{name: "valid", host: "http://localhost:8081"},
+		{name: "missing scheme", host: "localhost:8081", wantErr: "routes[0].upstreams[0].hosts: must be a valid URL"},
+		{name: "unsupported scheme", host: "ftp://localhost", wantErr: "routes[0].upstreams[0].hosts: must be a valid URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "kono.yaml")
+			if err := os.WriteFile(path, []byte(fmt.Sprintf(hostsTestConfig, tt.host)), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadConfig(path)
+
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package kono
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
 // Context is the internal interface that holds the request and response objects.
 type Context interface {
@@ -26,3 +29,17 @@ func (c *defaultContext) Request() *http.Request       { return c.req }
 func (c *defaultContext) Response() *http.Response     { return c.resp }
 func (c *defaultContext) SetRequest(r *http.Request)   { c.req = r }
 func (c *defaultContext) SetResponse(r *http.Response) { c.resp = r }
+
+type ctxKeyClaims struct{}
+
+// WithClaims returns a copy of ctx carrying verified token claims.
+// Authentication middlewares use it so that the gateway (e.g. rate limit keys) and plugins can read the claims.
+func WithClaims(ctx context.Context, claims map[string]any) context.Context {
+	return context.WithValue(ctx, ctxKeyClaims{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored by WithClaims.
+func ClaimsFromContext(ctx context.Context) (map[string]any, bool) {
+	claims, ok := ctx.Value(ctxKeyClaims{}).(map[string]any)
+	return claims, ok
+}
@@ -0,0 +1,505 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono"
+	"github.com/starwalkn/kono/internal/redact"
+)
+
+const (
+	maxConfigSize = 1 << 20 // 1MB
+
+	// historySize bounds the applied config versions kept for rollbacks.
+	historySize = 20
+)
+
+// restartPaths are config sections which are not applied at runtime.
+var restartPaths = []string{
+	"debug",
+	"server.port",
+	"server.timeout",
+	"server.metrics",
+	"server.tls",
+	"server.tracing",
+	"server.access_log",
+	"dashboard",
+	"admin",
+}
+
+// Gateway is the running gateway.
+type Gateway interface {
+	Router() *kono.Router
+}
+
+// ConfigApplier is a gateway which applies configs at runtime. Applying a config replaces its router without
+// dropping requests.
+type ConfigApplier interface {
+	Gateway
+	Apply(cfg kono.Config) error
+}
+
+type readOnlyGateway struct {
+	gateway Gateway
+}
+
+// ReadOnly returns the gateway without the ability to apply configs, for dashboards without authentication.
+func ReadOnly(gateway Gateway) Gateway {
+	return readOnlyGateway{gateway: gateway}
+}
+
+func (g readOnlyGateway) Router() *kono.Router {
+	return g.gateway.Router()
+}
+
+// Change is a difference between two configs. Path addresses the value, e.g. "routes[0].upstreams[1].timeout".
+// Values of secret-looking keys are masked.
+type Change struct {
+	Path string `json:"path"`
+	Op   string `json:"op"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// Change operations.
+const (
+	OpAdded   = "added"
+	OpRemoved = "removed"
+	OpChanged = "changed"
+)
+
+// historyEntry is an applied config version.
+type historyEntry struct {
+	Version    int       `json:"version"`
+	AppliedAt  time.Time `json:"applied_at"`
+	User       string    `json:"user,omitempty"`
+	Changes    int       `json:"changes"`
+	RollbackOf int       `json:"rollback_of,omitempty"`
+
+	cfg kono.Config
+}
+
+type applyResponse struct {
+	Version int      `json:"version,omitempty"`
+	Changes []Change `json:"changes"`
+}
+
+type rollbackRequest struct {
+	Version int `json:"version"`
+}
+
+// diffConfig validates the submitted config and responds with its changes against the running config.
+func (s *Server) diffConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, changes, ok := s.parseConfig(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, applyResponse{Changes: changes})
+}
+
+// applyConfig validates the submitted config and applies it.
+func (s *Server) applyConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg, changes, ok := s.parseConfig(w, r)
+	if !ok {
+		return
+	}
+
+	s.apply(w, r, cfg, changes, 0)
+}
+
+// rollback applies the config of a version from the history.
+func (s *Server) rollback(w http.ResponseWriter, r *http.Request) {
+	var req rollbackRequest
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxConfigSize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&req); err != nil {
+		kono.WriteError(w, kono.ErrorCodeBadRequest, "invalid request body: "+err.Error(), "", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := slices.IndexFunc(s.history, func(e historyEntry) bool { return e.Version == req.Version })
+	if idx < 0 {
+		kono.WriteError(w, kono.ErrorCodeNotFound, fmt.Sprintf("version %d not found", req.Version), "", http.StatusNotFound)
+		return
+	}
+
+	cfg := s.history[idx].cfg
+
+	changes, err := diffConfigs(s.cfg, &cfg)
+	if err != nil {
+		s.internalError(w, err)
+		return
+	}
+
+	s.apply(w, r, cfg, changes, req.Version)
+}
+
+func (s *Server) configHistory(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := slices.Clone(s.history)
+	slices.Reverse(history)
+
+	writeJSON(w, history)
+}
+
+// apply applies the config and records it in the history. It is called with s.mu locked.
+func (s *Server) apply(w http.ResponseWriter, r *http.Request, cfg kono.Config, changes []Change, rollbackOf int) {
+	if len(changes) == 0 {
+		kono.WriteError(w, kono.ErrorCodeBadRequest, "config has no changes", "", http.StatusBadRequest)
+		return
+	}
+
+	if restart := restartChanges(changes); len(restart) > 0 {
+		kono.WriteError(w, kono.ErrorCodeBadRequest,
+			"changes require a restart: "+strings.Join(restart, ", "), "", http.StatusBadRequest)
+
+		return
+	}
+
+	// Tokens are verified with the settings of a middleware, which the config may change.
+	auth, err := newAuthenticator(&cfg)
+	if err != nil {
+		kono.WriteError(w, kono.ErrorCodeBadRequest, err.Error(), "", http.StatusBadRequest)
+		return
+	}
+
+	if err = s.applier.Apply(cfg); err != nil {
+		kono.WriteError(w, kono.ErrorCodeBadRequest, err.Error(), "", http.StatusBadRequest)
+		return
+	}
+
+	*s.cfg = cfg
+	s.auth.Store(auth)
+
+	u := userFromContext(r.Context())
+	entry := s.record(cfg, u.Name, len(changes), rollbackOf)
+
+	s.log.Warn("config applied via dashboard", zap.Int("version", entry.Version), zap.String("user", u.Name),
+		zap.Int("changes", len(changes)), zap.Int("rollback_of", rollbackOf))
+
+	writeJSON(w, applyResponse{Version: entry.Version, Changes: changes})
+}
+
+// record adds the config to the history, dropping the oldest version if it is full.
+func (s *Server) record(cfg kono.Config, user string, changes, rollbackOf int) historyEntry {
+	s.version++
+
+	entry := historyEntry{
+		Version:    s.version,
+		AppliedAt:  time.Now(),
+		User:       user,
+		Changes:    changes,
+		RollbackOf: rollbackOf,
+		cfg:        cfg,
+	}
+
+	s.history = append(s.history, entry)
+	if len(s.history) > historySize {
+		s.history = slices.Delete(s.history, 0, len(s.history)-historySize)
+	}
+
+	return entry
+}
+
+// parseConfig reads the submitted config, restores masked secrets from the running config and validates it like
+// LoadConfig. It is called with s.mu locked.
+func (s *Server) parseConfig(w http.ResponseWriter, r *http.Request) (kono.Config, []Change, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxConfigSize))
+	if err != nil {
+		kono.WriteError(w, kono.ErrorCodeBadRequest, "cannot read config: "+err.Error(), "", http.StatusBadRequest)
+		return kono.Config{}, nil, false
+	}
+
+	var submitted any
+	if err = json.Unmarshal(data, &submitted); err != nil {
+		kono.WriteError(w, kono.ErrorCodeBadRequest, "cannot parse config: "+err.Error(), "", http.StatusBadRequest)
+		return kono.Config{}, nil, false
+	}
+
+	current, err := toGeneric(s.cfg)
+	if err != nil {
+		s.internalError(w, err)
+		return kono.Config{}, nil, false
+	}
+
+	restored, err := unredact("", submitted, current)
+	if err != nil {
+		kono.WriteError(w, kono.ErrorCodeBadRequest, err.Error(), "", http.StatusBadRequest)
+		return kono.Config{}, nil, false
+	}
+
+	if data, err = json.Marshal(restored); err != nil {
+		s.internalError(w, err)
+		return kono.Config{}, nil, false
+	}
+
+	var cfg kono.Config
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err = dec.Decode(&cfg); err != nil {
+		kono.WriteError(w, kono.ErrorCodeBadRequest, "cannot parse config: "+err.Error(), "", http.StatusBadRequest)
+		return kono.Config{}, nil, false
+	}
+
+	if err = kono.ValidateConfig(&cfg); err != nil {
+		kono.WriteError(w, kono.ErrorCodeBadRequest, err.Error(), "", http.StatusBadRequest)
+		return kono.Config{}, nil, false
+	}
+
+	changes, err := diffConfigs(s.cfg, &cfg)
+	if err != nil {
+		s.internalError(w, err)
+		return kono.Config{}, nil, false
+	}
+
+	return cfg, changes, true
+}
+
+func (s *Server) internalError(w http.ResponseWriter, err error) {
+	s.log.Error("cannot process config", zap.Error(err))
+	kono.WriteError(w, kono.ErrorCodeInternal, "internal error", "", http.StatusInternalServerError)
+}
+
+// unredact replaces masked values of the submitted config by the values of the same entry of the current one,
+// so that a config read from the dashboard can be applied with its secrets. List entries are matched by their
+// identity, see elementKey, so that deleting or reordering entries cannot move a secret to another entry.
+// A masked value without a counterpart in the current config is rejected.
+func unredact(path string, submitted, current any) (any, error) {
+	switch val := submitted.(type) {
+	case map[string]any:
+		cur, _ := current.(map[string]any)
+
+		for k, child := range val {
+			restored, err := unredact(joinPath(path, k), child, cur[k])
+			if err != nil {
+				return nil, err
+			}
+
+			val[k] = restored
+		}
+
+		return val, nil
+	case []any:
+		cur, _ := current.([]any)
+
+		for i, child := range val {
+			restored, err := unredact(path+"["+strconv.Itoa(i)+"]", child, counterpart(child, i, len(val), cur))
+			if err != nil {
+				return nil, err
+			}
+
+			val[i] = restored
+		}
+
+		return val, nil
+	case string:
+		if val != redact.Mask {
+			return val, nil
+		}
+
+		if current == nil {
+			return nil, fmt.Errorf("%s: masked value has no counterpart in the running config, enter it again", path)
+		}
+
+		return current, nil
+	default:
+		return val, nil
+	}
+}
+
+// counterpart returns the entry of the current list matching the submitted entry at index i. Entries with an
+// identity are matched by it, others by index if the list length is unchanged.
+func counterpart(submitted any, i, n int, current []any) any {
+	if key := elementKey(submitted); key != "" {
+		for _, c := range current {
+			if elementKey(c) == key {
+				return c
+			}
+		}
+
+		return nil
+	}
+
+	if n != len(current) || elementKey(current[i]) != "" {
+		return nil
+	}
+
+	return current[i]
+}
+
+// elementKey returns the identity of a list entry: the name of middlewares, upstreams, plugins and the like, or
+// the method and path of routes. It is empty for other entries.
+func elementKey(v any) string {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	if name, _ := m["name"].(string); name != "" {
+		return "name:" + name
+	}
+
+	path, _ := m["path"].(string)
+	method, _ := m["method"].(string)
+
+	if path != "" || method != "" {
+		return "route:" + method + " " + path
+	}
+
+	return ""
+}
+
+// diffConfigs returns the changes from the old to the new config.
+func diffConfigs(oldCfg, newCfg *kono.Config) ([]Change, error) {
+	oldVal, err := toGeneric(oldCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	newVal, err := toGeneric(newCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	diffValues("", oldVal, newVal, false, &changes)
+
+	return changes, nil
+}
+
+func diffValues(path string, oldVal, newVal any, secret bool, changes *[]Change) {
+	mask := func(v any) any {
+		if secret {
+			return redactValue(v, true)
+		}
+
+		return v
+	}
+
+	switch {
+	case oldVal == nil && newVal == nil:
+		return
+	case oldVal == nil:
+		*changes = append(*changes, Change{Path: path, Op: OpAdded, New: mask(newVal)})
+		return
+	case newVal == nil:
+		*changes = append(*changes, Change{Path: path, Op: OpRemoved, Old: mask(oldVal)})
+		return
+	}
+
+	oldMap, oldIsMap := oldVal.(map[string]any)
+	newMap, newIsMap := newVal.(map[string]any)
+
+	if oldIsMap && newIsMap {
+		keys := slices.Collect(maps.Keys(oldMap))
+		for k := range newMap {
+			if _, ok := oldMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			diffValues(joinPath(path, k), oldMap[k], newMap[k], secret || secretKey.MatchString(k), changes)
+		}
+
+		return
+	}
+
+	oldList, oldIsList := oldVal.([]any)
+	newList, newIsList := newVal.([]any)
+
+	if oldIsList && newIsList {
+		for i := range max(len(oldList), len(newList)) {
+			var o, n any
+
+			if i < len(oldList) {
+				o = oldList[i]
+			}
+
+			if i < len(newList) {
+				n = newList[i]
+			}
+
+			diffValues(path+"["+strconv.Itoa(i)+"]", o, n, secret, changes)
+		}
+
+		return
+	}
+
+	if oldIsMap || newIsMap || oldIsList || newIsList || oldVal != newVal {
+		*changes = append(*changes, Change{Path: path, Op: OpChanged, Old: mask(oldVal), New: mask(newVal)})
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// restartChanges returns the paths of changes which are not applied at runtime.
+func restartChanges(changes []Change) []string {
+	var paths []string
+
+	for _, c := range changes {
+		for _, p := range restartPaths {
+			if c.Path == p || strings.HasPrefix(c.Path, p+".") || strings.HasPrefix(c.Path, p+"[") {
+				paths = append(paths, c.Path)
+				break
+			}
+		}
+	}
+
+	return paths
+}
+
+// toGeneric returns the JSON form of the config as maps and slices.
+func toGeneric(cfg *kono.Config) (any, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal config: %w", err)
+	}
+
+	var v any
+	if err = json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+
+	if v == nil {
+		return nil, errors.New("empty config")
+	}
+
+	return v, nil
+}
@@ -0,0 +1,384 @@
+package dashboard
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono"
+)
+
+func newApplyTestServer(t *testing.T, configure ...func(cfg *kono.Config)) (*Server, *testGateway, *kono.Config) {
+	t.Helper()
+
+	cfg := &kono.Config{
+		ConfigVersion: "v1",
+		Name:          "test",
+		Version:       "1.0.0",
+		Server:        kono.ServerConfig{Port: 8080},
+		Admin:         kono.AdminConfig{Tokens: []string{"admin-token"}},
+		Routes: []kono.RouteConfig{
+			{
+				Path:   "/users",
+				Method: http.MethodGet,
+				Upstreams: []kono.UpstreamConfig{
+					{Name: "users", Hosts: []string{"http://users.local"}, Method: http.MethodGet},
+				},
+				Aggregation: kono.AggregationConfig{Strategy: "merge"},
+			},
+		},
+	}
+
+	withAuth(t)(cfg)
+
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	if err := kono.ValidateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	gateway := &testGateway{stats: kono.NewTrafficStats()}
+	if err := gateway.Apply(*cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServer(cfg, gateway, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, gateway, cfg
+}
+
+// serve serves the request as the admin user of withAuth.
+func serve(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.SetBasicAuth("alice", "pa55")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// editedConfig returns the config served by the dashboard with the edit applied.
+func editedConfig(t *testing.T, h http.Handler, edit func(cfg map[string]any)) string {
+	t.Helper()
+
+	rec := serve(t, h, http.MethodGet, "/config", "")
+
+	var cfg map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	edit(cfg)
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func firstRoute(cfg map[string]any) map[string]any {
+	return cfg["routes"].([]any)[0].(map[string]any) //nolint:forcetypeassert // test config
+}
+
+func TestServer_DiffConfig(t *testing.T) {
+	s, _, _ := newApplyTestServer(t)
+	h := s.handler(t.TempDir())
+
+	body := editedConfig(t, h, func(cfg map[string]any) {
+		firstRoute(cfg)["path"] = "/customers"
+		cfg["admin"].(map[string]any)["tokens"] = []any{"new-token"} //nolint:forcetypeassert // test config
+	})
+
+	rec := serve(t, h, http.MethodPost, "/config/diff", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp applyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Change{
+		{Path: "admin.tokens[0]", Op: OpChanged, Old: "[REDACTED]", New: "[REDACTED]"},
+		{Path: "routes[0].path", Op: OpChanged, Old: "/users", New: "/customers"},
+	}
+
+	if len(resp.Changes) != len(want) {
+		t.Fatalf("unexpected changes: %+v", resp.Changes)
+	}
+
+	for i, c := range want {
+		if resp.Changes[i] != c {
+			t.Fatalf("expected change %+v, got %+v", c, resp.Changes[i])
+		}
+	}
+
+	if rec = serve(t, h, http.MethodPost, "/config/diff", `{"config_version":"v2"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid config to be rejected, got %d", rec.Code)
+	}
+
+	if !strings.Contains(rec.Body.String(), "config_version") {
+		t.Fatalf("expected validation error, got %s", rec.Body)
+	}
+}
+
+func TestServer_ApplyAndRollback(t *testing.T) {
+	s, gateway, cfg := newApplyTestServer(t)
+	h := s.handler(t.TempDir())
+
+	body := editedConfig(t, h, func(cfg map[string]any) {
+		firstRoute(cfg)["path"] = "/customers"
+	})
+
+	rec := serve(t, h, http.MethodPost, "/config/apply", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if path := gateway.Router().Routes[0].Path; path != "/customers" {
+		t.Fatalf("expected applied router, got route %s", path)
+	}
+
+	// Masked secrets are restored from the running config.
+	if cfg.Routes[0].Path != "/customers" || cfg.Admin.Tokens[0] != "admin-token" {
+		t.Fatalf("unexpected running config: %+v", cfg)
+	}
+
+	if rec = serve(t, h, http.MethodPost, "/config/apply", body); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected config without changes to be rejected, got %d", rec.Code)
+	}
+
+	restart := editedConfig(t, h, func(cfg map[string]any) {
+		cfg["server"].(map[string]any)["port"] = 9090 //nolint:forcetypeassert // test config
+	})
+
+	if rec = serve(t, h, http.MethodPost, "/config/apply", restart); rec.Code != http.StatusBadRequest ||
+		!strings.Contains(rec.Body.String(), "server.port") {
+		t.Fatalf("expected restart change to be rejected, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec = serve(t, h, http.MethodPost, "/config/rollback", `{"version":1}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if path := gateway.Router().Routes[0].Path; path != "/users" {
+		t.Fatalf("expected rolled back router, got route %s", path)
+	}
+
+	if rec = serve(t, h, http.MethodPost, "/config/rollback", `{"version":42}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown version to be not found, got %d", rec.Code)
+	}
+
+	rec = serve(t, h, http.MethodGet, "/config/history", "")
+
+	var history []historyEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 3 || history[0].Version != 3 || history[0].RollbackOf != 1 || history[1].Changes != 1 {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+}
+
+func TestServer_ApplyRestoresSecretsByEntry(t *testing.T) {
+	s, _, cfg := newApplyTestServer(t, func(cfg *kono.Config) {
+		for _, name := range []string{"signer-a", "signer-b"} {
+			cfg.Middlewares = append(cfg.Middlewares, kono.MiddlewareConfig{
+				Name:   name,
+				Config: map[string]any{"hmac_secret": name + "-secret"},
+			})
+		}
+	})
+	h := s.handler(t.TempDir())
+
+	secretOf := func(name string) any {
+		for _, m := range cfg.Middlewares {
+			if m.Name == name {
+				return m.Config["hmac_secret"]
+			}
+		}
+
+		return nil
+	}
+
+	middlewares := func(cfg map[string]any) []any {
+		return cfg["middlewares"].([]any) //nolint:forcetypeassert // test config
+	}
+
+	// Reordered entries keep their own secrets.
+	body := editedConfig(t, h, func(cfg map[string]any) {
+		m := middlewares(cfg)
+		m[0], m[1], m[2] = m[2], m[0], m[1]
+	})
+
+	if rec := serve(t, h, http.MethodPost, "/config/apply", body); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if secretOf("signer-a") != "signer-a-secret" || secretOf("signer-b") != "signer-b-secret" ||
+		secretOf("auth") != base64.StdEncoding.EncodeToString(testSecret) {
+		t.Fatalf("secrets moved between middlewares: %+v", cfg.Middlewares)
+	}
+
+	// Deleting an entry does not shift the secrets of the others.
+	body = editedConfig(t, h, func(cfg map[string]any) {
+		cfg["middlewares"] = slices.DeleteFunc(middlewares(cfg), func(m any) bool {
+			return m.(map[string]any)["name"] == "signer-a" //nolint:forcetypeassert // test config
+		})
+	})
+
+	if rec := serve(t, h, http.MethodPost, "/config/apply", body); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if len(cfg.Middlewares) != 2 || secretOf("signer-b") != "signer-b-secret" {
+		t.Fatalf("unexpected middlewares: %+v", cfg.Middlewares)
+	}
+
+	// A masked secret of a renamed entry has no counterpart.
+	body = editedConfig(t, h, func(cfg map[string]any) {
+		for _, m := range middlewares(cfg) {
+			if m := m.(map[string]any); m["name"] == "signer-b" { //nolint:forcetypeassert // test config
+				m["name"] = "signer-c"
+			}
+		}
+	})
+
+	if rec := serve(t, h, http.MethodPost, "/config/apply", body); rec.Code != http.StatusBadRequest ||
+		!strings.Contains(rec.Body.String(), "hmac_secret") {
+		t.Fatalf("expected masked secret without counterpart to be rejected, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestServer_ApplyRotatesJWTKey(t *testing.T) {
+	s, _, _ := newApplyTestServer(t)
+	h := s.handler(t.TempDir())
+
+	claims := jwt.MapClaims{
+		"iss": "kono", "aud": "dashboard", "sub": "carol", "role": RoleViewer,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+
+	oldToken := signToken(t, claims)
+
+	newSecret := []byte("rotated-dashboard-secret")
+
+	newToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(newSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	me := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	if code := me(oldToken); code != http.StatusOK {
+		t.Fatalf("expected token to be accepted, got %d", code)
+	}
+
+	body := editedConfig(t, h, func(cfg map[string]any) {
+		m := cfg["middlewares"].([]any)[0].(map[string]any)["config"].(map[string]any) //nolint:forcetypeassert // test config
+		m["hmac_secret"] = base64.StdEncoding.EncodeToString(newSecret)
+	})
+
+	if rec := serve(t, h, http.MethodPost, "/config/apply", body); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	if code := me(oldToken); code != http.StatusUnauthorized {
+		t.Fatalf("expected token of the rotated key to be rejected, got %d", code)
+	}
+
+	if code := me(newToken); code != http.StatusOK {
+		t.Fatalf("expected token of the new key to be accepted, got %d", code)
+	}
+}
+
+func TestServer_ApplyRequiresAdmin(t *testing.T) {
+	srv := newTestServer(t, withAuth(t))
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, srv.URL+"/config/apply", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.SetBasicAuth("bob", "pa55")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected viewer to be forbidden, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_AnonymousReadOnly(t *testing.T) {
+	cfg := &kono.Config{ConfigVersion: "v1", Name: "test", Version: "1.0.0", Server: kono.ServerConfig{Port: 8080}}
+
+	if _, err := NewServer(cfg, &testGateway{}, zap.NewNop()); err == nil {
+		t.Fatal("expected dashboard applying configs without authentication to be rejected")
+	}
+
+	srv := newTestServer(t)
+
+	resp := doRequest(t, http.MethodGet, srv.URL+"/me", nil)
+
+	var me user
+	if err := json.NewDecoder(resp.Body).Decode(&me); err != nil {
+		t.Fatal(err)
+	}
+
+	if me.Role != RoleViewer {
+		t.Fatalf("expected anonymous viewer, got %+v", me)
+	}
+
+	for _, path := range []string{"/config/diff", "/config/apply", "/config/rollback"} {
+		if resp = doRequest(t, http.MethodPost, srv.URL+path, nil); resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected anonymous %s to be forbidden, got %d", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestHistory_Bounded(t *testing.T) {
+	s, _, cfg := newApplyTestServer(t)
+
+	for range historySize + 5 {
+		s.record(*cfg, "alice", 1, 0)
+	}
+
+	if len(s.history) != historySize || s.history[0].Version != 7 {
+		t.Fatalf("unexpected history: %d entries, oldest %d", len(s.history), s.history[0].Version)
+	}
+}
@@ -0,0 +1,258 @@
+package dashboard
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/starwalkn/kono"
+)
+
+// Dashboard roles. Viewers can only read, admins can also change the gateway.
+const (
+	RoleViewer = "viewer"
+	RoleAdmin  = "admin"
+)
+
+const jwtLeeway = 5 * time.Second
+
+type ctxKeyUser struct{}
+
+// user is the authenticated dashboard user.
+type user struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// authenticator verifies basic auth credentials of static users and bearer tokens.
+type authenticator struct {
+	users map[string]kono.DashboardUserConfig
+
+	// dummyHash is compared for unknown users, so that they cannot be told apart by the response time.
+	dummyHash []byte
+
+	// jwt is nil if JWT authentication is disabled.
+	jwt *jwtVerifier
+}
+
+func newAuthenticator(cfg *kono.Config) (*authenticator, error) {
+	auth := cfg.Dashboard.Auth
+
+	a := &authenticator{
+		users: make(map[string]kono.DashboardUserConfig, len(auth.Users)),
+	}
+
+	for _, u := range auth.Users {
+		a.users[u.Name] = u
+	}
+
+	if len(a.users) > 0 {
+		cost, err := bcrypt.Cost([]byte(auth.Users[0].PasswordHash))
+		if err != nil {
+			return nil, fmt.Errorf("invalid password hash of user %s: %w", auth.Users[0].Name, err)
+		}
+
+		if a.dummyHash, err = bcrypt.GenerateFromPassword([]byte("kono"), cost); err != nil {
+			return nil, fmt.Errorf("cannot generate dummy password hash: %w", err)
+		}
+	}
+
+	if !auth.JWT.Enabled {
+		return a, nil
+	}
+
+	idx := slices.IndexFunc(cfg.Middlewares, func(m kono.MiddlewareConfig) bool { return m.Name == auth.JWT.Middleware })
+	if idx < 0 {
+		return nil, fmt.Errorf("middleware %q of dashboard jwt auth not found", auth.JWT.Middleware)
+	}
+
+	verifier, err := newJWTVerifier(cfg.Middlewares[idx].Config, auth.JWT.RoleClaim)
+	if err != nil {
+		return nil, fmt.Errorf("cannot configure dashboard jwt auth: %w", err)
+	}
+
+	a.jwt = verifier
+
+	return a, nil
+}
+
+// enabled reports whether any authentication is configured.
+func (a *authenticator) enabled() bool {
+	return len(a.users) > 0 || a.jwt != nil
+}
+
+// challenge is the WWW-Authenticate header of unauthenticated responses.
+func (a *authenticator) challenge() string {
+	if len(a.users) > 0 {
+		return `Basic realm="kono-dashboard"`
+	}
+
+	return `Bearer realm="kono-dashboard"`
+}
+
+func (a *authenticator) authenticate(r *http.Request) (user, bool) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && a.jwt != nil {
+		return a.jwt.verify(token)
+	}
+
+	name, password, ok := r.BasicAuth()
+	if !ok || len(a.users) == 0 {
+		return user{}, false
+	}
+
+	u, found := a.users[name]
+
+	hash := a.dummyHash
+	if found {
+		hash = []byte(u.PasswordHash)
+	}
+
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || !found {
+		return user{}, false
+	}
+
+	return user{Name: u.Name, Role: u.Role}, true
+}
+
+// authorize authenticates requests if authentication is enabled and allows viewers only safe methods. Without
+// authentication, everyone is an anonymous viewer.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := user{Name: "anonymous", Role: RoleViewer}
+
+		if auth := s.auth.Load(); auth.enabled() {
+			var ok bool
+
+			if u, ok = auth.authenticate(r); !ok {
+				w.Header().Set("WWW-Authenticate", auth.challenge())
+				kono.WriteError(w, kono.ErrorCodeUnauthorized, "unauthorized", "", http.StatusUnauthorized)
+
+				return
+			}
+		}
+
+		if u.Role != RoleAdmin && r.Method != http.MethodGet && r.Method != http.MethodHead {
+			kono.WriteError(w, kono.ErrorCodeForbidden, "admin role required", "", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyUser{}, u)))
+	})
+}
+
+func userFromContext(ctx context.Context) user {
+	u, _ := ctx.Value(ctxKeyUser{}).(user)
+	return u
+}
+
+// jwtVerifier verifies tokens with the settings of the auth middleware: issuer, audience, alg and
+// hmac_secret (base64) or rsa_public_key (PEM).
+type jwtVerifier struct {
+	parser    *jwt.Parser
+	key       any
+	roleClaim string
+}
+
+func newJWTVerifier(config map[string]any, roleClaim string) (*jwtVerifier, error) {
+	issuer, _ := config["issuer"].(string)
+	audience, _ := config["audience"].(string)
+	alg, _ := config["alg"].(string)
+
+	if issuer == "" || audience == "" {
+		return nil, errors.New("issuer and audience are required")
+	}
+
+	v := &jwtVerifier{
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{alg}),
+			jwt.WithIssuer(issuer),
+			jwt.WithAudience(audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(jwtLeeway),
+		),
+		roleClaim: roleClaim,
+	}
+
+	switch alg {
+	case jwt.SigningMethodHS256.Alg():
+		secret, _ := config["hmac_secret"].(string)
+
+		key, err := base64.StdEncoding.DecodeString(secret)
+		if err != nil || len(key) == 0 {
+			return nil, errors.New("hmac_secret must be a base64 encoded secret")
+		}
+
+		v.key = key
+	case jwt.SigningMethodRS256.Alg():
+		pemStr, _ := config["rsa_public_key"].(string)
+
+		block, _ := pem.Decode([]byte(pemStr))
+		if block == nil {
+			return nil, errors.New("rsa_public_key must be a PEM encoded public key")
+		}
+
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse rsa_public_key: %w", err)
+		}
+
+		v.key = key
+	default:
+		return nil, fmt.Errorf("unsupported signing method: %s", alg)
+	}
+
+	return v, nil
+}
+
+func (v *jwtVerifier) verify(tokenString string) (user, bool) {
+	claims := jwt.MapClaims{}
+
+	token, err := v.parser.ParseWithClaims(tokenString, claims, func(*jwt.Token) (any, error) { return v.key, nil })
+	if err != nil || !token.Valid {
+		return user{}, false
+	}
+
+	role := roleOf(claims[v.roleClaim])
+	if role == "" {
+		return user{}, false
+	}
+
+	name, _ := claims.GetSubject()
+
+	return user{Name: name, Role: role}, true
+}
+
+// roleOf returns the highest dashboard role of a role claim, which is a string or a list of strings.
+func roleOf(claim any) string {
+	var roles []string
+
+	switch c := claim.(type) {
+	case string:
+		roles = []string{c}
+	case []any:
+		for _, r := range c {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+	}
+
+	switch {
+	case slices.Contains(roles, RoleAdmin):
+		return RoleAdmin
+	case slices.Contains(roles, RoleViewer):
+		return RoleViewer
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,265 @@
+package dashboard
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/starwalkn/kono"
+)
+
+var testSecret = []byte("dashboard-test-secret")
+
+func withAuth(t *testing.T) func(cfg *kono.Config) {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("pa55"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return func(cfg *kono.Config) {
+		cfg.Middlewares = []kono.MiddlewareConfig{
+			{
+				Name: "auth",
+				Config: map[string]any{
+					"issuer":      "kono",
+					"audience":    "dashboard",
+					"alg":         "HS256",
+					"hmac_secret": base64.StdEncoding.EncodeToString(testSecret),
+				},
+			},
+		}
+
+		cfg.Dashboard.Auth = kono.DashboardAuthConfig{
+			Users: []kono.DashboardUserConfig{
+				{Name: "alice", PasswordHash: string(hash), Role: RoleAdmin},
+				{Name: "bob", PasswordHash: string(hash), Role: RoleViewer},
+			},
+			JWT: kono.DashboardJWTConfig{Enabled: true, Middleware: "auth", RoleClaim: "role"},
+		}
+	}
+}
+
+func signToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func doRequest(t *testing.T, method, url string, auth func(r *http.Request)) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if auth != nil {
+		auth(req)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func TestServer_Auth(t *testing.T) {
+	srv := newTestServer(t, withAuth(t))
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name     string
+		method   string
+		auth     func(r *http.Request)
+		want     int
+		wantRole string
+	}{
+		{name: "anonymous", method: http.MethodGet, want: http.StatusUnauthorized},
+		{
+			name:   "wrong password",
+			method: http.MethodGet,
+			auth:   func(r *http.Request) { r.SetBasicAuth("alice", "wrong") },
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "unknown user",
+			method: http.MethodGet,
+			auth:   func(r *http.Request) { r.SetBasicAuth("mallory", "pa55") },
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:     "admin user",
+			method:   http.MethodGet,
+			auth:     func(r *http.Request) { r.SetBasicAuth("alice", "pa55") },
+			want:     http.StatusOK,
+			wantRole: RoleAdmin,
+		},
+		{
+			name:     "viewer user",
+			method:   http.MethodGet,
+			auth:     func(r *http.Request) { r.SetBasicAuth("bob", "pa55") },
+			want:     http.StatusOK,
+			wantRole: RoleViewer,
+		},
+		{
+			name:   "viewer write",
+			method: http.MethodPost,
+			auth:   func(r *http.Request) { r.SetBasicAuth("bob", "pa55") },
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "jwt",
+			method: http.MethodGet,
+			auth: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer "+signToken(t, jwt.MapClaims{
+					"iss": "kono", "aud": "dashboard", "sub": "carol", "exp": exp, "role": []any{"dev", "admin"},
+				}))
+			},
+			want:     http.StatusOK,
+			wantRole: RoleAdmin,
+		},
+		{
+			name:   "jwt without role",
+			method: http.MethodGet,
+			auth: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer "+signToken(t, jwt.MapClaims{
+					"iss": "kono", "aud": "dashboard", "sub": "carol", "exp": exp,
+				}))
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name:   "jwt wrong audience",
+			method: http.MethodGet,
+			auth: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer "+signToken(t, jwt.MapClaims{
+					"iss": "kono", "aud": "other", "exp": exp, "role": "admin",
+				}))
+			},
+			want: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, tt.method, srv.URL+"/me", tt.auth)
+			if resp.StatusCode != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, resp.StatusCode)
+			}
+
+			if tt.want == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+				t.Fatal("expected WWW-Authenticate header")
+			}
+
+			if tt.wantRole == "" {
+				return
+			}
+
+			var u user
+			if err := json.NewDecoder(resp.Body).Decode(&u); err != nil {
+				t.Fatal(err)
+			}
+
+			if u.Role != tt.wantRole {
+				t.Fatalf("expected role %s, got %+v", tt.wantRole, u)
+			}
+		})
+	}
+
+	// Static files are served without authentication.
+	if resp := doRequest(t, http.MethodGet, srv.URL+"/", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected static files to be public, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_ConfigRedacted(t *testing.T) {
+	srv := newTestServer(t, withAuth(t), func(cfg *kono.Config) {
+		cfg.Admin.Tokens = []string{"admin-token"}
+		cfg.Server.Tracing.Headers = map[string]string{"Authorization": "Bearer collector", "X-Tenant": "acme"}
+	})
+
+	resp := doRequest(t, http.MethodGet, srv.URL+"/config", func(r *http.Request) { r.SetBasicAuth("bob", "pa55") })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := string(data)
+
+	for _, secret := range []string{base64.StdEncoding.EncodeToString(testSecret), "admin-token", "$2a$", "collector"} {
+		if strings.Contains(body, secret) {
+			t.Fatalf("config leaks %q: %s", secret, body)
+		}
+	}
+
+	for _, kept := range []string{`"issuer":"kono"`, `"X-Tenant":"acme"`, `"name":"alice"`} {
+		if !strings.Contains(body, kept) {
+			t.Fatalf("config misses %s: %s", kept, body)
+		}
+	}
+}
+
+func TestConfig_DashboardAuthValidation(t *testing.T) {
+	const cfg = `
+config_version: v1
+name: test
+version: "1"
+server:
+  port: 8080
+dashboard:
+  enabled: true
+  auth:
+    users:
+      - name: alice
+        password_hash: plain
+        role: root
+    jwt:
+      enabled: true
+routes:
+  - path: /users
+    method: GET
+    upstreams:
+      - hosts: [http://localhost:8081]
+`
+
+	path := filepath.Join(t.TempDir(), "kono.yaml")
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := kono.LoadConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	for _, want := range []string{"must be one of [viewer admin]", "must be a bcrypt hash", "must name a global middleware"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package dashboard
+
+import (
+	"regexp"
+
+	"github.com/starwalkn/kono"
+	"github.com/starwalkn/kono/internal/redact"
+)
+
+// secretKey matches config keys whose values are masked in the config served by the dashboard, e.g. the
+// hmac_secret of the auth middleware, admin tokens, dashboard password hashes or authorization headers.
+var secretKey = regexp.MustCompile(`(?i)secret|passw(or)?d|token|private_?key|api_?key|credential|authorization|cookie`)
+
+// redactConfig returns the JSON form of the config with secret-looking values masked.
+func redactConfig(cfg *kono.Config) (any, error) {
+	v, err := toGeneric(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return redactValue(v, false), nil
+}
+
+// redactValue masks every non-empty scalar below a secret key.
+func redactValue(v any, secret bool) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			val[k] = redactValue(child, secret || secretKey.MatchString(k))
+		}
+
+		return val
+	case []any:
+		for i, child := range val {
+			val[i] = redactValue(child, secret)
+		}
+
+		return val
+	case nil:
+		return nil
+	case string:
+		if secret && val != "" {
+			return redact.Mask
+		}
+
+		return val
+	default:
+		if secret {
+			return redact.Mask
+		}
+
+		return val
+	}
+}
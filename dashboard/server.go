@@ -2,46 +2,81 @@ package dashboard
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/starwalkn/kono"
 )
 
+// statsInterval is the period of stats events pushed to the live traffic view.
+const statsInterval = time.Second
+
 type Server struct {
-	cfg *kono.Config
-	log *zap.Logger
+	gateway Gateway
+	// applier is nil if configs cannot be applied from the dashboard.
+	applier ConfigApplier
+	// auth is replaced when a config is applied, as tokens are verified with the settings of a middleware.
+	auth atomic.Pointer[authenticator]
+	log  *zap.Logger
+
+	mu sync.Mutex // guards the config and its history
+	// cfg is the running config, replaced when a config is applied.
+	cfg     *kono.Config
+	history []historyEntry
+	version int
 }
 
-func NewServer(cfg *kono.Config, log *zap.Logger) *Server {
-	return &Server{
-		cfg: cfg,
-		log: log,
+func NewServer(cfg *kono.Config, gateway Gateway, log *zap.Logger) (*Server, error) {
+	auth, err := newAuthenticator(cfg)
+	if err != nil {
+		return nil, err
 	}
-}
 
-func (s *Server) Start() {
-	mux := http.NewServeMux()
+	applier, _ := gateway.(ConfigApplier)
 
-	staticDir := filepath.Join("/", "dashboard", "static")
-	mux.Handle("/", http.FileServer(http.Dir(staticDir)))
+	if !auth.enabled() {
+		// Applied configs load plugins from disk and route traffic anywhere, they must not be anonymous.
+		if applier != nil {
+			return nil, errors.New("dashboard authentication is required to apply configs")
+		}
 
-	mux.HandleFunc("/config", func(w http.ResponseWriter, _ *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+		log.Warn("dashboard authentication is not configured, the dashboard is read-only and open to anyone " +
+			"who can reach it")
+	}
 
-		//nolint:errcheck,gosec // its ok
-		json.NewEncoder(w).Encode(s.cfg)
-	})
+	s := &Server{
+		gateway: gateway,
+		applier: applier,
+		log:     log,
+		cfg:     cfg,
+	}
+
+	s.auth.Store(auth)
+	s.record(*cfg, "", 0, 0)
+
+	return s, nil
+}
 
+// statsResponse is the body of the stats endpoint and of stats events.
+type statsResponse struct {
+	Time   time.Time         `json:"time"`
+	Window float64           `json:"window_seconds"`
+	Routes []kono.RouteStats `json:"routes"`
+}
+
+func (s *Server) Start() {
 	addr := fmt.Sprintf(":%d", s.cfg.Dashboard.Port)
 
 	server := http.Server{
 		Addr:         addr,
-		Handler:      mux,
+		Handler:      s.handler(filepath.Join("/", "dashboard", "static")),
 		ReadTimeout:  s.cfg.Dashboard.Timeout,
 		WriteTimeout: s.cfg.Dashboard.Timeout,
 	}
@@ -53,3 +88,110 @@ func (s *Server) Start() {
 		return
 	}
 }
+
+func (s *Server) handler(staticDir string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.Handle("/", http.FileServer(http.Dir(staticDir)))
+
+	// Static files hold no data, the API requires authentication.
+	api := http.NewServeMux()
+
+	api.HandleFunc("GET /me", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, userFromContext(r.Context()))
+	})
+
+	api.HandleFunc("GET /config", s.config)
+	api.HandleFunc("GET /config/history", s.configHistory)
+
+	if s.applier != nil {
+		api.HandleFunc("POST /config/diff", s.diffConfig)
+		api.HandleFunc("POST /config/apply", s.applyConfig)
+		api.HandleFunc("POST /config/rollback", s.rollback)
+	}
+
+	api.HandleFunc("GET /stats", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, s.stats())
+	})
+
+	api.HandleFunc("GET /stats/stream", s.streamStats)
+
+	protected := s.authorize(api)
+
+	for _, pattern := range []string{"/me", "/config", "/config/", "/stats", "/stats/stream"} {
+		mux.Handle(pattern, protected)
+	}
+
+	return mux
+}
+
+// config serves the running config with secret-looking values masked.
+func (s *Server) config(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	cfg, err := redactConfig(s.cfg)
+	s.mu.Unlock()
+
+	if err != nil {
+		s.log.Error("cannot redact config", zap.Error(err))
+		kono.WriteError(w, kono.ErrorCodeInternal, "internal error", "", http.StatusInternalServerError)
+
+		return
+	}
+
+	writeJSON(w, cfg)
+}
+
+func (s *Server) stats() statsResponse {
+	return statsResponse{
+		Time:   time.Now(),
+		Window: kono.StatsWindow.Seconds(),
+		Routes: s.gateway.Router().RoutesStats(),
+	}
+}
+
+// streamStats pushes the stats as server-sent events every statsInterval until the client disconnects.
+func (s *Server) streamStats(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	// The stream outlives the server write timeout.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		s.log.Debug("cannot disable write deadline of stats stream", zap.Error(err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	for {
+		data, err := json.Marshal(s.stats())
+		if err != nil {
+			s.log.Error("cannot marshal stats", zap.Error(err))
+			return
+		}
+
+		if _, err = fmt.Fprintf(w, "event: stats\ndata: %s\n\n", data); err != nil {
+			return
+		}
+
+		if err = rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	//nolint:errcheck,gosec // its ok
+	json.NewEncoder(w).Encode(v)
+}
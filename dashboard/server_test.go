@@ -0,0 +1,149 @@
+package dashboard
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono"
+)
+
+// testGateway replaces its router like the gateway server does.
+type testGateway struct {
+	stats  *kono.TrafficStats
+	router *kono.Router
+}
+
+func (g *testGateway) Router() *kono.Router {
+	return g.router
+}
+
+func (g *testGateway) Apply(cfg kono.Config) error {
+	router, err := kono.BuildRouter(kono.RouterConfigSet{Routes: cfg.Routes, TrafficStats: g.stats}, zap.NewNop())
+	if err != nil {
+		return err
+	}
+
+	g.router = router
+
+	return nil
+}
+
+func newTestServer(t *testing.T, configure ...func(cfg *kono.Config)) *httptest.Server {
+	t.Helper()
+
+	cfg := &kono.Config{
+		ConfigVersion: "v1",
+		Name:          "test",
+		Version:       "1.0.0",
+		Server:        kono.ServerConfig{Port: 8080},
+		Routes: []kono.RouteConfig{
+			{
+				Path:   "/users",
+				Method: http.MethodGet,
+				Upstreams: []kono.UpstreamConfig{
+					{Name: "users", Hosts: []string{"http://users.local"}, Method: http.MethodGet},
+				},
+				Aggregation: kono.AggregationConfig{Strategy: "merge"},
+			},
+		},
+	}
+
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	if err := kono.ValidateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	gateway := &testGateway{stats: kono.NewTrafficStats()}
+	if err := gateway.Apply(*cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var g Gateway = gateway
+	if len(cfg.Dashboard.Auth.Users) == 0 && !cfg.Dashboard.Auth.JWT.Enabled {
+		g = ReadOnly(gateway)
+	}
+
+	s, err := NewServer(cfg, g, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(s.handler(t.TempDir()))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestServer_Stats(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, err := http.Get(srv.URL + "/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var stats statsResponse
+	if err = json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Window != 60 || len(stats.Routes) != 1 || stats.Routes[0].Path != "/users" {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	if hosts := stats.Routes[0].Upstreams[0].Hosts; len(hosts) != 1 || hosts[0].URL != "http://users.local" {
+		t.Fatalf("unexpected upstream health: %+v", hosts)
+	}
+}
+
+func TestServer_StatsStream(t *testing.T) {
+	srv := newTestServer(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stats/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+
+	if !scanner.Scan() || scanner.Text() != "event: stats" {
+		t.Fatalf("expected stats event, got %q", scanner.Text())
+	}
+
+	if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), "data: ") {
+		t.Fatalf("expected event data, got %q", scanner.Text())
+	}
+
+	var stats statsResponse
+	if err = json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data: ")), &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats.Routes) != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
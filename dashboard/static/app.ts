@@ -1,53 +1,238 @@
 // main.ts — TypeScript for dashboard UI (tabs, fetch, render, accordion, filter, refresh, editor)
 
+// Types mirror the JSON encoding of kono.Config. Durations are nanoseconds, nil slices are null.
+
+interface DashboardConfig {
+  enabled: boolean;
+  port: number;
+  timeout: number;
+}
+
 interface AdminConfig {
-  enable: boolean;
+  enabled: boolean;
   port: number;
+  timeout: number;
 }
 
 interface PluginConfig {
   name: string;
-  config: Record<string, any>;
+  path?: string;
+  config: Record<string, any> | null;
+}
+
+interface MiddlewareConfig extends PluginConfig {
+  can_fail_on_load: boolean;
+  override: boolean;
+}
+
+interface FeatureConfig {
+  enabled: boolean;
+  name: string;
+  config: Record<string, any> | null;
 }
 
 interface UpstreamConfig {
-  url: string;
+  name: string;
+  type: string;
+  hosts: string[] | null;
   method: string;
-  timeout?: number;
+  timeout: number;
+}
+
+interface AggregationConfig {
+  strategy: string;
+  allow_partial_results: boolean;
 }
 
 interface RouteConfig {
   path: string;
   method: string;
-  backends: UpstreamConfig[];
-  plugins: PluginConfig[];
-  aggregate: string;
-  transform: string;
+  type: string;
+  plugins: PluginConfig[] | null;
+  middlewares: MiddlewareConfig[] | null;
+  upstreams: UpstreamConfig[];
+  aggregation: AggregationConfig;
+  max_parallel_upstreams: number;
+}
+
+interface MetricsConfig {
+  enabled: boolean;
+  provider: string;
+  port: number;
+  path: string;
 }
 
 interface ServerConfig {
   port: number;
   timeout: number;
+  metrics: MetricsConfig;
+  trusted_proxies: string[] | null;
+  tls: { enabled: boolean };
 }
 
 interface GatewayConfig {
-  schema?: string;
-  name?: string;
-  version?: string;
+  config_version: string;
+  name: string;
+  version: string;
+  debug: boolean;
   server: ServerConfig;
-  admin_panel?: AdminConfig;
-  plugins: PluginConfig[];
+  dashboard: DashboardConfig;
+  admin: AdminConfig;
+  features: FeatureConfig[] | null;
+  middlewares: MiddlewareConfig[] | null;
   routes: RouteConfig[];
 }
 
+// Types of the stats endpoint, see kono.RouteStats.
+
+interface HostInfo {
+  url: string;
+  healthy: boolean;
+  drained: boolean;
+  successes: number;
+  failures: number;
+  last_status?: number;
+  last_error?: string;
+}
+
+interface UpstreamInfo {
+  name: string;
+  circuit_breaker?: { state: string; forced: boolean };
+  hosts: HostInfo[];
+}
+
+interface RouteStats {
+  path: string;
+  method: string;
+  requests: number;
+  errors: number;
+  request_rate: number;
+  error_rate: number;
+  latency_ms: { p50: number; p90: number; p95: number; p99: number };
+  upstreams: UpstreamInfo[];
+}
+
+interface StatsResponse {
+  time: string;
+  window_seconds: number;
+  routes: RouteStats[];
+}
+
+interface ConfigChange {
+  path: string;
+  op: "added" | "removed" | "changed";
+  old?: unknown;
+  new?: unknown;
+}
+
+interface ApplyResponse {
+  version?: number;
+  changes: ConfigChange[];
+}
+
+interface HistoryEntry {
+  version: number;
+  applied_at: string;
+  user?: string;
+  changes: number;
+  rollback_of?: number;
+}
+
 const CONFIG_URL = "config";
+const CONFIG_DIFF_URL = "config/diff";
+const CONFIG_APPLY_URL = "config/apply";
+const CONFIG_HISTORY_URL = "config/history";
+const CONFIG_ROLLBACK_URL = "config/rollback";
+const ME_URL = "me";
+const STATS_STREAM_URL = "stats/stream";
+const AUTH_STORAGE_KEY = "kono-dashboard-auth";
+const STATS_RECONNECT_DELAY = 3000;
 let ALL_ROUTES: RouteConfig[] = [];
 declare const CodeMirror: any;
 let FULL_CONFIG: GatewayConfig | null = null;
 let codeMirrorEditor: any | null = null;
+let statsStreaming = false;
+let CURRENT_USER: DashboardUser | null = null;
+
+interface DashboardUser {
+  name: string;
+  role: "viewer" | "admin";
+}
+
+// === Authentication ===
+
+class UnauthorizedError extends Error {}
+
+function authHeaders(): Record<string, string> {
+  const auth = sessionStorage.getItem(AUTH_STORAGE_KEY);
+  return auth ? { Authorization: auth } : {};
+}
+
+// apiFetch sends the stored credentials and asks for new ones if they are missing or rejected.
+async function apiFetch(
+  url: string,
+  init: RequestInit = {},
+  headers: Record<string, string> = {},
+): Promise<Response> {
+  const resp = await fetch(url, {
+    ...init,
+    headers: { ...authHeaders(), ...headers },
+  });
+  if (resp.status === 401) {
+    sessionStorage.removeItem(AUTH_STORAGE_KEY);
+    showLogin();
+    throw new UnauthorizedError("Authentication required");
+  }
+  return resp;
+}
+
+function showLogin() {
+  const overlay = document.getElementById("login");
+  if (overlay) overlay.classList.add("visible");
+}
+
+function setupLogin() {
+  const form = document.getElementById("login-form") as HTMLFormElement | null;
+  if (!form) return;
+
+  form.addEventListener("submit", (e) => {
+    e.preventDefault();
+    const data = new FormData(form);
+    const token = String(data.get("token") || "").trim();
+    const name = String(data.get("username") || "");
+    const password = String(data.get("password") || "");
+
+    const auth = token ? `Bearer ${token}` : `Basic ${btoa(`${name}:${password}`)}`;
+    sessionStorage.setItem(AUTH_STORAGE_KEY, auth);
+
+    form.reset();
+    document.getElementById("login")?.classList.remove("visible");
+    void init();
+  });
+}
+
+async function fetchMe(): Promise<DashboardUser> {
+  const resp = await apiFetch(ME_URL);
+  if (!resp.ok) throw new Error(`User load failed: ${resp.status}`);
+  return resp.json();
+}
+
+function renderUser(me: DashboardUser) {
+  CURRENT_USER = me;
+  const userEl = document.getElementById("current-user");
+  if (userEl) userEl.textContent = `${me.name} (${me.role})`;
+
+  // Viewers can only read.
+  document
+    .querySelectorAll<HTMLButtonElement>("[data-requires-admin]")
+    .forEach((btn) => {
+      btn.disabled = me.role !== "admin";
+      btn.title = me.role !== "admin" ? "Admin role required" : "";
+    });
+}
 
 async function fetchConfig(): Promise<GatewayConfig> {
-  const resp = await fetch(CONFIG_URL);
+  const resp = await apiFetch(CONFIG_URL);
   if (!resp.ok) throw new Error(`Config load failed: ${resp.status}`);
   return resp.json();
 }
@@ -72,21 +257,25 @@ function renderServerInfo(cfg: GatewayConfig) {
   }
 
   container.innerHTML = `
+      <p class="meta"><span class="label">Name</span><span class="value">${escapeHtml(cfg.name)}</span></p>
       <p class="meta"><span class="label">Listen Port</span><span class="value">${server.port}</span></p>
-      <p class="meta"><span class="label">Timeout</span><span class="value">${server.timeout} ms</span></p>
-      ${cfg.admin_panel?.enable ? `<p class="meta"><span class="label">Admin Port</span><span class="value">${cfg.admin_panel.port}</span></p>` : ""}
+      <p class="meta"><span class="label">Timeout</span><span class="value">${formatDuration(server.timeout)}</span></p>
+      <p class="meta"><span class="label">TLS</span><span class="value">${server.tls?.enabled ? "on" : "off"}</span></p>
+      ${server.metrics?.enabled ? `<p class="meta"><span class="label">Metrics</span><span class="value">${escapeHtml(server.metrics.provider || "prometheus")}</span></p>` : ""}
+      ${cfg.admin?.enabled ? `<p class="meta"><span class="label">Admin Port</span><span class="value">${cfg.admin.port}</span></p>` : ""}
+      ${(cfg.features ?? []).map((f) => `<p class="meta"><span class="label">Feature ${escapeHtml(f.name)}</span><span class="value">${f.enabled ? "on" : "off"}</span></p>`).join("")}
     `;
 }
 
-function renderPlugins(plugins?: PluginConfig[]) {
-  const container = document.getElementById("plugins-list");
+function renderMiddlewares(middlewares: MiddlewareConfig[] | null) {
+  const container = document.getElementById("middlewares-list");
   if (!container) return;
-  if (!plugins || plugins.length === 0) {
-    container.innerHTML = `<div class="glass-card">No global plugins configured.</div>`;
+  if (!middlewares || middlewares.length === 0) {
+    container.innerHTML = `<div class="glass-card">No global middlewares configured.</div>`;
     return;
   }
 
-  container.innerHTML = plugins
+  container.innerHTML = middlewares
     .map(
       (p) => `
     <div class="plugin-item glass-card">
@@ -108,21 +297,17 @@ function renderRoutes(routes: RouteConfig[] = []) {
 
   container.innerHTML = routes
     .map((r) => {
-      const methodClass = ["GET", "POST", "PUT", "DELETE", "PATCH"].includes(
-        r.method.toUpperCase(),
-      )
-        ? r.method.toUpperCase()
-        : "OTHER";
+      const methodClass = methodClassOf(r.method);
 
-      const numBackends = r.backends?.length || 0;
+      const numUpstreams = r.upstreams?.length || 0;
       const numPlugins = r.plugins?.length || 0;
 
-      const backendsHtml = r.backends
+      const upstreamsHtml = (r.upstreams ?? [])
         .map(
-          (b) => `
-            <div class="backend-method">${escapeHtml(b.method)}</div>
-            <div class="backend-url">${escapeHtml(b.url)}</div>
-            <div class="backend-timeout">${b.timeout ? `${b.timeout}ms` : "N/A"}</div>
+          (u) => `
+            <div class="backend-method">${escapeHtml(u.method || r.method)}</div>
+            <div class="backend-url">${escapeHtml(u.name)} ${escapeHtml((u.hosts ?? []).join(", "))}</div>
+            <div class="backend-timeout">${u.timeout ? formatDuration(u.timeout) : "N/A"}</div>
         `,
         )
         .join("");
@@ -148,18 +333,18 @@ function renderRoutes(routes: RouteConfig[] = []) {
           <div class="method ${methodClass}">${escapeHtml(r.method)}</div>
           <div class="path">${escapeHtml(r.path)}</div>
           <span class="meta-badge plugins-count" title="${numPlugins} plugins"><span class="label">🔌</span> ${numPlugins}</span>
-          <span class="meta-badge backends-count" title="${numBackends} backends"><span class="label">🔗</span> ${numBackends}</span>
+          <span class="meta-badge backends-count" title="${numUpstreams} upstreams"><span class="label">🔗</span> ${numUpstreams}</span>
         </div>
         <div class="right"><span class="toggle">▼</span></div>
       </div>
       <div class="route-details">
-        <p class="meta"><span class="label">Aggregate:</span> <span class="value">${escapeHtml(r.aggregate)}</span></p>
-        <p class="meta"><span class="label">Transform:</span> <span class="value">${escapeHtml(r.transform)}</span></p>
+        <p class="meta"><span class="label">Type:</span> <span class="value">${escapeHtml(r.type || "http")}</span></p>
+        <p class="meta"><span class="label">Aggregation:</span> <span class="value">${escapeHtml(r.aggregation?.strategy || "merge")}${r.aggregation?.allow_partial_results ? ", partial results" : ""}</span></p>
 
-        <div class="meta" style="margin-top: 15px;"><span class="label">Backends:</span></div>
+        <div class="meta" style="margin-top: 15px;"><span class="label">Upstreams:</span></div>
         <div class="backends-grid">
-            <div class="header">METHOD</div><div class="header">URL</div><div class="header">TIMEOUT</div>
-            ${backendsHtml}
+            <div class="header">METHOD</div><div class="header">NAME / HOSTS</div><div class="header">TIMEOUT</div>
+            ${upstreamsHtml}
         </div>
 
         <div class="meta" style="margin-top: 15px;"><span class="label">Plugins:</span></div>
@@ -229,10 +414,18 @@ function setupConfigEditor(cfg: GatewayConfig) {
       matchBrackets: true,
       autoCloseBrackets: true,
     });
+
+    applyBtn.addEventListener("click", () => void reviewConfig());
+    revertBtn.addEventListener("click", () => {
+      if (FULL_CONFIG) codeMirrorEditor.setValue(JSON.stringify(FULL_CONFIG, null, 2));
+      hideConfigDiff();
+      setConfigStatus("Reverted to the running config.", false);
+    });
   }
 
   codeMirrorEditor.setValue(JSON.stringify(cfg, null, 2));
   statusEl.textContent = "";
+  hideConfigDiff();
 
   setTimeout(() => {
     if (codeMirrorEditor) {
@@ -242,6 +435,297 @@ function setupConfigEditor(cfg: GatewayConfig) {
   }, 50);
 }
 
+function setConfigStatus(message: string, failed: boolean) {
+  const statusEl = document.getElementById("config-status");
+  if (!statusEl) return;
+  statusEl.textContent = message;
+  statusEl.style.color = failed ? "var(--error)" : "var(--ok)";
+}
+
+// errorMessage returns the message of an error response of the dashboard API.
+async function errorMessage(resp: Response): Promise<string> {
+  try {
+    const body = await resp.json();
+    if (body && typeof body.message === "string") return body.message;
+  } catch {
+    // not a JSON error
+  }
+  return `request failed: ${resp.status}`;
+}
+
+async function postJSON(url: string, body: string): Promise<Response> {
+  return apiFetch(url, { method: "POST", body }, { "Content-Type": "application/json" });
+}
+
+// reviewConfig validates the edited config and shows its changes against the running config before applying it.
+async function reviewConfig() {
+  if (!codeMirrorEditor) return;
+  const body = codeMirrorEditor.getValue();
+
+  try {
+    JSON.parse(body);
+  } catch (err) {
+    setConfigStatus(`Invalid JSON: ${(err as Error).message}`, true);
+    return;
+  }
+
+  try {
+    const resp = await postJSON(CONFIG_DIFF_URL, body);
+    if (!resp.ok) {
+      hideConfigDiff();
+      setConfigStatus(await errorMessage(resp), true);
+      return;
+    }
+
+    const diff = (await resp.json()) as ApplyResponse;
+    if (diff.changes.length === 0) {
+      hideConfigDiff();
+      setConfigStatus("No changes to apply.", false);
+      return;
+    }
+
+    setConfigStatus("", false);
+    renderConfigDiff(diff.changes, () => void applyConfig(body));
+  } catch (err) {
+    if (err instanceof UnauthorizedError) return;
+    setConfigStatus((err as Error).message, true);
+  }
+}
+
+async function applyConfig(body: string) {
+  try {
+    const resp = await postJSON(CONFIG_APPLY_URL, body);
+    if (!resp.ok) {
+      setConfigStatus(await errorMessage(resp), true);
+      return;
+    }
+
+    const applied = (await resp.json()) as ApplyResponse;
+    await init();
+    setConfigStatus(`Applied version ${applied.version} with ${applied.changes.length} change(s).`, false);
+  } catch (err) {
+    if (err instanceof UnauthorizedError) return;
+    setConfigStatus((err as Error).message, true);
+  }
+}
+
+function renderConfigDiff(changes: ConfigChange[], onConfirm: () => void) {
+  const container = document.getElementById("config-diff");
+  if (!container) return;
+
+  const rows = changes
+    .map((c) => {
+      const oldVal = c.op === "added" ? "" : formatValue(c.old);
+      const newVal = c.op === "removed" ? "" : formatValue(c.new);
+      return `
+        <div class="diff-op diff-${c.op}">${escapeHtml(c.op)}</div>
+        <div class="diff-path">${escapeHtml(c.path)}</div>
+        <div class="diff-old">${escapeHtml(oldVal)}</div>
+        <div class="diff-new">${escapeHtml(newVal)}</div>
+      `;
+    })
+    .join("");
+
+  container.innerHTML = `
+    <div class="diff-grid">
+      <div class="header">OP</div><div class="header">PATH</div><div class="header">RUNNING</div><div class="header">EDITED</div>
+      ${rows}
+    </div>
+    <div class="editor-actions">
+      <button id="cancel-config" class="secondary-btn" type="button">Cancel</button>
+      <button id="confirm-config" class="refresh-btn hot-reload-btn" type="button">Apply ${changes.length} change(s)</button>
+    </div>`;
+  container.hidden = false;
+
+  document.getElementById("cancel-config")?.addEventListener("click", hideConfigDiff);
+  document.getElementById("confirm-config")?.addEventListener("click", () => {
+    hideConfigDiff();
+    onConfirm();
+  });
+}
+
+function hideConfigDiff() {
+  const container = document.getElementById("config-diff");
+  if (!container) return;
+  container.hidden = true;
+  container.innerHTML = "";
+}
+
+async function fetchHistory(): Promise<HistoryEntry[]> {
+  const resp = await apiFetch(CONFIG_HISTORY_URL);
+  if (!resp.ok) throw new Error(`History load failed: ${resp.status}`);
+  return resp.json();
+}
+
+function renderHistory(history: HistoryEntry[]) {
+  const container = document.getElementById("config-history");
+  if (!container) return;
+
+  const isAdmin = CURRENT_USER?.role === "admin";
+
+  const rows = history
+    .map((h, i) => {
+      const note = h.version === 1 ? "startup" : h.rollback_of ? `rollback to v${h.rollback_of}` : `${h.changes} change(s)`;
+      // The newest version is the running one.
+      const action =
+        i === 0
+          ? `<span class="status-badge status-ok">running</span>`
+          : `<button class="secondary-btn rollback-btn" type="button" data-version="${h.version}" ${isAdmin ? "" : "disabled title=\"Admin role required\""}>⟲ Roll back</button>`;
+
+      return `
+        <div class="history-version">v${h.version}</div>
+        <div>${escapeHtml(new Date(h.applied_at).toLocaleString())}</div>
+        <div>${escapeHtml(h.user || "-")}</div>
+        <div>${escapeHtml(note)}</div>
+        <div>${action}</div>
+      `;
+    })
+    .join("");
+
+  container.innerHTML = `
+    <div class="history-grid glass-card">
+      <div class="header">VERSION</div><div class="header">APPLIED</div><div class="header">USER</div>
+      <div class="header">CHANGES</div><div class="header"></div>
+      ${rows}
+    </div>`;
+
+  container.querySelectorAll<HTMLButtonElement>(".rollback-btn").forEach((btn) => {
+    btn.addEventListener("click", () => void rollbackConfig(Number(btn.dataset.version)));
+  });
+}
+
+async function rollbackConfig(version: number) {
+  if (!confirm(`Roll back to config version ${version}?`)) return;
+
+  try {
+    const resp = await postJSON(CONFIG_ROLLBACK_URL, JSON.stringify({ version }));
+    if (!resp.ok) {
+      setConfigStatus(await errorMessage(resp), true);
+      return;
+    }
+
+    const applied = (await resp.json()) as ApplyResponse;
+    await init();
+    setConfigStatus(`Rolled back to version ${version} as version ${applied.version}.`, false);
+  } catch (err) {
+    if (err instanceof UnauthorizedError) return;
+    setConfigStatus((err as Error).message, true);
+  }
+}
+
+// === Live Traffic ===
+
+function setTrafficStatus(live: boolean) {
+  const statusEl = document.getElementById("traffic-status");
+  if (!statusEl) return;
+  statusEl.textContent = live ? "● Live" : "● Reconnecting";
+  statusEl.className = `status-badge ${live ? "status-ok" : "status-error"}`;
+}
+
+// connectStats reads the stats event stream and reconnects until the credentials are rejected.
+// It uses fetch instead of EventSource, which cannot send the Authorization header.
+async function connectStats() {
+  if (statsStreaming) return;
+  statsStreaming = true;
+
+  for (;;) {
+    try {
+      const resp = await apiFetch(STATS_STREAM_URL);
+      if (!resp.ok || !resp.body) throw new Error(`Stats stream failed: ${resp.status}`);
+
+      setTrafficStatus(true);
+
+      const reader = resp.body.getReader();
+      const decoder = new TextDecoder();
+      let buf = "";
+
+      for (;;) {
+        const { done, value } = await reader.read();
+        if (done) break;
+
+        buf += decoder.decode(value, { stream: true });
+
+        let idx: number;
+        while ((idx = buf.indexOf("\n\n")) >= 0) {
+          handleStatsEvent(buf.slice(0, idx));
+          buf = buf.slice(idx + 2);
+        }
+      }
+    } catch (err) {
+      if (err instanceof UnauthorizedError) {
+        statsStreaming = false;
+        return;
+      }
+      console.error("Stats stream failed:", err);
+    }
+
+    setTrafficStatus(false);
+    await new Promise((resolve) => setTimeout(resolve, STATS_RECONNECT_DELAY));
+  }
+}
+
+function handleStatsEvent(event: string) {
+  const data = event
+    .split("\n")
+    .filter((line) => line.startsWith("data: "))
+    .map((line) => line.slice("data: ".length))
+    .join("\n");
+  if (data) renderTraffic(JSON.parse(data) as StatsResponse);
+}
+
+function renderTraffic(stats: StatsResponse) {
+  const container = document.getElementById("traffic-list");
+  const windowEl = document.getElementById("traffic-window");
+  if (!container) return;
+
+  if (windowEl) windowEl.textContent = `last ${stats.window_seconds}s`;
+
+  const routes = stats.routes ?? [];
+  if (routes.length === 0) {
+    container.innerHTML = `<div class="glass-card">No routes configured.</div>`;
+    return;
+  }
+
+  const rows = routes
+    .map((r) => {
+      const errorClass = r.error_rate > 0.05 ? "traffic-error" : "";
+      const lat = r.latency_ms;
+
+      return `
+        <div class="method ${methodClassOf(r.method)}">${escapeHtml(r.method)}</div>
+        <div class="traffic-path">${escapeHtml(r.path)}</div>
+        <div class="traffic-num">${r.request_rate.toFixed(2)}</div>
+        <div class="traffic-num ${errorClass}">${(r.error_rate * 100).toFixed(1)}%</div>
+        <div class="traffic-num">${lat.p50.toFixed(1)} / ${lat.p95.toFixed(1)} / ${lat.p99.toFixed(1)}</div>
+        <div class="traffic-upstreams">${(r.upstreams ?? []).map(renderUpstreamHealth).join("")}</div>
+      `;
+    })
+    .join("");
+
+  container.innerHTML = `
+    <div class="traffic-grid glass-card">
+      <div class="header">METHOD</div><div class="header">PATH</div><div class="header">REQ/S</div>
+      <div class="header">ERRORS</div><div class="header">P50 / P95 / P99 MS</div><div class="header">UPSTREAMS</div>
+      ${rows}
+    </div>`;
+}
+
+function renderUpstreamHealth(u: UpstreamInfo): string {
+  const breaker = u.circuit_breaker
+    ? `<span class="breaker breaker-${escapeAttr(u.circuit_breaker.state)}" title="circuit breaker${u.circuit_breaker.forced ? " (forced)" : ""}">${escapeHtml(u.circuit_breaker.state)}</span>`
+    : "";
+
+  const hosts = (u.hosts ?? [])
+    .map((h) => {
+      const state = h.drained ? "drained" : h.healthy ? "healthy" : "unhealthy";
+      const title = `${h.url}: ${h.successes} ok, ${h.failures} failed${h.last_error ? `, ${h.last_error}` : ""}`;
+      return `<span class="host-dot host-${state}" title="${escapeAttr(title)}"></span>`;
+    })
+    .join("");
+
+  return `<span class="upstream-health"><span class="plugin-name-badge">${escapeHtml(u.name)}</span>${hosts}${breaker}</span>`;
+}
+
 /* tabs, filter, method filter helpers */
 function setupTabs() {
   const buttons =
@@ -333,7 +817,21 @@ function escapeHtml(s: unknown): string {
 function escapeAttr(s: unknown): string {
   return escapeHtml(s).replace(/\s+/g, " ");
 }
-function shortConfig(cfg: Record<string, any>): string {
+function methodClassOf(method: string): string {
+  const m = method.toUpperCase();
+  return ["GET", "POST", "PUT", "DELETE", "PATCH"].includes(m) ? m : "OTHER";
+}
+function formatDuration(ns: number): string {
+  if (!ns) return "0s";
+  const ms = ns / 1e6;
+  return ms < 1000 ? `${ms}ms` : `${ms / 1000}s`;
+}
+function formatValue(v: unknown): string {
+  if (v === undefined || v === null) return "null";
+  return typeof v === "string" ? v : JSON.stringify(v);
+}
+
+function shortConfig(cfg: Record<string, any> | null): string {
   try {
     const entries = Object.entries(cfg || {});
     if (entries.length === 0) return "{}";
@@ -350,6 +848,7 @@ function shortConfig(cfg: Record<string, any>): string {
 /* init */
 document.addEventListener("DOMContentLoaded", async () => {
   setupTabs();
+  setupLogin();
   const refreshBtn = document.getElementById("refresh");
   if (refreshBtn) refreshBtn.addEventListener("click", () => void init());
   await init();
@@ -357,18 +856,23 @@ document.addEventListener("DOMContentLoaded", async () => {
 
 async function init() {
   try {
+    renderUser(await fetchMe());
     const cfg = await fetchConfig();
     FULL_CONFIG = cfg;
     setVersionInHeader(cfg.version);
     renderServerInfo(cfg);
-    renderPlugins(cfg.plugins);
+    renderMiddlewares(cfg.middlewares);
     setupConfigEditor(cfg);
+    renderHistory(await fetchHistory());
 
     // Setup Route data and Filters
     ALL_ROUTES = cfg.routes || [];
     renderRoutes(ALL_ROUTES);
     setupFilters();
+
+    connectStats();
   } catch (err) {
+    if (err instanceof UnauthorizedError) return;
     console.error("Admin init failed:", err);
     const main = document.querySelector("main");
     if (main)
@@ -378,10 +882,5 @@ async function init() {
       statusEl.textContent = "● Stopped";
       statusEl.className = "status-badge status-error";
     }
-    setupConfigEditor({
-      server: { port: 0, timeout: 0 },
-      plugins: [],
-      routes: [],
-    });
   }
 }
@@ -9,6 +9,8 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"golang.org/x/sync/semaphore"
 
@@ -35,6 +37,11 @@ type defaultDispatcher struct {
 func (d *defaultDispatcher) dispatch(route *Route, original *http.Request) []UpstreamResponse {
 	results := make([]UpstreamResponse, len(route.Upstreams))
 
+	dispatchCtx, span := startSpan(original.Context(), "dispatch",
+		trace.WithAttributes(attribute.Int("kono.upstreams", len(route.Upstreams))),
+	)
+	defer span.End()
+
 	originalBody, readErr := io.ReadAll(io.LimitReader(original.Body, maxBodySize+1))
 	if readErr != nil {
 		d.log.Error("cannot read body", zap.Error(readErr))
@@ -49,6 +56,13 @@ func (d *defaultDispatcher) dispatch(route *Route, original *http.Request) []Ups
 		return nil
 	}
 
+	d.metrics.ObserveRequestSize(route.Path, len(originalBody))
+
+	accessEntry := accessEntryFromContext(original.Context())
+	accessEntry.setBytesIn(len(originalBody))
+
+	mirrored := route.Mirror.start(original, originalBody)
+
 	var (
 		wg  = sync.WaitGroup{}
 		sem = semaphore.NewWeighted(route.MaxParallelUpstreams)
@@ -62,7 +76,7 @@ func (d *defaultDispatcher) dispatch(route *Route, original *http.Request) []Ups
 
 			start := time.Now()
 
-			ctx := original.Context()
+			ctx := dispatchCtx
 
 			if err := sem.Acquire(ctx, 1); err != nil {
 				d.log.Error("cannot acquire semaphore", zap.Error(err))
@@ -117,7 +131,15 @@ func (d *defaultDispatcher) dispatch(route *Route, original *http.Request) []Ups
 				}
 			}
 
-			d.metrics.UpdateUpstreamLatency(route.Path, route.Method, u.Name(), time.Since(start))
+			latency := time.Since(start)
+
+			d.metrics.UpdateUpstreamLatency(route.Path, route.Method, u.Name(), resp.Variant, latency)
+			accessEntry.addUpstream(u.Name(), resp, latency)
+			d.metrics.IncUpstreamResponsesTotal(route.Path, u.Name(), resp.Status, errorKindLabel(resp.Err))
+
+			if resp.Attempts > 1 {
+				d.metrics.AddUpstreamRetries(route.Path, u.Name(), resp.Attempts-1)
+			}
 
 			results[i] = *resp
 		}(i, u, originalBody)
@@ -125,5 +147,19 @@ func (d *defaultDispatcher) dispatch(route *Route, original *http.Request) []Ups
 
 	wg.Wait()
 
+	mirrored(results)
+
 	return results
 }
+
+// errorKindLabel returns the error kind for metrics. Policy violations have no kind of their own.
+func errorKindLabel(err *UpstreamError) string {
+	switch {
+	case err == nil:
+		return ""
+	case err.Kind == "":
+		return string(metric.FailReasonPolicyViolation)
+	default:
+		return string(err.Kind)
+	}
+}
@@ -381,3 +381,48 @@ func TestDispatcher_Dispatch_RetryPolicy(t *testing.T) {
 		t.Errorf("retries count %d exceeds max retries %d", retriesCount, route.Upstreams[0].Policy().RetryPolicy.MaxRetries)
 	}
 }
+
+func TestDispatcher_Dispatch_RetryPolicyStopsOnSuccess(t *testing.T) {
+	attemptsCount := 0
+
+	upstreamA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attemptsCount++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstreamA.Close()
+
+	d := &defaultDispatcher{
+		log:     zap.NewNop(),
+		metrics: metric.NewNop(),
+	}
+
+	route := &Route{
+		Upstreams: []Upstream{
+			&httpUpstream{
+				hosts:   []string{upstreamA.URL},
+				method:  http.MethodGet,
+				timeout: 500 * time.Millisecond,
+				log:     zap.NewNop(),
+				client:  http.DefaultClient,
+				policy: Policy{
+					RetryPolicy: RetryPolicy{
+						MaxRetries:      3,
+						RetryOnStatuses: []int{http.StatusInternalServerError},
+					},
+				},
+			},
+		},
+		MaxParallelUpstreams: maxParallelUpstreams,
+	}
+
+	originalRequest := httptest.NewRequest(http.MethodGet, "http://example.com/test", nil)
+
+	results := d.dispatch(route, originalRequest)
+	if len(results) != 1 || results[0].Status != http.StatusOK {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	if attemptsCount != 1 {
+		t.Errorf("expected a single attempt on success, got %d", attemptsCount)
+	}
+}
@@ -1,11 +1,26 @@
 package kono
 
+const (
+	// RouteTypeHTTP routes dispatch requests to all upstreams and aggregate the responses.
+	RouteTypeHTTP = "http"
+	// RouteTypeWebSocket routes proxy upgraded connections to a single upstream.
+	RouteTypeWebSocket = "websocket"
+	// RouteTypeStream routes pass the response of a single upstream through without buffering.
+	RouteTypeStream = "stream"
+)
+
 type Route struct {
 	Path                 string
 	Method               string
+	Type                 string
 	Upstreams            []Upstream
 	Aggregation          AggregationConfig
 	MaxParallelUpstreams int64
 	Plugins              []Plugin
 	Middlewares          []Middleware
+	RateLimits           []*RateLimitRule
+	Concurrency          *ConcurrencyLimit
+	ClientCert           ClientCertConfig
+	WebSocket            *WebSocketProxy
+	Mirror               *Mirror
 }
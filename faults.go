@@ -0,0 +1,151 @@
+package kono
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errFaultConnection = errors.New("fault injection: connection refused")
+	errFaultAbort      = errors.New("fault injection: aborted")
+)
+
+// faultInjector makes a share of upstream attempts fail on purpose to test how clients and aggregation
+// settings deal with misbehaving upstreams. Faults are injected per attempt, so retries, the circuit
+// breaker and policies observe them like real failures.
+type faultInjector struct {
+	header string // Only requests carrying the header are affected, if set.
+
+	delay         float64
+	delayDuration time.Duration
+
+	abort       float64
+	abortStatus int
+
+	connectionError float64
+	truncatedBody   float64
+}
+
+// newFaultInjector returns nil if no fault is enabled.
+func newFaultInjector(cfg FaultInjectionConfig) *faultInjector {
+	if cfg.Delay.Percentage == 0 && cfg.Abort.Percentage == 0 &&
+		cfg.ConnectionError.Percentage == 0 && cfg.TruncatedBody.Percentage == 0 {
+		return nil
+	}
+
+	abortStatus := cfg.Abort.Status
+	if abortStatus == 0 {
+		abortStatus = http.StatusServiceUnavailable
+	}
+
+	return &faultInjector{
+		header:          cfg.Header,
+		delay:           cfg.Delay.Percentage,
+		delayDuration:   cfg.Delay.Duration,
+		abort:           cfg.Abort.Percentage,
+		abortStatus:     abortStatus,
+		connectionError: cfg.ConnectionError.Percentage,
+		truncatedBody:   cfg.TruncatedBody.Percentage,
+	}
+}
+
+func (f *faultInjector) applies(original *http.Request) bool {
+	return f.header == "" || original.Header.Get(f.header) != ""
+}
+
+// attempt runs the upstream attempt with faults injected. timeout is the upstream timeout the injected
+// delay counts against.
+func (f *faultInjector) attempt(
+	ctx context.Context,
+	log *zap.Logger,
+	timeout time.Duration,
+	attempt func(ctx context.Context, log *zap.Logger) *UpstreamResponse,
+) *UpstreamResponse {
+	if hit(f.connectionError) {
+		log.Debug("injecting connection error")
+
+		return &UpstreamResponse{
+			Headers: make(http.Header),
+			Err: &UpstreamError{
+				Kind: UpstreamConnection,
+				Err:  errFaultConnection,
+			},
+		}
+	}
+
+	if hit(f.abort) {
+		log.Debug("injecting abort", zap.Int("status", f.abortStatus))
+
+		resp := &UpstreamResponse{
+			Status:  f.abortStatus,
+			Headers: make(http.Header),
+		}
+
+		if f.abortStatus >= http.StatusInternalServerError {
+			resp.Err = &UpstreamError{
+				Kind: UpstreamBadStatus,
+				Err:  errFaultAbort,
+			}
+		}
+
+		return resp
+	}
+
+	if hit(f.delay) {
+		log.Debug("injecting delay", zap.Duration("delay", f.delayDuration))
+
+		if uerr := f.sleep(ctx, timeout); uerr != nil {
+			return &UpstreamResponse{
+				Headers: make(http.Header),
+				Err:     uerr,
+			}
+		}
+	}
+
+	resp := attempt(ctx, log)
+
+	if resp.Err == nil && len(resp.Body) > 0 && hit(f.truncatedBody) {
+		log.Debug("injecting truncated body", zap.Int("size", len(resp.Body)))
+
+		resp.Body = resp.Body[:len(resp.Body)/2]
+	}
+
+	return resp
+}
+
+// sleep waits for the injected delay. A delay reaching the upstream timeout fails like a slow upstream.
+func (f *faultInjector) sleep(ctx context.Context, timeout time.Duration) *UpstreamError {
+	delay, timedOut := f.delayDuration, false
+	if timeout > 0 && timeout <= delay {
+		delay, timedOut = timeout, true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return &UpstreamError{Kind: UpstreamCanceled, Err: ctx.Err()}
+	case <-timer.C:
+	}
+
+	if timedOut {
+		return &UpstreamError{
+			Kind: UpstreamTimeout,
+			Err:  fmt.Errorf("fault injection: %w", context.DeadlineExceeded),
+		}
+	}
+
+	return nil
+}
+
+// hit reports whether a fault with the percentage happens on this attempt.
+func hit(percentage float64) bool {
+	return percentage > 0 && rand.Float64()*100 < percentage //nolint:gosec // not security sensitive
+}
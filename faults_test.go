@@ -0,0 +1,143 @@
+package kono
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newFaultTestUpstream(t *testing.T, faults FaultInjectionConfig, policy PolicyConfig) (Upstream, *atomic.Int64) {
+	t.Helper()
+
+	var hits atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write([]byte(`{"id":1234}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	upstream := initUpstreams([]UpstreamConfig{
+		{
+			Hosts:   []string{srv.URL},
+			Method:  http.MethodGet,
+			Timeout: time.Second,
+			Policy:  policy,
+			Faults:  faults,
+		},
+	}, newTransportRegistry(), zap.NewNop())[0]
+
+	return upstream, &hits
+}
+
+func TestFaults_ConnectionErrorTripsCircuitBreaker(t *testing.T) {
+	upstream, hits := newFaultTestUpstream(t,
+		FaultInjectionConfig{ConnectionError: FaultConfig{Percentage: 100}},
+		PolicyConfig{CircuitBreakerConfig: CircuitBreakerConfig{Enabled: true, MaxFailures: 1, ResetTimeout: time.Minute}},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if resp := upstream.Call(req.Context(), req, nil); resp.Err == nil || resp.Err.Kind != UpstreamConnection {
+		t.Fatalf("expected an injected connection error, got %+v", resp.Err)
+	}
+
+	if resp := upstream.Call(req.Context(), req, nil); resp.Err == nil || resp.Err.Kind != UpstreamCircuitOpen {
+		t.Fatalf("expected the circuit breaker to open, got %+v", resp.Err)
+	}
+
+	if hits.Load() != 0 {
+		t.Fatalf("expected the upstream not to be called, got %d calls", hits.Load())
+	}
+}
+
+func TestFaults_Abort(t *testing.T) {
+	upstream, hits := newFaultTestUpstream(t,
+		FaultInjectionConfig{Abort: AbortFaultConfig{Percentage: 100, Status: http.StatusServiceUnavailable}},
+		PolicyConfig{RetryConfig: RetryConfig{MaxRetries: 2}},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err == nil || resp.Err.Kind != UpstreamBadStatus || resp.Status != http.StatusServiceUnavailable {
+		t.Fatalf("expected an injected 503, got %d %+v", resp.Status, resp.Err)
+	}
+
+	if hits.Load() != 0 {
+		t.Fatalf("expected the upstream not to be called, got %d calls", hits.Load())
+	}
+
+	upstream, _ = newFaultTestUpstream(t,
+		FaultInjectionConfig{Abort: AbortFaultConfig{Percentage: 100, Status: http.StatusTeapot}},
+		PolicyConfig{},
+	)
+
+	if resp = upstream.Call(req.Context(), req, nil); resp.Err != nil || resp.Status != http.StatusTeapot {
+		t.Fatalf("expected an injected 418 without error, got %d %+v", resp.Status, resp.Err)
+	}
+}
+
+func TestFaults_HeaderGate(t *testing.T) {
+	upstream, hits := newFaultTestUpstream(t,
+		FaultInjectionConfig{Header: "X-Kono-Fault", ConnectionError: FaultConfig{Percentage: 100}},
+		PolicyConfig{},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if resp := upstream.Call(req.Context(), req, nil); resp.Err != nil || hits.Load() != 1 {
+		t.Fatalf("expected requests without the header to reach the upstream, got %+v", resp.Err)
+	}
+
+	req.Header.Set("X-Kono-Fault", "1")
+
+	if resp := upstream.Call(req.Context(), req, nil); resp.Err == nil || resp.Err.Kind != UpstreamConnection {
+		t.Fatalf("expected an injected connection error, got %+v", resp.Err)
+	}
+}
+
+func TestFaults_Delay(t *testing.T) {
+	upstream, _ := newFaultTestUpstream(t,
+		FaultInjectionConfig{Delay: DelayFaultConfig{Percentage: 100, Duration: 50 * time.Millisecond}},
+		PolicyConfig{},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	start := time.Now()
+	if resp := upstream.Call(req.Context(), req, nil); resp.Err != nil {
+		t.Fatalf("unexpected error: %v", resp.Err.Unwrap())
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected the call to be delayed, took %s", elapsed)
+	}
+
+	upstream, hits := newFaultTestUpstream(t,
+		FaultInjectionConfig{Delay: DelayFaultConfig{Percentage: 100, Duration: time.Minute}},
+		PolicyConfig{},
+	)
+
+	if resp := upstream.Call(req.Context(), req, nil); resp.Err == nil || resp.Err.Kind != UpstreamTimeout || hits.Load() != 0 {
+		t.Fatalf("expected a delay over the timeout to time out, got %+v", resp.Err)
+	}
+}
+
+func TestFaults_TruncatedBody(t *testing.T) {
+	upstream, _ := newFaultTestUpstream(t,
+		FaultInjectionConfig{TruncatedBody: FaultConfig{Percentage: 100}},
+		PolicyConfig{},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err != nil || string(resp.Body) != `{"id"` {
+		t.Fatalf("expected a truncated body, got %q %+v", resp.Body, resp.Err)
+	}
+}
@@ -0,0 +1,176 @@
+package kono
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// graphqlUpstream sends the configured GraphQL query with variables rendered from the request and
+// unwraps "data" of the response, so GraphQL backends can be aggregated with REST ones.
+// GraphQL errors are returned to the client as is. A response with both data and errors is partial
+// and follows the route's allow_partial_results setting.
+type graphqlUpstream struct {
+	http          *httpUpstream
+	query         string
+	operationName string
+	variables     map[string]any
+}
+
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphqlError  `json:"errors"`
+}
+
+type graphqlError struct {
+	Message    string         `json:"message"`
+	Extensions map[string]any `json:"extensions"`
+}
+
+func newGraphQLUpstream(upstream *httpUpstream, cfg GraphQLConfig) (*graphqlUpstream, error) {
+	query := cfg.Query
+
+	if cfg.QueryFile != "" {
+		data, err := os.ReadFile(cfg.QueryFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read graphql query: %w", err)
+		}
+
+		query = string(data)
+	}
+
+	// Queries and mutations are always sent as JSON documents.
+	upstream.method = http.MethodPost
+
+	return &graphqlUpstream{
+		http:          upstream,
+		query:         query,
+		operationName: cfg.OperationName,
+		variables:     cfg.Variables,
+	}, nil
+}
+
+func (g *graphqlUpstream) Name() string   { return g.http.Name() }
+func (g *graphqlUpstream) Policy() Policy { return g.http.Policy() }
+
+func (g *graphqlUpstream) Call(ctx context.Context, original *http.Request, originalBody []byte) *UpstreamResponse {
+	payload, err := g.requestBody(original, originalBody)
+	if err != nil {
+		return &UpstreamResponse{
+			Headers: make(http.Header),
+			Err: &UpstreamError{
+				Kind: UpstreamInternal,
+				Err:  err,
+				Details: []JSONError{
+					{Code: ErrorCodeBadRequest, Message: err.Error()},
+				},
+			},
+		}
+	}
+
+	req := original.Clone(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	// GraphQL errors are application errors, they are neither retried nor counted by the circuit breaker.
+	resp := g.http.callWithPolicy(ctx, original, func(ctx context.Context, log *zap.Logger) *UpstreamResponse {
+		return g.http.call(ctx, req, payload, log)
+	})
+	if resp.Err != nil {
+		return resp
+	}
+
+	g.unwrap(resp)
+
+	return resp
+}
+
+// requestBody renders the variables and builds the GraphQL request document.
+func (g *graphqlUpstream) requestBody(original *http.Request, originalBody []byte) ([]byte, error) {
+	data := newRequestData(original, originalBody)
+
+	variables := make(map[string]any, len(g.variables))
+
+	for name, value := range g.variables {
+		rendered, err := data.render(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid graphql variable %s: %w", name, err)
+		}
+
+		variables[name] = rendered
+	}
+
+	return json.Marshal(graphqlRequest{
+		Query:         g.query,
+		OperationName: g.operationName,
+		Variables:     variables,
+	})
+}
+
+// unwrap replaces the response body with the "data" field and maps GraphQL errors.
+func (g *graphqlUpstream) unwrap(resp *UpstreamResponse) {
+	resp.Headers.Del("Content-Length")
+
+	var gresp graphqlResponse
+	if err := json.Unmarshal(resp.Body, &gresp); err != nil {
+		resp.Body = nil
+		resp.Err = &UpstreamError{
+			Kind: UpstreamBadStatus,
+			Err:  fmt.Errorf("malformed graphql response: %w", err),
+			Details: []JSONError{
+				{Code: ErrorCodeUpstreamMalformed, Message: "upstream malformed"},
+			},
+		}
+
+		return
+	}
+
+	resp.Body = nil
+	if len(gresp.Data) > 0 && !bytes.Equal(gresp.Data, []byte("null")) {
+		resp.Body = gresp.Data
+	}
+
+	if len(gresp.Errors) == 0 {
+		return
+	}
+
+	kind := UpstreamBadStatus
+	if resp.Body != nil {
+		kind = UpstreamPartial
+	}
+
+	details := make([]JSONError, 0, len(gresp.Errors))
+	for _, gerr := range gresp.Errors {
+		details = append(details, gerr.jsonError())
+	}
+
+	resp.Err = &UpstreamError{
+		Kind:    kind,
+		Err:     errors.New("graphql errors: " + gresp.Errors[0].Message),
+		Details: details,
+	}
+}
+
+// jsonError uses extensions.code as the error code when the server sets it.
+func (e graphqlError) jsonError() JSONError {
+	code, ok := e.Extensions["code"].(string)
+	if !ok || code == "" {
+		code = ErrorCodeUpstreamError
+	}
+
+	return JSONError{
+		Code:    code,
+		Message: e.Message,
+	}
+}
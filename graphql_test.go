@@ -0,0 +1,192 @@
+package kono
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+// newGraphQLServer answers every request with the given response and records the request document.
+func newGraphQLServer(t *testing.T, response string, received *graphqlRequest) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		if received != nil {
+			if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newTestGraphQLUpstream(t *testing.T, host string, variables map[string]any) Upstream {
+	t.Helper()
+
+	return initUpstreams([]UpstreamConfig{
+		{
+			Type:    UpstreamTypeGraphQL,
+			Hosts:   []string{host},
+			Timeout: time.Second,
+			GraphQL: GraphQLConfig{
+				Query:         "query User($id: ID!, $limit: Int) { user(id: $id) { name orders(limit: $limit) { id } } }",
+				OperationName: "User",
+				Variables:     variables,
+			},
+		},
+	}, newTransportRegistry(), zap.NewNop())[0]
+}
+
+func TestGraphQLUpstream_RendersVariablesAndUnwrapsData(t *testing.T) {
+	var received graphqlRequest
+
+	srv := newGraphQLServer(t, `{"data":{"user":{"name":"ann","orders":[]}}}`, &received)
+	upstream := newTestGraphQLUpstream(t, srv.URL, map[string]any{
+		"id":     "{{ path.id }}",
+		"limit":  "{{ query.limit | int }}",
+		"filter": map[string]any{"status": "{{ body.filter.status }}", "note": "from {{ header.X-Client }}"},
+		"fixed":  true,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42?limit=5", nil)
+	req.SetPathValue("id", "42")
+	req.Header.Set("X-Client", "web")
+
+	resp := upstream.Call(req.Context(), req, []byte(`{"filter":{"status":"open"}}`))
+	if resp.Err != nil {
+		t.Fatalf("unexpected error: %v", resp.Err.Unwrap())
+	}
+
+	if string(resp.Body) != `{"user":{"name":"ann","orders":[]}}` {
+		t.Fatalf("expected unwrapped data, got %s", resp.Body)
+	}
+
+	if received.OperationName != "User" || !strings.HasPrefix(received.Query, "query User") {
+		t.Fatalf("unexpected request document %+v", received)
+	}
+
+	got, _ := json.Marshal(received.Variables)
+	want := `{"filter":{"note":"from web","status":"open"},"fixed":true,"id":"42","limit":5}`
+
+	if string(got) != want {
+		t.Fatalf("expected variables %s, got %s", want, got)
+	}
+}
+
+func TestGraphQLUpstream_Errors(t *testing.T) {
+	srv := newGraphQLServer(t, `{"data":null,"errors":[{"message":"user not found","extensions":{"code":"NOT_FOUND"}},{"message":"boom"}]}`, nil)
+	upstream := newTestGraphQLUpstream(t, srv.URL, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err == nil || resp.Err.Kind != UpstreamBadStatus || resp.Body != nil {
+		t.Fatalf("expected bad_status error without data, got %+v", resp)
+	}
+
+	want := []JSONError{
+		{Code: "NOT_FOUND", Message: "user not found"},
+		{Code: ErrorCodeUpstreamError, Message: "boom"},
+	}
+
+	if len(resp.Err.Details) != len(want) || resp.Err.Details[0] != want[0] || resp.Err.Details[1] != want[1] {
+		t.Fatalf("expected %v, got %v", want, resp.Err.Details)
+	}
+}
+
+func TestGraphQLUpstream_InvalidVariable(t *testing.T) {
+	srv := newGraphQLServer(t, `{"data":{}}`, nil)
+	upstream := newTestGraphQLUpstream(t, srv.URL, map[string]any{"limit": "{{ query.limit | int }}"})
+
+	req := httptest.NewRequest(http.MethodGet, "/users?limit=many", nil)
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err == nil || len(resp.Err.Details) != 1 || resp.Err.Details[0].Code != ErrorCodeBadRequest {
+		t.Fatalf("expected a bad request error, got %+v", resp.Err)
+	}
+}
+
+func TestGraphQLUpstream_PartialResults(t *testing.T) {
+	srv := newGraphQLServer(t, `{"data":{"user":{"name":"ann","orders":null}},"errors":[{"message":"orders unavailable","path":["user","orders"]}]}`, nil)
+
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"profile":{"age":30}}`))
+	}))
+	defer rest.Close()
+
+	tests := []struct {
+		name         string
+		allowPartial bool
+		wantStatus   int
+		wantData     string
+	}{
+		{name: "allowed", allowPartial: true, wantStatus: http.StatusPartialContent, wantData: `{"profile":{"age":30},"user":{"name":"ann","orders":null}}`},
+		{name: "not allowed", allowPartial: false, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstreams := initUpstreams([]UpstreamConfig{
+				{Hosts: []string{rest.URL}, Method: http.MethodGet, Timeout: time.Second},
+			}, newTransportRegistry(), zap.NewNop())
+			upstreams = append(upstreams, newTestGraphQLUpstream(t, srv.URL, nil))
+
+			r := &Router{
+				dispatcher: &defaultDispatcher{log: zap.NewNop(), metrics: metric.NewNop()},
+				aggregator: &defaultAggregator{log: zap.NewNop()},
+				Routes: []Route{
+					{
+						Path:      "/users/{id}",
+						Method:    http.MethodGet,
+						Upstreams: upstreams,
+						Aggregation: AggregationConfig{
+							Strategy:            strategyMerge,
+							AllowPartialResults: tt.allowPartial,
+						},
+						MaxParallelUpstreams: 2,
+					},
+				},
+				log:     zap.NewNop(),
+				metrics: metric.NewNop(),
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+
+			var out JSONResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+
+			if string(out.Data) != tt.wantData {
+				t.Fatalf("expected data %q, got %q", tt.wantData, out.Data)
+			}
+
+			if len(out.Errors) != 1 || out.Errors[0].Code != ErrorCodeUpstreamError || out.Errors[0].Message != "orders unavailable" {
+				t.Fatalf("expected the graphql error, got %v", out.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,542 @@
+package kono
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	grpcFrameHeaderSize = 5
+	grpcFlagCompressed  = 0x01
+	grpcFlagTrailer     = 0x80
+
+	// grpcMaxMessageSize bounds response frames if the policy sets no max_response_body_size, like grpc-go.
+	grpcMaxMessageSize = 4 << 20 // 4MB
+
+	contentTypeGRPC    = "application/grpc"
+	contentTypeGRPCWeb = "application/grpc-web+proto"
+)
+
+// grpcUpstream calls a unary gRPC method. JSON request bodies are transcoded to the method input message
+// and the output message is returned as JSON, so gRPC backends can be aggregated with HTTP ones.
+// Hosts, balancing, forwarded headers, transports and the resilience policy are shared with httpUpstream.
+type grpcUpstream struct {
+	http   *httpUpstream
+	web    bool // Use the gRPC-Web protocol, e.g. for backends behind HTTP/1.1 proxies.
+	method protoreflect.MethodDescriptor
+	path   string // "/package.Service/Method"
+
+	unmarshal protojson.UnmarshalOptions
+	marshal   protojson.MarshalOptions
+}
+
+func newGRPCUpstream(upstream *httpUpstream, upstreamType string, cfg GRPCConfig) (*grpcUpstream, error) {
+	method, err := loadGRPCMethod(cfg.DescriptorSet, cfg.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcUpstream{
+		http:   upstream,
+		web:    upstreamType == UpstreamTypeGRPCWeb,
+		method: method,
+		path:   "/" + string(method.Parent().FullName()) + "/" + string(method.Name()),
+		unmarshal: protojson.UnmarshalOptions{
+			DiscardUnknown: true,
+		},
+		marshal: protojson.MarshalOptions{
+			UseProtoNames:   cfg.UseProtoNames,
+			EmitUnpopulated: cfg.EmitDefaults,
+		},
+	}, nil
+}
+
+// loadGRPCMethod finds a unary method, given as "package.Service/Method", in the descriptor set file.
+func loadGRPCMethod(descriptorSet, name string) (protoreflect.MethodDescriptor, error) {
+	data, err := os.ReadFile(descriptorSet)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read descriptor set: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err = proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("cannot parse descriptor set %s: %w", descriptorSet, err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %s: %w", descriptorSet, err)
+	}
+
+	serviceName, methodName, ok := strings.Cut(strings.TrimPrefix(name, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid grpc method %q, expected package.Service/Method", name)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("grpc service %s not found: %w", serviceName, err)
+	}
+
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a grpc service", serviceName)
+	}
+
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("grpc method %s not found in service %s", methodName, serviceName)
+	}
+
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, fmt.Errorf("grpc method %s is not unary", name)
+	}
+
+	return method, nil
+}
+
+func (g *grpcUpstream) Name() string   { return g.http.Name() }
+func (g *grpcUpstream) Policy() Policy { return g.http.Policy() }
+
+func (g *grpcUpstream) Call(ctx context.Context, original *http.Request, originalBody []byte) *UpstreamResponse {
+	return g.http.callWithPolicy(ctx, original, func(ctx context.Context, log *zap.Logger) *UpstreamResponse {
+		return g.call(ctx, original, originalBody, log)
+	})
+}
+
+func (g *grpcUpstream) call(ctx context.Context, original *http.Request, originalBody []byte, log *zap.Logger) *UpstreamResponse {
+	uresp := &UpstreamResponse{
+		Headers: make(http.Header),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.http.timeout)
+	defer cancel()
+
+	payload, err := g.requestMessage(original, originalBody)
+	if err != nil {
+		uresp.Err = &UpstreamError{
+			Kind: UpstreamInternal,
+			Err:  err,
+		}
+
+		return uresp
+	}
+
+	req, err := g.newRequest(ctx, original, payload)
+	if err != nil {
+		uresp.Err = &UpstreamError{
+			Kind: UpstreamInternal,
+			Err:  err,
+		}
+
+		return uresp
+	}
+
+	hresp, err := g.http.client.Do(req)
+	if err != nil {
+		log.Error("non-successful upstream request", zap.Error(err))
+
+		kind := UpstreamConnection
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			kind = UpstreamTimeout
+		}
+
+		if errors.Is(err, context.Canceled) {
+			kind = UpstreamCanceled
+		}
+
+		uresp.Err = &UpstreamError{
+			Kind: kind,
+			Err:  err,
+		}
+
+		return uresp
+	}
+	defer hresp.Body.Close()
+
+	if hresp.StatusCode != http.StatusOK {
+		log.Error("non-200 grpc upstream response status code", zap.Int("status_code", hresp.StatusCode))
+
+		uresp.Status = hresp.StatusCode
+		uresp.Err = &UpstreamError{
+			Kind: UpstreamBadStatus,
+			Err:  fmt.Errorf("grpc upstream responded with http status %d", hresp.StatusCode),
+		}
+
+		return uresp
+	}
+
+	message, trailer, err := g.readResponse(hresp)
+	if err != nil {
+		kind := UpstreamReadError
+		if errors.Is(err, errGRPCBodyTooLarge) {
+			kind = UpstreamBodyTooLarge
+		}
+
+		uresp.Err = &UpstreamError{
+			Kind: kind,
+			Err:  err,
+		}
+
+		return uresp
+	}
+
+	code, statusErr := grpcStatus(hresp.Header, trailer)
+	if code != codes.OK {
+		log.Error("grpc upstream returned error status", zap.String("code", code.String()), zap.Error(statusErr))
+
+		uresp.Status = grpcHTTPStatus(code)
+		uresp.Err = &UpstreamError{
+			Kind: grpcErrorKind(code),
+			Err:  statusErr,
+		}
+
+		return uresp
+	}
+
+	body, err := g.responseJSON(message)
+	if err != nil {
+		uresp.Err = &UpstreamError{
+			Kind: UpstreamReadError,
+			Err:  err,
+		}
+
+		return uresp
+	}
+
+	uresp.Status = http.StatusOK
+	uresp.Headers = grpcResponseHeaders(hresp.Header)
+	uresp.Body = body
+
+	return uresp
+}
+
+// requestMessage builds the input message from the JSON body. Top-level scalar fields are also set
+// from path parameters and forwarded query strings with the same name.
+func (g *grpcUpstream) requestMessage(original *http.Request, body []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(g.method.Input())
+
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := g.unmarshal.Unmarshal(body, msg); err != nil {
+			return nil, fmt.Errorf("cannot transcode request body to %s: %w", g.method.Input().FullName(), err)
+		}
+	}
+
+	query := original.URL.Query()
+	fields := g.method.Input().Fields()
+
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if fd.IsList() || fd.IsMap() || fd.Message() != nil {
+			continue
+		}
+
+		name := string(fd.Name())
+
+		value := original.PathValue(name)
+		if value == "" && g.forwardsQuery(name) {
+			value = query.Get(name)
+		}
+
+		if value == "" {
+			continue
+		}
+
+		v, err := scalarValue(fd, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for field %s: %w", name, err)
+		}
+
+		msg.Set(fd, v)
+	}
+
+	return proto.Marshal(msg)
+}
+
+func (g *grpcUpstream) forwardsQuery(name string) bool {
+	for _, fqs := range g.http.forwardQueryStrings {
+		if fqs == "*" || fqs == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scalarValue parses a request parameter into a value of the field kind.
+func scalarValue(fd protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	//nolint:exhaustive // messages and groups are skipped by the caller
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(value)), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(value)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(value, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(value, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(value, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(value, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(value, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(value)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+
+		n, err := strconv.ParseInt(value, 10, 32)
+
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+	}
+}
+
+func (g *grpcUpstream) newRequest(ctx context.Context, original *http.Request, payload []byte) (*http.Request, error) {
+	frame := make([]byte, grpcFrameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[1:grpcFrameHeaderSize], uint32(len(payload))) //nolint:gosec // bounded by maxBodySize
+	copy(frame[grpcFrameHeaderSize:], payload)
+
+	target, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(g.http.selectHost(), "/")+g.path, bytes.NewReader(frame))
+	if err != nil {
+		return nil, err
+	}
+
+	g.http.resolveHeaders(target, original)
+
+	// Forwarded headers become request metadata. Headers managed by the protocol are always overwritten.
+	target.Header.Del("Content-Length")
+	target.Header.Del("Accept-Encoding")
+
+	if g.web {
+		target.Header.Set("Content-Type", contentTypeGRPCWeb)
+		target.Header.Set("X-Grpc-Web", "1")
+	} else {
+		target.Header.Set("Content-Type", contentTypeGRPC)
+		target.Header.Set("Te", "trailers")
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		target.Header.Set("Grpc-Timeout", grpcTimeout(deadline))
+	}
+
+	return target, nil
+}
+
+var errGRPCBodyTooLarge = errors.New("grpc response message too large")
+
+// readResponse reads the response message. For gRPC-Web, trailers are read from the trailer frame
+// at the end of the body, otherwise from HTTP trailers.
+func (g *grpcUpstream) readResponse(hresp *http.Response) ([]byte, http.Header, error) {
+	var message, trailerFrame []byte
+
+	for {
+		header := make([]byte, grpcFrameHeaderSize)
+
+		if _, err := io.ReadFull(hresp.Body, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, nil, fmt.Errorf("cannot read grpc frame: %w", err)
+		}
+
+		limit := g.http.policy.MaxResponseBodySize
+		if limit <= 0 {
+			limit = grpcMaxMessageSize
+		}
+
+		// The frame length is untrusted, it is checked before allocating the frame.
+		size := int64(binary.BigEndian.Uint32(header[1:]))
+		if size > limit {
+			return nil, nil, fmt.Errorf("%w: %d bytes, limit is %d", errGRPCBodyTooLarge, size, limit)
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(hresp.Body, data); err != nil {
+			return nil, nil, fmt.Errorf("cannot read grpc frame: %w", err)
+		}
+
+		switch {
+		case header[0]&grpcFlagTrailer != 0:
+			trailerFrame = data
+		case header[0]&grpcFlagCompressed != 0:
+			return nil, nil, errors.New("compressed grpc messages are not supported")
+		case message == nil:
+			message = data
+		default:
+			return nil, nil, errors.New("unary grpc method returned more than one message")
+		}
+	}
+
+	if trailerFrame == nil {
+		// HTTP trailers are only populated once the body has been read to the end.
+		return message, hresp.Trailer, nil
+	}
+
+	trailer, err := parseGRPCWebTrailer(trailerFrame)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return message, trailer, nil
+}
+
+func parseGRPCWebTrailer(data []byte) (http.Header, error) {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(data)))
+
+	mime, err := reader.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("cannot parse grpc-web trailer: %w", err)
+	}
+
+	return http.Header(mime), nil
+}
+
+func (g *grpcUpstream) responseJSON(message []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(g.method.Output())
+
+	if err := proto.Unmarshal(message, msg); err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %w", g.method.Output().FullName(), err)
+	}
+
+	return g.marshal.Marshal(msg)
+}
+
+// grpcStatus returns the call status from the trailers, or from the headers for trailers-only responses.
+func grpcStatus(header, trailer http.Header) (codes.Code, error) {
+	source := trailer
+	if source.Get("Grpc-Status") == "" {
+		source = header
+	}
+
+	value := source.Get("Grpc-Status")
+	if value == "" {
+		return codes.Unknown, errors.New("grpc upstream response has no status")
+	}
+
+	code, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return codes.Unknown, fmt.Errorf("invalid grpc status %q", value)
+	}
+
+	if codes.Code(code) == codes.OK {
+		return codes.OK, nil
+	}
+
+	message, err := url.PathUnescape(source.Get("Grpc-Message"))
+	if err != nil {
+		message = source.Get("Grpc-Message")
+	}
+
+	return codes.Code(code), fmt.Errorf("grpc status %s: %s", codes.Code(code), message) //nolint:gosec // parsed as 32 bit
+}
+
+// grpcErrorKind maps gRPC status codes onto upstream error kinds. Codes caused by the request itself,
+// e.g. INVALID_ARGUMENT or NOT_FOUND, are client errors: like HTTP 4xx they are neither retried nor
+// counted by the circuit breaker.
+func grpcErrorKind(code codes.Code) UpstreamErrorKind {
+	switch code { //nolint:exhaustive // the rest are client errors
+	case codes.DeadlineExceeded:
+		return UpstreamTimeout
+	case codes.Canceled:
+		return UpstreamCanceled
+	case codes.Unavailable:
+		return UpstreamConnection
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented, codes.ResourceExhausted:
+		return UpstreamBadStatus
+	default:
+		return UpstreamClientError
+	}
+}
+
+// grpcHTTPStatus maps gRPC status codes onto HTTP statuses the same way grpc-gateway does.
+func grpcHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 //nolint:mnd // client closed request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// grpcResponseHeaders returns the response metadata without protocol headers, which must not reach HTTP clients.
+func grpcResponseHeaders(header http.Header) http.Header {
+	out := make(http.Header, len(header))
+
+	for k, v := range header {
+		lower := strings.ToLower(k)
+		if lower == "content-type" || lower == "content-length" || lower == "trailer" || strings.HasPrefix(lower, "grpc-") {
+			continue
+		}
+
+		// Binary metadata is base64 encoded protobuf and meaningless to HTTP clients.
+		if strings.HasSuffix(lower, "-bin") {
+			continue
+		}
+
+		out[k] = v
+	}
+
+	return out
+}
+
+// grpcTimeout formats the time remaining until deadline as a grpc-timeout header value in milliseconds.
+func grpcTimeout(deadline time.Time) string {
+	return strconv.FormatInt(max(time.Until(deadline).Milliseconds(), 1), 10) + "m"
+}
@@ -0,0 +1,444 @@
+package kono
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/starwalkn/kono/internal/circuitbreaker"
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+// testUsersFile describes:
+//
+//	package test.v1;
+//	message GetUserRequest { string id = 1; int32 version = 2; }
+//	message User { string id = 1; string display_name = 2; }
+//	service Users { rpc GetUser(GetUserRequest) returns (User); }
+func testUsersFile() *descriptorpb.FileDescriptorProto {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Type:   typ.Enum(),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+	}
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/v1/users.proto"),
+		Package: proto.String("test.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("GetUserRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					field("version", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				},
+			},
+			{
+				Name: proto.String("User"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					field("display_name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("Users"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("GetUser"),
+						InputType:  proto.String(".test.v1.GetUserRequest"),
+						OutputType: proto.String(".test.v1.User"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func writeDescriptorSet(t *testing.T) (string, protoreflect.FileDescriptor) {
+	t.Helper()
+
+	fdp := testUsersFile()
+
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatalf("build descriptor: %v", err)
+	}
+
+	data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdp}})
+	if err != nil {
+		t.Fatalf("marshal descriptor set: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "users.pb")
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write descriptor set: %v", err)
+	}
+
+	return path, fd
+}
+
+// startUsersServer serves test.v1.Users/GetUser. Unknown users yield NOT_FOUND, empty IDs INVALID_ARGUMENT,
+// the caller's authorization metadata is echoed in the display name.
+func startUsersServer(t *testing.T, fd protoreflect.FileDescriptor, opts ...grpc.ServerOption) string {
+	t.Helper()
+
+	input := fd.Messages().ByName("GetUserRequest")
+	output := fd.Messages().ByName("User")
+
+	getUser := func(ctx context.Context, msg any) (any, error) {
+		req, _ := msg.(*dynamicpb.Message)
+
+		id := req.Get(input.Fields().ByName("id")).String()
+
+		switch id {
+		case "missing":
+			return nil, status.Error(codes.NotFound, "user not found")
+		case "":
+			return nil, status.Error(codes.InvalidArgument, "id is required")
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		resp := dynamicpb.NewMessage(output)
+		resp.Set(output.Fields().ByName("id"), protoreflect.ValueOfString(id))
+		resp.Set(output.Fields().ByName("display_name"), protoreflect.ValueOfString(
+			"user "+id+" v"+req.Get(input.Fields().ByName("version")).String()+" "+firstOf(md.Get("authorization")),
+		))
+
+		return resp, nil
+	}
+
+	handler := func(
+		srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor,
+	) (any, error) {
+		req := dynamicpb.NewMessage(input)
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+
+		if interceptor == nil {
+			return getUser(ctx, req)
+		}
+
+		return interceptor(ctx, req, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.v1.Users/GetUser"}, getUser)
+	}
+
+	srv := grpc.NewServer(opts...)
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.v1.Users",
+		HandlerType: (*any)(nil),
+		Methods:     []grpc.MethodDesc{{MethodName: "GetUser", Handler: handler}},
+	}, struct{}{})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go func() { _ = srv.Serve(lis) }()
+
+	t.Cleanup(srv.Stop)
+
+	return "http://" + lis.Addr().String()
+}
+
+func firstOf(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func newTestGRPCUpstream(t *testing.T, upstreamType, host, descriptorSet string) Upstream {
+	t.Helper()
+
+	return initUpstreams([]UpstreamConfig{
+		{
+			Type:                upstreamType,
+			Hosts:               []string{host},
+			Timeout:             2 * time.Second,
+			ForwardHeaders:      []string{"Authorization"},
+			ForwardQueryStrings: []string{"version"},
+			GRPC: GRPCConfig{
+				DescriptorSet: descriptorSet,
+				Method:        "test.v1.Users/GetUser",
+			},
+		},
+	}, newTransportRegistry(), zap.NewNop())[0]
+}
+
+func TestGRPCUpstream_TranscodesUnaryCall(t *testing.T) {
+	descriptorSet, fd := writeDescriptorSet(t)
+	host := startUsersServer(t, fd)
+	upstream := newTestGRPCUpstream(t, UpstreamTypeGRPC, host, descriptorSet)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42?version=3", nil)
+	req.SetPathValue("id", "42")
+	req.Header.Set("Authorization", "Bearer t")
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err != nil {
+		t.Fatalf("unexpected error: %v", resp.Err.Unwrap())
+	}
+
+	if resp.Status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Status)
+	}
+
+	var user map[string]string
+	if err := json.Unmarshal(resp.Body, &user); err != nil {
+		t.Fatalf("response is not JSON: %s", resp.Body)
+	}
+
+	if user["id"] != "42" || user["displayName"] != "user 42 v3 Bearer t" {
+		t.Fatalf("unexpected response %v", user)
+	}
+
+	if resp.Headers.Get("Content-Type") != "" {
+		t.Fatalf("grpc content type must not be returned, got %q", resp.Headers.Get("Content-Type"))
+	}
+}
+
+func TestGRPCUpstream_TranscodesJSONBody(t *testing.T) {
+	descriptorSet, fd := writeDescriptorSet(t)
+	host := startUsersServer(t, fd)
+	upstream := newTestGRPCUpstream(t, UpstreamTypeGRPC, host, descriptorSet)
+
+	body := []byte(`{"id":"7","version":2,"unknown":true}`)
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+
+	resp := upstream.Call(req.Context(), req, body)
+	if resp.Err != nil {
+		t.Fatalf("unexpected error: %v", resp.Err.Unwrap())
+	}
+
+	var user map[string]string
+	if err := json.Unmarshal(resp.Body, &user); err != nil || user["displayName"] != "user 7 v2 " {
+		t.Fatalf("unexpected body %s", resp.Body)
+	}
+}
+
+func TestGRPCUpstream_MapsStatus(t *testing.T) {
+	descriptorSet, fd := writeDescriptorSet(t)
+	host := startUsersServer(t, fd)
+	upstream := newTestGRPCUpstream(t, UpstreamTypeGRPC, host, descriptorSet)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/missing", nil)
+	req.SetPathValue("id", "missing")
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if resp.Status != http.StatusNotFound || resp.Err.Kind != UpstreamClientError {
+		t.Fatalf("expected 404 client_error, got %d %s", resp.Status, resp.Err.Kind)
+	}
+}
+
+func TestGRPCUpstream_ClientErrorNotRetried(t *testing.T) {
+	descriptorSet, fd := writeDescriptorSet(t)
+
+	var calls atomic.Int32
+
+	host := startUsersServer(t, fd, grpc.UnaryInterceptor(
+		func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			calls.Add(1)
+			return handler(ctx, req)
+		},
+	))
+
+	upstream := initUpstreams([]UpstreamConfig{
+		{
+			Type:    UpstreamTypeGRPC,
+			Hosts:   []string{host},
+			Timeout: 2 * time.Second,
+			GRPC:    GRPCConfig{DescriptorSet: descriptorSet, Method: "test.v1.Users/GetUser"},
+			Policy: PolicyConfig{
+				RetryConfig:          RetryConfig{MaxRetries: 2},
+				CircuitBreakerConfig: CircuitBreakerConfig{Enabled: true, MaxFailures: 1, ResetTimeout: time.Minute},
+			},
+		},
+	}, newTransportRegistry(), zap.NewNop())[0]
+
+	for range 3 {
+		req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+
+		resp := upstream.Call(req.Context(), req, nil)
+		if resp.Err == nil || resp.Err.Kind != UpstreamClientError || resp.Status != http.StatusBadRequest {
+			t.Fatalf("expected 400 client_error, got %d %+v", resp.Status, resp.Err)
+		}
+	}
+
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("expected a single attempt per call, got %d attempts for 3 calls", got)
+	}
+
+	if state := upstream.(*grpcUpstream).http.circuitBreaker.State(); state != circuitbreaker.Closed {
+		t.Fatalf("expected closed circuit breaker, got %s", state)
+	}
+}
+
+func TestGRPCUpstream_AggregatesWithMerge(t *testing.T) {
+	descriptorSet, fd := writeDescriptorSet(t)
+	host := startUsersServer(t, fd)
+
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"orders":[1,2]}`))
+	}))
+	defer rest.Close()
+
+	upstreams := initUpstreams([]UpstreamConfig{
+		{Hosts: []string{rest.URL}, Method: http.MethodGet, Timeout: time.Second},
+	}, newTransportRegistry(), zap.NewNop())
+	upstreams = append(upstreams, newTestGRPCUpstream(t, UpstreamTypeGRPC, host, descriptorSet))
+
+	r := &Router{
+		dispatcher: &defaultDispatcher{log: zap.NewNop(), metrics: metric.NewNop()},
+		aggregator: &defaultAggregator{log: zap.NewNop()},
+		Routes: []Route{
+			{
+				Path:                 "/users/{id}",
+				Method:               http.MethodGet,
+				Upstreams:            upstreams,
+				Aggregation:          AggregationConfig{Strategy: strategyMerge},
+				MaxParallelUpstreams: 2,
+			},
+		},
+		log:     zap.NewNop(),
+		metrics: metric.NewNop(),
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/5", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var out JSONResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+
+	if string(out.Data) != `{"displayName":"user 5 v0 ","id":"5","orders":[1,2]}` {
+		t.Fatalf("unexpected merged data %s", out.Data)
+	}
+}
+
+func TestGRPCWebUpstream(t *testing.T) {
+	descriptorSet, fd := writeDescriptorSet(t)
+	output := fd.Messages().ByName("User")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != contentTypeGRPCWeb || r.URL.Path != "/test.v1.Users/GetUser" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		user := dynamicpb.NewMessage(output)
+		user.Set(output.Fields().ByName("id"), protoreflect.ValueOfString("1"))
+		payload, _ := proto.Marshal(user)
+
+		w.Header().Set("Content-Type", contentTypeGRPCWeb)
+		_, _ = w.Write(grpcWebFrame(0, payload))
+		_, _ = w.Write(grpcWebFrame(grpcFlagTrailer, []byte("grpc-status: 0\r\ngrpc-message: \r\n")))
+	}))
+	defer srv.Close()
+
+	upstream := newTestGRPCUpstream(t, UpstreamTypeGRPCWeb, srv.URL, descriptorSet)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err != nil {
+		t.Fatalf("unexpected error: %v", resp.Err.Unwrap())
+	}
+
+	var user map[string]string
+	if err := json.Unmarshal(resp.Body, &user); err != nil || user["id"] != "1" {
+		t.Fatalf("unexpected body %s", resp.Body)
+	}
+}
+
+func TestGRPCWebUpstream_TrailerStatus(t *testing.T) {
+	descriptorSet, _ := writeDescriptorSet(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentTypeGRPCWeb)
+		_, _ = w.Write(grpcWebFrame(grpcFlagTrailer, []byte("grpc-status: 14\r\ngrpc-message: backend%20down\r\n")))
+	}))
+	defer srv.Close()
+
+	upstream := newTestGRPCUpstream(t, UpstreamTypeGRPCWeb, srv.URL, descriptorSet)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err == nil || resp.Err.Kind != UpstreamConnection || resp.Status != http.StatusServiceUnavailable {
+		t.Fatalf("expected unavailable mapped to connection/503, got %+v", resp)
+	}
+
+	if resp.Err.Unwrap().Error() != "grpc status Unavailable: backend down" {
+		t.Fatalf("unexpected error message %q", resp.Err.Unwrap())
+	}
+}
+
+func TestGRPCWebUpstream_OversizedFrame(t *testing.T) {
+	descriptorSet, _ := writeDescriptorSet(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// A frame header announcing a 4GB message without a policy limit.
+		header := make([]byte, grpcFrameHeaderSize)
+		binary.BigEndian.PutUint32(header[1:], math.MaxUint32)
+
+		w.Header().Set("Content-Type", contentTypeGRPCWeb)
+		_, _ = w.Write(header)
+	}))
+	defer srv.Close()
+
+	upstream := newTestGRPCUpstream(t, UpstreamTypeGRPCWeb, srv.URL, descriptorSet)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err == nil || resp.Err.Kind != UpstreamBodyTooLarge {
+		t.Fatalf("expected body too large error, got %+v", resp.Err)
+	}
+}
+
+func grpcWebFrame(flag byte, payload []byte) []byte {
+	frame := make([]byte, grpcFrameHeaderSize+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	copy(frame[grpcFrameHeaderSize:], payload)
+
+	return frame
+}
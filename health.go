@@ -0,0 +1,144 @@
+package kono
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// unhealthyAfterFailures is the number of consecutive failed requests after which a host is reported unhealthy.
+const unhealthyAfterFailures = 3
+
+// hostHealth tracks the results of requests sent to an upstream host. Health is passive: it reflects
+// the responses of proxied requests, no probes are sent.
+type hostHealth struct {
+	url string
+	key string // scheme://host[:port] of the url, matched against outgoing requests.
+
+	drained atomic.Bool
+
+	successes           atomic.Uint64
+	failures            atomic.Uint64
+	consecutiveFailures atomic.Uint64
+
+	mu         sync.Mutex
+	lastStatus int
+	lastError  string
+	lastSeen   time.Time
+}
+
+func newHostHealth(hosts []string) []*hostHealth {
+	health := make([]*hostHealth, 0, len(hosts))
+
+	for _, host := range hosts {
+		h := &hostHealth{url: host}
+
+		if u, err := url.Parse(host); err == nil {
+			h.key = u.Scheme + "://" + u.Host
+		}
+
+		health = append(health, h)
+	}
+
+	return health
+}
+
+func (h *hostHealth) record(status int, err error) {
+	failed := err != nil || status >= http.StatusInternalServerError
+
+	if failed {
+		h.failures.Add(1)
+		h.consecutiveFailures.Add(1)
+	} else {
+		h.successes.Add(1)
+		h.consecutiveFailures.Store(0)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastStatus = status
+	h.lastSeen = time.Now()
+
+	h.lastError = ""
+	if err != nil {
+		h.lastError = err.Error()
+	}
+}
+
+// HostInfo is the state of an upstream host.
+type HostInfo struct {
+	URL                 string    `json:"url"`
+	Healthy             bool      `json:"healthy"`
+	Drained             bool      `json:"drained"`
+	Successes           uint64    `json:"successes"`
+	Failures            uint64    `json:"failures"`
+	ConsecutiveFailures uint64    `json:"consecutive_failures"`
+	LastStatus          int       `json:"last_status,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastSeen            time.Time `json:"last_seen,omitzero"`
+}
+
+func (h *hostHealth) info() HostInfo {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	consecutiveFailures := h.consecutiveFailures.Load()
+
+	return HostInfo{
+		URL:                 h.url,
+		Healthy:             consecutiveFailures < unhealthyAfterFailures,
+		Drained:             h.drained.Load(),
+		Successes:           h.successes.Load(),
+		Failures:            h.failures.Load(),
+		ConsecutiveFailures: consecutiveFailures,
+		LastStatus:          h.lastStatus,
+		LastError:           h.lastError,
+		LastSeen:            h.lastSeen,
+	}
+}
+
+// healthTransport records the result of every request in the health of its host.
+type healthTransport struct {
+	next  http.RoundTripper
+	hosts []*hostHealth
+}
+
+func (t *healthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+
+	// Requests canceled by the client say nothing about the host.
+	if errors.Is(err, context.Canceled) {
+		return resp, err
+	}
+
+	key := req.URL.Scheme + "://" + req.URL.Host
+
+	for _, h := range t.hosts {
+		if h.key != key {
+			continue
+		}
+
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+
+		h.record(status, err)
+
+		break
+	}
+
+	return resp, err
+}
+
+// CloseIdleConnections closes idle connections of the wrapped transport.
+func (t *healthTransport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
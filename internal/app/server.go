@@ -2,49 +2,124 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 
-	"github.com/VictoriaMetrics/metrics"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 
 	"github.com/starwalkn/kono"
+	"github.com/starwalkn/kono/admin"
 	"github.com/starwalkn/kono/dashboard"
 )
 
 type Server struct {
-	http *http.Server
-	log  *zap.Logger
+	http     *http.Server
+	redirect *http.Server
+	metrics  *http.Server
+	admin    *http.Server
+	tls      kono.ServerTLSConfig
+	log      *zap.Logger
+
+	// tracerProvider is nil if tracing is disabled.
+	tracerProvider *sdktrace.TracerProvider
+
+	// accessLog is nil if the access log is disabled.
+	accessLog *kono.AccessLog
+
+	routers *routerSwitch
+
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func NewServer(cfg kono.Config, log *zap.Logger) *Server {
-	if cfg.Dashboard.Enabled {
-		dashboardServer := dashboard.NewServer(&cfg, log.Named("dashboard"))
-		go dashboardServer.Start()
+// NewServer creates the gateway server. The level of log can be changed at runtime via the admin API.
+func NewServer(cfg kono.Config, log *zap.Logger, level zap.AtomicLevel) *Server {
+	// Settings shared by routers of all applied configs, the config dependent ones are set by the router switch.
+	var routerConfigSet kono.RouterConfigSet
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Metrics outlive the router and are pushed until the server stops.
+	metrics, err := kono.NewMetrics(ctx, cfg.Server.Metrics)
+	if err != nil {
+		log.Fatal("failed to init metrics", zap.Error(err))
+	}
+
+	routerConfigSet.MetricsBackend = metrics
+
+	var tracerProvider *sdktrace.TracerProvider
+
+	if cfg.Server.Tracing.Enabled {
+		provider, propagator, terr := kono.NewTracerProvider(cfg.Server.Tracing, cfg.Name, cfg.Version)
+		if terr != nil {
+			log.Fatal("failed to init tracing", zap.Error(terr))
+		}
+
+		tracerProvider = provider
+
+		routerConfigSet.TracerProvider = provider
+		routerConfigSet.Propagator = propagator
+	}
+
+	var accessLog *kono.AccessLog
+
+	if cfg.Server.AccessLog.Enabled {
+		accessLog, err = kono.NewAccessLog(cfg.Server.AccessLog)
+		if err != nil {
+			log.Fatal("failed to init access log", zap.Error(err))
+		}
+
+		routerConfigSet.AccessLog = accessLog
 	}
 
-	routerConfigSet := kono.RouterConfigSet{
-		Version:     cfg.Version,
-		Routes:      cfg.Routes,
-		Middlewares: cfg.Middlewares,
-		Features:    cfg.Features,
-		Metrics:     cfg.Server.Metrics,
+	// Traffic statistics are only collected for the dashboard live view.
+	if cfg.Dashboard.Enabled {
+		routerConfigSet.TrafficStats = kono.NewTrafficStats()
 	}
 
-	mainRouter := kono.NewRouter(routerConfigSet, log.Named("router"))
+	routers := newRouterSwitch(routerConfigSet, cfg, log.Named("router"))
+
+	if cfg.Dashboard.Enabled {
+		var gateway dashboard.Gateway = routers
+
+		// Configs are only applied by authenticated admins.
+		if len(cfg.Dashboard.Auth.Users) == 0 && !cfg.Dashboard.Auth.JWT.Enabled {
+			gateway = dashboard.ReadOnly(routers)
+		}
+
+		dashboardServer, derr := dashboard.NewServer(&cfg, gateway, log.Named("dashboard"))
+		if derr != nil {
+			log.Fatal("failed to init dashboard", zap.Error(derr))
+		}
+
+		go dashboardServer.Start()
+	}
 
 	mux := http.NewServeMux()
 
+	var metricsServer *http.Server
+
 	if cfg.Server.Metrics.Enabled {
-		mux.Handle("/metrics", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-			metrics.WritePrometheus(w, true)
-			// promhttp.Handler()
-		}))
+		if cfg.Server.Metrics.Port > 0 && cfg.Server.Metrics.Port != cfg.Server.Port {
+			metricsServer = newMetricsServer(cfg.Server, metrics.Handler())
+		} else {
+			mux.Handle(cfg.Server.Metrics.Path, metrics.Handler())
+		}
 	}
 
-	mux.Handle("/", mainRouter)
+	mux.Handle("/", routers)
+
+	var adminServer *http.Server
+
+	if cfg.Admin.Enabled {
+		adminServer = newAdminServer(cfg.Admin, admin.NewHandler(routers.Router, level, cfg.Admin.Tokens, log.Named("admin")))
+	}
 
-	return &Server{
+	server := &Server{
 		log: log,
 		http: &http.Server{
 			Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
@@ -52,13 +127,126 @@ func NewServer(cfg kono.Config, log *zap.Logger) *Server {
 			ReadTimeout:  cfg.Server.Timeout,
 			WriteTimeout: cfg.Server.Timeout,
 		},
+		metrics:        metricsServer,
+		admin:          adminServer,
+		tls:            cfg.Server.TLS,
+		tracerProvider: tracerProvider,
+		accessLog:      accessLog,
+		routers:        routers,
+		ctx:            ctx,
+		cancel:         cancel,
 	}
+
+	if cfg.Server.TLS.Enabled {
+		server.http.Protocols = newProtocols(cfg.Server.TLS.DisableHTTP2)
+
+		if cfg.Server.TLS.RedirectPort > 0 {
+			server.redirect = newRedirectServer(cfg.Server)
+		}
+	}
+
+	return server
 }
 
 func (s *Server) Start() error {
-	return s.http.ListenAndServe()
+	if s.metrics != nil {
+		go func() {
+			if merr := s.metrics.ListenAndServe(); merr != nil && !errors.Is(merr, http.ErrServerClosed) {
+				s.log.Error("metrics server error", zap.Error(merr))
+			}
+		}()
+	}
+
+	if s.admin != nil {
+		go func() {
+			if aerr := s.admin.ListenAndServe(); aerr != nil && !errors.Is(aerr, http.ErrServerClosed) {
+				s.log.Error("admin server error", zap.Error(aerr))
+			}
+		}()
+	}
+
+	if !s.tls.Enabled {
+		return s.http.ListenAndServe()
+	}
+
+	tlsConfig, store, err := newTLSConfig(s.tls, s.log.Named("tls"))
+	if err != nil {
+		return fmt.Errorf("cannot configure TLS: %w", err)
+	}
+
+	s.http.TLSConfig = tlsConfig
+
+	go store.Watch(s.ctx, s.tls.ReloadInterval)
+
+	if s.redirect != nil {
+		go func() {
+			if rerr := s.redirect.ListenAndServe(); rerr != nil && !errors.Is(rerr, http.ErrServerClosed) {
+				s.log.Error("redirect server error", zap.Error(rerr))
+			}
+		}()
+	}
+
+	return s.http.ListenAndServeTLS("", "")
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	return s.http.Shutdown(ctx)
+	s.cancel()
+
+	if s.redirect != nil {
+		if err := s.redirect.Shutdown(ctx); err != nil {
+			s.log.Warn("cannot shutdown redirect server", zap.Error(err))
+		}
+	}
+
+	if s.metrics != nil {
+		if err := s.metrics.Shutdown(ctx); err != nil {
+			s.log.Warn("cannot shutdown metrics server", zap.Error(err))
+		}
+	}
+
+	if s.admin != nil {
+		if err := s.admin.Shutdown(ctx); err != nil {
+			s.log.Warn("cannot shutdown admin server", zap.Error(err))
+		}
+	}
+
+	err := s.http.Shutdown(ctx)
+
+	s.routers.Close()
+
+	// Spans of requests finished during shutdown are flushed to the collector.
+	if s.tracerProvider != nil {
+		if terr := s.tracerProvider.Shutdown(ctx); terr != nil {
+			s.log.Warn("cannot shutdown tracer provider", zap.Error(terr))
+		}
+	}
+
+	if aerr := s.accessLog.Close(); aerr != nil {
+		s.log.Warn("cannot close access log", zap.Error(aerr))
+	}
+
+	return err
+}
+
+// newMetricsServer creates a dedicated listener serving only the metrics, bound to the metrics host.
+func newMetricsServer(cfg kono.ServerConfig, handler http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Metrics.Path, handler)
+
+	return &http.Server{
+		Addr:         net.JoinHostPort(cfg.Metrics.Host, strconv.Itoa(cfg.Metrics.Port)),
+		Handler:      mux,
+		ReadTimeout:  cfg.Timeout,
+		WriteTimeout: cfg.Timeout,
+	}
+}
+
+// newAdminServer creates the listener of the admin API.
+func newAdminServer(cfg kono.AdminConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
+		Handler:      handler,
+		ReadTimeout:  cfg.Timeout,
+		WriteTimeout: cfg.Timeout,
+	}
 }
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/starwalkn/kono"
+)
+
+func TestNewMetricsServer_BindsHost(t *testing.T) {
+	cfg := kono.Config{
+		ConfigVersion: "v1",
+		Name:          "test",
+		Version:       "1",
+		Server: kono.ServerConfig{
+			Port:    7805,
+			Metrics: kono.MetricsConfig{Enabled: true, Provider: "prometheus", Port: 9100},
+		},
+		Routes: []kono.RouteConfig{
+			{
+				Path:        "/users",
+				Method:      http.MethodGet,
+				Aggregation: kono.AggregationConfig{Strategy: "merge"},
+				Upstreams:   []kono.UpstreamConfig{{Method: http.MethodGet, Hosts: []string{"http://localhost:8081"}}},
+			},
+		},
+	}
+
+	if err := kono.ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if srv := newMetricsServer(cfg.Server, http.NotFoundHandler()); srv.Addr != "127.0.0.1:9100" {
+		t.Fatalf("expected metrics on the loopback address by default, got %q", srv.Addr)
+	}
+
+	cfg.Server.Metrics.Host = "0.0.0.0"
+
+	if srv := newMetricsServer(cfg.Server, http.NotFoundHandler()); srv.Addr != "0.0.0.0:9100" {
+		t.Fatalf("unexpected metrics address %q", srv.Addr)
+	}
+}
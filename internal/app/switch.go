@@ -0,0 +1,119 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono"
+)
+
+// retirePollInterval is how often a replaced router is checked for requests in flight.
+const retirePollInterval = 50 * time.Millisecond
+
+// routerSwitch serves requests with the current router. Routers of applied configs replace it without dropping
+// requests, a replaced router is closed once its requests are served or drainTimeout expires.
+type routerSwitch struct {
+	mu      sync.Mutex // serializes applies
+	current atomic.Pointer[activeRouter]
+
+	// base holds the router settings shared by all configs, e.g. metrics and the access log.
+	base         kono.RouterConfigSet
+	drainTimeout time.Duration
+	log          *zap.Logger
+
+	// closeRouter closes retired routers.
+	closeRouter func(r *kono.Router)
+}
+
+type activeRouter struct {
+	router   *kono.Router
+	inflight atomic.Int64
+}
+
+func newRouterSwitch(base kono.RouterConfigSet, cfg kono.Config, log *zap.Logger) *routerSwitch {
+	s := &routerSwitch{
+		base:         base,
+		drainTimeout: cfg.Server.Timeout,
+		log:          log,
+		closeRouter:  (*kono.Router).Close,
+	}
+
+	s.current.Store(&activeRouter{router: kono.NewRouter(s.configSet(cfg), log)})
+
+	return s
+}
+
+func (s *routerSwitch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	active := s.current.Load()
+
+	active.inflight.Add(1)
+	defer active.inflight.Add(-1)
+
+	active.router.ServeHTTP(w, r)
+}
+
+// Router returns the current router.
+func (s *routerSwitch) Router() *kono.Router {
+	return s.current.Load().router
+}
+
+// Apply builds a router of the config and replaces the current one. Settings needing a restart, e.g. the
+// listener port, are not applied.
+func (s *routerSwitch) Apply(cfg kono.Config) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	router, err := kono.BuildRouter(s.configSet(cfg), s.log)
+	if err != nil {
+		return fmt.Errorf("cannot build router: %w", err)
+	}
+
+	old := s.current.Swap(&activeRouter{router: router})
+	go s.retire(old)
+
+	s.log.Info("router replaced", zap.String("version", cfg.Version), zap.Int("routes", len(cfg.Routes)))
+
+	return nil
+}
+
+// Close closes the current router.
+func (s *routerSwitch) Close() {
+	s.current.Load().router.Close()
+}
+
+// retire closes the router once it has no requests in flight.
+func (s *routerSwitch) retire(old *activeRouter) {
+	deadline := time.Now().Add(s.drainTimeout)
+
+	for old.inflight.Load() > 0 && time.Now().Before(deadline) {
+		time.Sleep(retirePollInterval)
+	}
+
+	if n := old.inflight.Load(); n > 0 {
+		s.log.Warn("closing replaced router with requests in flight", zap.Int64("requests", n))
+	}
+
+	s.closeRouter(old.router)
+}
+
+func (s *routerSwitch) configSet(cfg kono.Config) kono.RouterConfigSet {
+	set := s.base
+
+	set.Version = cfg.Version
+	set.Routes = cfg.Routes
+	set.Middlewares = cfg.Middlewares
+	set.Features = cfg.Features
+	set.Metrics = cfg.Server.Metrics
+	set.TrustedProxies = cfg.Server.TrustedProxies
+	set.ClientIPHeader = cfg.Server.ClientIPHeader
+	set.Concurrency = cfg.Server.Concurrency
+	set.ForwardClientCert = cfg.Server.TLS.Enabled && cfg.Server.TLS.ClientAuth.ForwardHeaders
+	set.Redaction = cfg.Server.Redaction
+
+	return set
+}
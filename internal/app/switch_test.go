@@ -0,0 +1,196 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono"
+)
+
+func newSwitchTestConfig(t *testing.T, upstreamURL, path string) kono.Config {
+	t.Helper()
+
+	cfg := kono.Config{
+		ConfigVersion: "v1",
+		Name:          "test",
+		Version:       "1",
+		Server:        kono.ServerConfig{Port: 8080, Timeout: time.Second},
+		Routes: []kono.RouteConfig{
+			{
+				Path:   path,
+				Method: http.MethodGet,
+				Upstreams: []kono.UpstreamConfig{
+					{Name: "users", Hosts: []string{upstreamURL}, Method: http.MethodGet, Timeout: time.Second},
+				},
+				Aggregation: kono.AggregationConfig{Strategy: "merge"},
+			},
+		},
+	}
+
+	if err := kono.ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg
+}
+
+// newTestSwitch returns a router switch reporting closed routers on the returned channel.
+func newTestSwitch(t *testing.T, cfg kono.Config) (*routerSwitch, <-chan *kono.Router) {
+	t.Helper()
+
+	closed := make(chan *kono.Router, 1)
+
+	s := newRouterSwitch(kono.RouterConfigSet{}, cfg, zap.NewNop())
+	s.closeRouter = func(r *kono.Router) {
+		r.Close()
+		closed <- r
+	}
+
+	t.Cleanup(s.Close)
+
+	return s, closed
+}
+
+func TestRouterSwitch_ApplyDuringRequest(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var calls atomic.Int64
+
+	// The first call is in flight until released.
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	defer upstream.Close()
+
+	s, closed := newTestSwitch(t, newSwitchTestConfig(t, upstream.URL, "/users"))
+	old := s.Router()
+
+	done := make(chan int)
+
+	go func() {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+		done <- rec.Code
+	}()
+
+	<-started
+
+	if err := s.Apply(newSwitchTestConfig(t, upstream.URL, "/customers")); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Router() == old {
+		t.Fatal("expected router to be replaced")
+	}
+
+	// New requests are served by the new router while the old one drains.
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/customers", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected new route to be served, got %d", rec.Code)
+	}
+
+	select {
+	case <-closed:
+		t.Fatal("expected old router to stay open while requests are in flight")
+	case <-time.After(3 * retirePollInterval):
+	}
+
+	close(release)
+
+	if code := <-done; code != http.StatusOK {
+		t.Fatalf("expected request in flight to complete, got %d", code)
+	}
+
+	select {
+	case r := <-closed:
+		if r != old {
+			t.Fatal("expected old router to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected old router to be closed after drain")
+	}
+}
+
+func TestRouterSwitch_DrainTimeout(t *testing.T) {
+	// The upstream outlasts the drain timeout, until the upstream timeout cancels the request.
+	upstream := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+
+	cfg := newSwitchTestConfig(t, upstream.URL, "/users")
+
+	s, closed := newTestSwitch(t, cfg)
+	s.drainTimeout = 2 * retirePollInterval
+
+	old := s.current.Load()
+
+	go s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	// Wait for the request to be in flight.
+	for old.inflight.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := s.Apply(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case r := <-closed:
+		if r != old.router || old.inflight.Load() == 0 {
+			t.Fatal("expected old router to be closed with the request in flight")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("expected old router to be closed after the drain timeout")
+	}
+}
+
+func TestRouterSwitch_FailedApplyKeepsRouter(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	cfg := newSwitchTestConfig(t, upstream.URL, "/users")
+
+	s, closed := newTestSwitch(t, cfg)
+	current := s.Router()
+
+	invalid := cfg
+	invalid.Server.TrustedProxies = []string{"not-a-cidr"}
+
+	if err := s.Apply(invalid); err == nil {
+		t.Fatal("expected invalid config to be rejected")
+	}
+
+	if s.Router() != current {
+		t.Fatal("expected current router to be kept")
+	}
+
+	select {
+	case <-closed:
+		t.Fatal("expected current router to stay open")
+	default:
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected current router to serve requests, got %d", rec.Code)
+	}
+}
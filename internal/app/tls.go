@@ -0,0 +1,101 @@
+package app
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono"
+	"github.com/starwalkn/kono/internal/certs"
+)
+
+// newTLSConfig builds the listener TLS configuration backed by a reloadable certificate store.
+func newTLSConfig(cfg kono.ServerTLSConfig, log *zap.Logger) (*tls.Config, *certs.Store, error) {
+	pairs := make([]certs.Pair, 0, len(cfg.Certificates)+1)
+
+	if cfg.CertFile != "" {
+		pairs = append(pairs, certs.Pair{CertFile: cfg.CertFile, KeyFile: cfg.KeyFile})
+	}
+
+	for _, c := range cfg.Certificates {
+		pairs = append(pairs, certs.Pair{CertFile: c.CertFile, KeyFile: c.KeyFile})
+	}
+
+	store, err := certs.NewStore(pairs, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	minVersion, err := certs.ParseVersion(cfg.MinVersion, tls.VersionTLS12)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cipherSuites, err := certs.ParseCipherSuites(cfg.CipherSuites)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nextProtos := []string{"h2", "http/1.1"}
+	if cfg.DisableHTTP2 {
+		nextProtos = []string{"http/1.1"}
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		CipherSuites:   cipherSuites,
+		NextProtos:     nextProtos,
+		GetCertificate: store.GetCertificate,
+	}
+
+	switch cfg.ClientAuth.Mode {
+	case "optional", "require":
+		clientCAs, cerr := certs.LoadCertPool(cfg.ClientAuth.CAFile)
+		if cerr != nil {
+			return nil, nil, cerr
+		}
+
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+		if cfg.ClientAuth.Mode == "require" {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return tlsConfig, store, nil
+}
+
+// newProtocols returns listener protocols, HTTP/2 is served over TLS only.
+func newProtocols(disableHTTP2 bool) *http.Protocols {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(!disableHTTP2)
+
+	return protocols
+}
+
+// newRedirectServer returns a plain HTTP server that redirects every request to the HTTPS listener.
+func newRedirectServer(cfg kono.ServerConfig) *http.Server {
+	return &http.Server{
+		Addr: fmt.Sprintf(":%d", cfg.TLS.RedirectPort),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host := r.Host
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+
+			if cfg.Port != 443 { //nolint:mnd // default HTTPS port is omitted from URLs
+				host = net.JoinHostPort(host, strconv.Itoa(cfg.Port))
+			}
+
+			http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+		}),
+		ReadTimeout:  cfg.Timeout,
+		WriteTimeout: cfg.Timeout,
+	}
+}
@@ -0,0 +1,201 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Pair is a certificate and private key file pair in PEM format.
+type Pair struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Store holds server certificates and selects one by SNI. Certificates are reloaded when their files change.
+type Store struct {
+	pairs []Pair
+	log   *zap.Logger
+
+	mu       sync.RWMutex
+	certs    []tls.Certificate
+	modTimes map[string]time.Time
+}
+
+func NewStore(pairs []Pair, log *zap.Logger) (*Store, error) {
+	if len(pairs) == 0 {
+		return nil, errors.New("no certificates configured")
+	}
+
+	s := &Store{
+		pairs: pairs,
+		log:   log,
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate. The first certificate supporting the client hello
+// is returned, the first configured certificate is the default one.
+func (s *Store) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := range s.certs {
+		if hello.SupportsCertificate(&s.certs[i]) == nil {
+			return &s.certs[i], nil
+		}
+	}
+
+	return &s.certs[0], nil
+}
+
+// Watch reloads certificates every interval if any of the files changed, until ctx is done.
+// Failed reloads keep the previous certificates.
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !s.changed() {
+				continue
+			}
+
+			if err := s.load(); err != nil {
+				s.log.Error("cannot reload certificates, keeping previous ones", zap.Error(err))
+				continue
+			}
+
+			s.log.Info("certificates reloaded")
+		}
+	}
+}
+
+func (s *Store) load() error {
+	certs := make([]tls.Certificate, 0, len(s.pairs))
+	modTimes := make(map[string]time.Time, 2*len(s.pairs)) //nolint:mnd // cert and key per pair
+
+	for _, pair := range s.pairs {
+		cert, err := tls.LoadX509KeyPair(pair.CertFile, pair.KeyFile)
+		if err != nil {
+			return fmt.Errorf("cannot load certificate %s: %w", pair.CertFile, err)
+		}
+
+		certs = append(certs, cert)
+
+		for _, file := range []string{pair.CertFile, pair.KeyFile} {
+			modTimes[file] = modTime(file)
+		}
+	}
+
+	s.mu.Lock()
+	s.certs = certs
+	s.modTimes = modTimes
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Store) changed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for file, loaded := range s.modTimes {
+		if !modTime(file).Equal(loaded) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func modTime(file string) time.Time {
+	info, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
+
+// LoadCertPool loads PEM encoded CA certificates from the files into a new pool.
+func LoadCertPool(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA bundle: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", file)
+		}
+	}
+
+	return pool, nil
+}
+
+// ParseVersion parses TLS versions in "1.2" form. An empty string returns the fallback.
+func ParseVersion(version string, fallback uint16) (uint16, error) {
+	switch version {
+	case "":
+		return fallback, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unknown TLS version: %s", version)
+	}
+}
+
+// ParseCipherSuites maps IANA cipher suite names (e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256) to their IDs.
+// Insecure suites are not accepted.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+
+	for _, name := range names {
+		id, ok := known[strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite: %s", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,150 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func writeCert(t *testing.T, dir, name string, serial int64) Pair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair := Pair{
+		CertFile: filepath.Join(dir, name+".crt"),
+		KeyFile:  filepath.Join(dir, name+".key"),
+	}
+
+	if err = os.WriteFile(pair.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(pair.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return pair
+}
+
+func leafSerial(t *testing.T, cert *tls.Certificate) int64 {
+	t.Helper()
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return leaf.SerialNumber.Int64()
+}
+
+func TestStore_SelectsBySNI(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewStore([]Pair{
+		writeCert(t, dir, "a.example.com", 1),
+		writeCert(t, dir, "b.example.com", 2),
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hello := func(name string) *tls.ClientHelloInfo {
+		return &tls.ClientHelloInfo{
+			ServerName:        name,
+			SupportedVersions: []uint16{tls.VersionTLS13},
+			SignatureSchemes:  []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		}
+	}
+
+	tests := map[string]int64{"a.example.com": 1, "b.example.com": 2, "unknown.example.com": 1}
+
+	for name, want := range tests {
+		cert, err := store.GetCertificate(hello(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := leafSerial(t, cert); got != want {
+			t.Errorf("%s: expected serial %d, got %d", name, want, got)
+		}
+	}
+}
+
+func TestStore_ReloadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	pair := writeCert(t, dir, "a.example.com", 1)
+
+	store, err := NewStore([]Pair{pair}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make sure the modification time differs on filesystems with coarse timestamps.
+	future := time.Now().Add(time.Minute)
+
+	writeCert(t, dir, "a.example.com", 2)
+
+	for _, file := range []string{pair.CertFile, pair.KeyFile} {
+		if err = os.Chtimes(file, future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !store.changed() {
+		t.Fatal("expected store to detect changed files")
+	}
+
+	if err = store.load(); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ := store.GetCertificate(&tls.ClientHelloInfo{ServerName: "a.example.com"})
+	if got := leafSerial(t, cert); got != 2 {
+		t.Fatalf("expected reloaded certificate, got serial %d", got)
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	if _, err := ParseCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"}); err == nil {
+		t.Fatal("expected error for insecure cipher suite")
+	}
+}
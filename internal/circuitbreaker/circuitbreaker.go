@@ -13,6 +13,17 @@ const (
 	HalfOpen
 )
 
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
 type CircuitBreaker struct {
 	mu            sync.Mutex
 	state         State
@@ -22,6 +33,11 @@ type CircuitBreaker struct {
 	threshold     int
 	resetTimeout  time.Duration
 	halfOpenTrial bool
+
+	// forced holds the state until Release, e.g. set by an operator.
+	forced bool
+
+	onStateChange func(from, to State)
 }
 
 func New(threshold int, resetTimeout time.Duration) *CircuitBreaker {
@@ -32,14 +48,65 @@ func New(threshold int, resetTimeout time.Duration) *CircuitBreaker {
 	}
 }
 
+// OnStateChange sets a function called on every state transition. It is called with the breaker locked,
+// so it must be fast and must not use the breaker.
+func (b *CircuitBreaker) OnStateChange(fn func(from, to State)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.onStateChange = fn
+}
+
+func (b *CircuitBreaker) setState(to State) {
+	from := b.state
+	b.state = to
+
+	if from != to && b.onStateChange != nil {
+		b.onStateChange(from, to)
+	}
+}
+
+// Force holds the breaker in the given state until Release. A forced open breaker denies all requests,
+// a forced closed breaker allows all requests and ignores failures. Half-open is not a valid forced state.
+func (b *CircuitBreaker) Force(state State) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.forced = true
+	b.failures = 0
+	b.setState(state)
+}
+
+// Release returns a forced breaker to normal operation in the closed state.
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.forced = false
+	b.failures = 0
+	b.setState(Closed)
+}
+
+// Forced reports whether the breaker state is forced.
+func (b *CircuitBreaker) Forced() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.forced
+}
+
 func (b *CircuitBreaker) Allow() bool {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if b.forced {
+		return b.state != Open
+	}
+
 	switch b.state {
 	case Open:
 		if time.Since(b.lastFailureAt) >= b.resetTimeout {
-			b.state = HalfOpen
+			b.setState(HalfOpen)
 			b.halfOpenTrial = false
 
 			return true
@@ -63,17 +130,21 @@ func (b *CircuitBreaker) OnFailure() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if b.forced {
+		return
+	}
+
 	b.lastFailureAt = time.Now()
 
 	switch b.state {
 	case HalfOpen:
-		b.state = Open
+		b.setState(Open)
 		b.failures = b.threshold
 	case Closed:
 		b.failures++
 
 		if b.failures >= b.threshold {
-			b.state = Open
+			b.setState(Open)
 		}
 	}
 }
@@ -82,9 +153,13 @@ func (b *CircuitBreaker) OnSuccess() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if b.forced {
+		return
+	}
+
 	switch b.state {
 	case HalfOpen:
-		b.state = Closed
+		b.setState(Closed)
 		b.failures = 0
 	case Closed:
 		b.failures = 0
@@ -0,0 +1,73 @@
+package concurrency
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	defaultBackoffRatio = 0.9
+	defaultSmoothing    = 0.2
+	longWindow          = 600 // Samples in the long term RTT average.
+	minGradient         = 0.5
+)
+
+// AIMD increases the limit by one while requests succeed and the limit is utilized,
+// and multiplies it by BackoffRatio on drops. Requests slower than LatencyThreshold count as drops.
+type AIMD struct {
+	BackoffRatio     float64
+	LatencyThreshold time.Duration
+}
+
+func (a *AIMD) Update(limit float64, inFlight int, rtt time.Duration, dropped bool) float64 {
+	if dropped || (a.LatencyThreshold > 0 && rtt > a.LatencyThreshold) {
+		ratio := a.BackoffRatio
+		if ratio <= 0 || ratio >= 1 {
+			ratio = defaultBackoffRatio
+		}
+
+		return limit * ratio
+	}
+
+	// Grow only when at least half of the limit is in use, otherwise the limit is not the bottleneck.
+	if float64(inFlight)*2 >= limit {
+		return limit + 1
+	}
+
+	return limit
+}
+
+// Gradient compares the short term RTT with the long term RTT average. When latency grows because of queueing
+// in upstreams, the gradient falls below one and the limit shrinks; otherwise the limit grows by sqrt(limit).
+type Gradient struct {
+	Smoothing float64
+
+	longRTT float64
+}
+
+func (g *Gradient) Update(limit float64, _ int, rtt time.Duration, dropped bool) float64 {
+	sample := float64(rtt)
+	if sample <= 0 {
+		return limit
+	}
+
+	if g.longRTT == 0 {
+		g.longRTT = sample
+	} else {
+		g.longRTT += (sample - g.longRTT) / longWindow
+	}
+
+	gradient := math.Max(minGradient, math.Min(1, g.longRTT/sample))
+	if dropped {
+		gradient = minGradient
+	}
+
+	newLimit := limit*gradient + math.Sqrt(limit)
+
+	smoothing := g.Smoothing
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = defaultSmoothing
+	}
+
+	return limit*(1-smoothing) + newLimit*smoothing
+}
@@ -0,0 +1,186 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLimitExceeded is returned when the limit is reached and the wait queue is full.
+	ErrLimitExceeded = errors.New("concurrency limit exceeded")
+	// ErrQueueTimeout is returned when a request waited in the queue longer than the queue timeout.
+	ErrQueueTimeout = errors.New("concurrency queue timeout")
+)
+
+// Algorithm adjusts the limit based on observed request latencies and drops.
+// Implementations are called under the limiter lock.
+type Algorithm interface {
+	Update(limit float64, inFlight int, rtt time.Duration, dropped bool) float64
+}
+
+type Options struct {
+	Limit        int
+	MinLimit     int
+	MaxLimit     int
+	QueueSize    int
+	QueueTimeout time.Duration
+	Algorithm    Algorithm // Nil means a static limit.
+}
+
+// Limiter limits the number of in-flight requests. Requests above the limit wait in a bounded FIFO queue.
+type Limiter struct {
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	waiters  []chan struct{}
+
+	minLimit     float64
+	maxLimit     float64
+	queueSize    int
+	queueTimeout time.Duration
+	algorithm    Algorithm
+}
+
+// Token is a granted in-flight slot. It must be released exactly once.
+type Token struct {
+	limiter *Limiter
+	start   time.Time
+	once    sync.Once
+}
+
+func New(opts Options) *Limiter {
+	minLimit := max(opts.MinLimit, 1)
+	maxLimit := max(opts.MaxLimit, opts.Limit)
+
+	return &Limiter{
+		limit:        float64(opts.Limit),
+		minLimit:     float64(minLimit),
+		maxLimit:     float64(maxLimit),
+		queueSize:    opts.QueueSize,
+		queueTimeout: opts.QueueTimeout,
+		algorithm:    opts.Algorithm,
+	}
+}
+
+// Acquire takes an in-flight slot, waiting in the queue if the limit is reached.
+func (l *Limiter) Acquire(ctx context.Context) (*Token, error) {
+	l.mu.Lock()
+
+	if l.inFlight < l.currentLimit() && len(l.waiters) == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+
+		return l.newToken(), nil
+	}
+
+	if len(l.waiters) >= l.queueSize {
+		l.mu.Unlock()
+
+		return nil, ErrLimitExceeded
+	}
+
+	ready := make(chan struct{}, 1)
+	l.waiters = append(l.waiters, ready)
+	l.mu.Unlock()
+
+	var timeout <-chan time.Time
+
+	if l.queueTimeout > 0 {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	select {
+	case <-ready:
+		return l.newToken(), nil
+	case <-timeout:
+		return l.abandon(ready, ErrQueueTimeout)
+	case <-ctx.Done():
+		return l.abandon(ready, ctx.Err())
+	}
+}
+
+// abandon removes the waiter from the queue. If the waiter has been granted a slot concurrently, the slot is kept.
+func (l *Limiter) abandon(ready chan struct{}, err error) (*Token, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	idx := slices.Index(l.waiters, ready)
+	if idx < 0 {
+		return l.newToken(), nil
+	}
+
+	l.waiters = slices.Delete(l.waiters, idx, idx+1)
+
+	return nil, err
+}
+
+// Release frees the slot. dropped reports that the request failed because of overload (e.g. an upstream timeout),
+// which makes adaptive algorithms decrease the limit.
+func (t *Token) Release(dropped bool) {
+	t.once.Do(func() {
+		t.limiter.release(time.Since(t.start), dropped)
+	})
+}
+
+func (l *Limiter) release(rtt time.Duration, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.algorithm != nil {
+		limit := l.algorithm.Update(l.limit, l.inFlight, rtt, dropped)
+		l.limit = math.Min(math.Max(limit, l.minLimit), l.maxLimit)
+	}
+
+	l.inFlight--
+
+	// Hand the freed slots over to the waiters.
+	for len(l.waiters) > 0 && l.inFlight < l.currentLimit() {
+		ready := l.waiters[0]
+		l.waiters = l.waiters[1:]
+		l.inFlight++
+
+		ready <- struct{}{}
+	}
+}
+
+// Limit returns the current limit.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.currentLimit()
+}
+
+// InFlight returns the number of requests holding a slot.
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.inFlight
+}
+
+// Queued returns the number of requests waiting for a slot.
+func (l *Limiter) Queued() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.waiters)
+}
+
+func (l *Limiter) currentLimit() int {
+	return int(l.limit)
+}
+
+func (l *Limiter) newToken() *Token {
+	return &Token{
+		limiter: l,
+		start:   time.Now(),
+	}
+}
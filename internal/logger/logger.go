@@ -6,19 +6,25 @@ import (
 )
 
 func New(debug bool) *zap.Logger {
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
-
-	var lvl zapcore.Level
+	return NewWithLevel(Level(debug), debug)
+}
 
+// Level returns the level for the debug mode. It can be changed at runtime, e.g. by the admin API.
+func Level(debug bool) zap.AtomicLevel {
 	if debug {
-		lvl = zap.DebugLevel
-	} else {
-		lvl = zap.InfoLevel
+		return zap.NewAtomicLevelAt(zap.DebugLevel)
 	}
 
+	return zap.NewAtomicLevelAt(zap.InfoLevel)
+}
+
+// NewWithLevel creates a logger using the given level.
+func NewWithLevel(level zap.AtomicLevel, debug bool) *zap.Logger {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
+
 	config := zap.Config{
-		Level:            zap.NewAtomicLevelAt(lvl),
+		Level:            level,
 		Development:      debug,
 		Encoding:         "json",
 		EncoderConfig:    encoderConfig,
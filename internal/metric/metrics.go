@@ -1,6 +1,15 @@
 package metric
 
-import "time"
+import (
+	"net/http"
+	"time"
+)
+
+// Metrics providers.
+const (
+	ProviderPrometheus      = "prometheus"
+	ProviderVictoriaMetrics = "victoriametrics"
+)
 
 type FailReason string
 
@@ -10,15 +19,44 @@ const (
 	FailReasonNoMatchedRoute  FailReason = "no_matched_route"
 	FailReasonPolicyViolation FailReason = "policy_violation"
 	FailReasonBodyTooLarge    FailReason = "body_too_large"
+	FailReasonOverloaded      FailReason = "overloaded"
 	FailReasonUnknown         FailReason = "unknown"
 )
 
+// Bucket bounds of histograms other than latencies.
+var (
+	sizeBuckets  = []float64{100, 1 << 10, 10 << 10, 100 << 10, 1 << 20, 5 << 20}   // 100B - 5MB
+	stageBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1} // 0.1ms - 1s
+)
+
+// Metrics records gateway metrics. Route labels are always route templates (e.g. /users/{id})
+// and upstream labels are configured names, so the number of series is bounded by the configuration.
 type Metrics interface {
 	IncRequestsTotal()
 	UpdateRequestsDuration(route, method string, start time.Time)
-	IncResponsesTotal(route string, status int)
+	// IncResponsesTotal counts route responses. Variant names the canary variants that served the request, if any.
+	IncResponsesTotal(route string, status int, variant string)
 	IncRequestsInFlight()
 	DecRequestsInFlight()
 	IncFailedRequestsTotal(FailReason)
-	UpdateUpstreamLatency(route, method, upstream string, lat time.Duration)
+	UpdateUpstreamLatency(route, method, upstream, variant string, lat time.Duration)
+	IncWebSocketConnections(route string)
+	DecWebSocketConnections(route string)
+	// IncUpstreamResponsesTotal counts upstream responses. ErrorKind is empty for successful responses.
+	IncUpstreamResponsesTotal(route, upstream string, status int, errorKind string)
+	AddUpstreamRetries(route, upstream string, retries int)
+	// SetCircuitBreakerState sets the breaker state of an upstream: 0 closed, 1 open, 2 half-open.
+	SetCircuitBreakerState(route, upstream string, state int)
+	IncCircuitBreakerTransitions(route, upstream, from, to string)
+	// IncRateLimitRejections counts rejected requests. KeyType is the key types of the rule, e.g. "ip+header",
+	// or "global" for the gateway rate limiter.
+	IncRateLimitRejections(route, keyType string)
+	ObserveRequestSize(route string, size int)
+	ObserveResponseSize(route string, size int)
+	UpdatePluginDuration(route, plugin string, d time.Duration)
+	// UpdateMiddlewareDuration records the duration of a middleware itself, excluding the rest of the chain.
+	UpdateMiddlewareDuration(route, middleware string, d time.Duration)
+	IncPartialResponsesTotal(route string)
+	// Handler serves the metrics in the Prometheus text format.
+	Handler() http.Handler
 }
@@ -1,6 +1,7 @@
 package metric
 
 import (
+	"net/http"
 	"time"
 )
 
@@ -9,10 +10,23 @@ type nopMetrics struct{}
 func NewNop() Metrics {
 	return &nopMetrics{}
 }
-func (m *nopMetrics) IncRequestsTotal()                                     {}
-func (m *nopMetrics) UpdateRequestsDuration(_, _ string, _ time.Time)       {}
-func (m *nopMetrics) IncResponsesTotal(_ string, _ int)                     {}
-func (m *nopMetrics) IncRequestsInFlight()                                  {}
-func (m *nopMetrics) DecRequestsInFlight()                                  {}
-func (m *nopMetrics) IncFailedRequestsTotal(_ FailReason)                   {}
-func (m *nopMetrics) UpdateUpstreamLatency(_, _, _ string, _ time.Duration) {}
+func (m *nopMetrics) IncRequestsTotal()                                        {}
+func (m *nopMetrics) UpdateRequestsDuration(_, _ string, _ time.Time)          {}
+func (m *nopMetrics) IncResponsesTotal(_ string, _ int, _ string)              {}
+func (m *nopMetrics) IncRequestsInFlight()                                     {}
+func (m *nopMetrics) DecRequestsInFlight()                                     {}
+func (m *nopMetrics) IncFailedRequestsTotal(_ FailReason)                      {}
+func (m *nopMetrics) UpdateUpstreamLatency(_, _, _, _ string, _ time.Duration) {}
+func (m *nopMetrics) IncWebSocketConnections(_ string)                         {}
+func (m *nopMetrics) DecWebSocketConnections(_ string)                         {}
+func (m *nopMetrics) Handler() http.Handler                                    { return http.NotFoundHandler() }
+func (m *nopMetrics) IncUpstreamResponsesTotal(_, _ string, _ int, _ string)   {}
+func (m *nopMetrics) AddUpstreamRetries(_, _ string, _ int)                    {}
+func (m *nopMetrics) SetCircuitBreakerState(_, _ string, _ int)                {}
+func (m *nopMetrics) IncCircuitBreakerTransitions(_, _, _, _ string)           {}
+func (m *nopMetrics) IncRateLimitRejections(_, _ string)                       {}
+func (m *nopMetrics) ObserveRequestSize(_ string, _ int)                       {}
+func (m *nopMetrics) ObserveResponseSize(_ string, _ int)                      {}
+func (m *nopMetrics) UpdatePluginDuration(_, _ string, _ time.Duration)        {}
+func (m *nopMetrics) UpdateMiddlewareDuration(_, _ string, _ time.Duration)    {}
+func (m *nopMetrics) IncPartialResponsesTotal(_ string)                        {}
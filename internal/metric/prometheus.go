@@ -1,19 +1,36 @@
 package metric
 
 import (
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type prometheusMetrics struct {
+	registry *prometheus.Registry
+
 	RequestsTotal       prometheus.Counter
 	RequestsDuration    *prometheus.HistogramVec
 	ResponsesTotal      *prometheus.CounterVec
 	RequestsInFlight    prometheus.Gauge
 	FailedRequestsTotal *prometheus.CounterVec
 	UpstreamLatency     *prometheus.HistogramVec
+	WebSocketConns      *prometheus.GaugeVec
+
+	UpstreamResponsesTotal    *prometheus.CounterVec
+	UpstreamRetriesTotal      *prometheus.CounterVec
+	CircuitBreakerState       *prometheus.GaugeVec
+	CircuitBreakerTransitions *prometheus.CounterVec
+	RateLimitRejectionsTotal  *prometheus.CounterVec
+	RequestSize               *prometheus.HistogramVec
+	ResponseSize              *prometheus.HistogramVec
+	PluginDuration            *prometheus.HistogramVec
+	MiddlewareDuration        *prometheus.HistogramVec
+	PartialResponsesTotal     *prometheus.CounterVec
 }
 
 func NewPrometheus() Metrics {
@@ -42,7 +59,7 @@ func NewPrometheus() Metrics {
 				Name: "kono_responses_total",
 				Help: "Total number of responses by status code",
 			},
-			[]string{"route", "status"},
+			[]string{"route", "status", "variant"},
 		),
 		RequestsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "kono_requests_in_flight",
@@ -53,17 +70,113 @@ func NewPrometheus() Metrics {
 				Name: "kono_upstream_latency",
 				Help: "",
 			},
-			[]string{"route", "method", "upstream"},
+			[]string{"route", "method", "upstream", "variant"},
+		),
+		WebSocketConns: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "kono_websocket_connections",
+				Help: "Current number of open websocket connections",
+			},
+			[]string{"route"},
+		),
+		UpstreamResponsesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kono_upstream_responses_total",
+				Help: "Total number of upstream responses by status and error kind",
+			},
+			[]string{"route", "upstream", "status", "error"},
+		),
+		UpstreamRetriesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kono_upstream_retries_total",
+				Help: "Total number of upstream retry attempts",
+			},
+			[]string{"route", "upstream"},
+		),
+		CircuitBreakerState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "kono_circuit_breaker_state",
+				Help: "Circuit breaker state of upstreams: 0 closed, 1 open, 2 half-open",
+			},
+			[]string{"route", "upstream"},
+		),
+		CircuitBreakerTransitions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kono_circuit_breaker_transitions_total",
+				Help: "Total number of circuit breaker state transitions",
+			},
+			[]string{"route", "upstream", "from", "to"},
+		),
+		RateLimitRejectionsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kono_ratelimit_rejections_total",
+				Help: "Total number of requests rejected by rate limits by key type",
+			},
+			[]string{"route", "key"},
+		),
+		RequestSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kono_request_size_bytes",
+				Help:    "Request body size in bytes",
+				Buckets: sizeBuckets,
+			},
+			[]string{"route"},
+		),
+		ResponseSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kono_response_size_bytes",
+				Help:    "Response body size in bytes",
+				Buckets: sizeBuckets,
+			},
+			[]string{"route"},
+		),
+		PluginDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kono_plugin_duration_seconds",
+				Help:    "Plugin execution duration in seconds",
+				Buckets: stageBuckets,
+			},
+			[]string{"route", "plugin"},
+		),
+		MiddlewareDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "kono_middleware_duration_seconds",
+				Help:    "Middleware duration in seconds, excluding the rest of the chain",
+				Buckets: stageBuckets,
+			},
+			[]string{"route", "middleware"},
+		),
+		PartialResponsesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "kono_partial_responses_total",
+				Help: "Total number of responses aggregated from partial results",
+			},
+			[]string{"route"},
 		),
 	}
 
-	prometheus.MustRegister(
+	// Every instance has an own registry, so routers can be created more than once (e.g. on reload).
+	m.registry = prometheus.NewRegistry()
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 		m.RequestsTotal,
 		m.RequestsDuration,
 		m.ResponsesTotal,
 		m.RequestsInFlight,
 		m.FailedRequestsTotal,
 		m.UpstreamLatency,
+		m.WebSocketConns,
+		m.UpstreamResponsesTotal,
+		m.UpstreamRetriesTotal,
+		m.CircuitBreakerState,
+		m.CircuitBreakerTransitions,
+		m.RateLimitRejectionsTotal,
+		m.RequestSize,
+		m.ResponseSize,
+		m.PluginDuration,
+		m.MiddlewareDuration,
+		m.PartialResponsesTotal,
 	)
 
 	return m
@@ -77,8 +190,8 @@ func (m *prometheusMetrics) UpdateRequestsDuration(route, method string, start t
 	m.RequestsDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
 }
 
-func (m *prometheusMetrics) IncResponsesTotal(route string, status int) {
-	m.ResponsesTotal.WithLabelValues(route, strconv.Itoa(status)).Inc()
+func (m *prometheusMetrics) IncResponsesTotal(route string, status int, variant string) {
+	m.ResponsesTotal.WithLabelValues(route, strconv.Itoa(status), variant).Inc()
 }
 
 func (m *prometheusMetrics) IncRequestsInFlight() {
@@ -93,6 +206,58 @@ func (m *prometheusMetrics) IncFailedRequestsTotal(reason FailReason) {
 	m.FailedRequestsTotal.WithLabelValues(string(reason)).Inc()
 }
 
-func (m *prometheusMetrics) UpdateUpstreamLatency(route, method, upstream string, lat time.Duration) {
-	m.UpstreamLatency.WithLabelValues(route, method, upstream).Observe(lat.Seconds())
+func (m *prometheusMetrics) UpdateUpstreamLatency(route, method, upstream, variant string, lat time.Duration) {
+	m.UpstreamLatency.WithLabelValues(route, method, upstream, variant).Observe(lat.Seconds())
+}
+
+func (m *prometheusMetrics) IncWebSocketConnections(route string) {
+	m.WebSocketConns.WithLabelValues(route).Inc()
+}
+
+func (m *prometheusMetrics) DecWebSocketConnections(route string) {
+	m.WebSocketConns.WithLabelValues(route).Dec()
+}
+
+func (m *prometheusMetrics) IncUpstreamResponsesTotal(route, upstream string, status int, errorKind string) {
+	m.UpstreamResponsesTotal.WithLabelValues(route, upstream, strconv.Itoa(status), errorKind).Inc()
+}
+
+func (m *prometheusMetrics) AddUpstreamRetries(route, upstream string, retries int) {
+	m.UpstreamRetriesTotal.WithLabelValues(route, upstream).Add(float64(retries))
+}
+
+func (m *prometheusMetrics) SetCircuitBreakerState(route, upstream string, state int) {
+	m.CircuitBreakerState.WithLabelValues(route, upstream).Set(float64(state))
+}
+
+func (m *prometheusMetrics) IncCircuitBreakerTransitions(route, upstream, from, to string) {
+	m.CircuitBreakerTransitions.WithLabelValues(route, upstream, from, to).Inc()
+}
+
+func (m *prometheusMetrics) IncRateLimitRejections(route, keyType string) {
+	m.RateLimitRejectionsTotal.WithLabelValues(route, keyType).Inc()
+}
+
+func (m *prometheusMetrics) ObserveRequestSize(route string, size int) {
+	m.RequestSize.WithLabelValues(route).Observe(float64(size))
+}
+
+func (m *prometheusMetrics) ObserveResponseSize(route string, size int) {
+	m.ResponseSize.WithLabelValues(route).Observe(float64(size))
+}
+
+func (m *prometheusMetrics) UpdatePluginDuration(route, plugin string, d time.Duration) {
+	m.PluginDuration.WithLabelValues(route, plugin).Observe(d.Seconds())
+}
+
+func (m *prometheusMetrics) UpdateMiddlewareDuration(route, middleware string, d time.Duration) {
+	m.MiddlewareDuration.WithLabelValues(route, middleware).Observe(d.Seconds())
+}
+
+func (m *prometheusMetrics) IncPartialResponsesTotal(route string) {
+	m.PartialResponsesTotal.WithLabelValues(route).Inc()
+}
+
+func (m *prometheusMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
 }
@@ -0,0 +1,159 @@
+package metric
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// VictoriaMetrics implements Metrics on github.com/VictoriaMetrics/metrics. Metrics are kept in an own set,
+// so several instances (e.g. in tests) do not share series.
+type VictoriaMetrics struct {
+	set *metrics.Set
+
+	requestsTotal    *metrics.Counter
+	requestsInFlight *metrics.Gauge
+}
+
+func NewVictoriaMetrics() *VictoriaMetrics {
+	set := metrics.NewSet()
+
+	return &VictoriaMetrics{
+		set:              set,
+		requestsTotal:    set.NewCounter("kono_requests_total"),
+		requestsInFlight: set.NewGauge("kono_requests_in_flight", nil),
+	}
+}
+
+// StartPush periodically pushes the metrics in the Prometheus text format to pushURL,
+// e.g. http://victoriametrics:8428/api/v1/import/prometheus, until ctx is canceled.
+func (m *VictoriaMetrics) StartPush(ctx context.Context, pushURL string, interval time.Duration) error {
+	if err := m.set.InitPushWithOptions(ctx, pushURL, interval, nil); err != nil {
+		return fmt.Errorf("cannot init metrics push: %w", err)
+	}
+
+	return nil
+}
+
+// Handler serves the metrics together with Go runtime and process metrics.
+func (m *VictoriaMetrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		m.set.WritePrometheus(w)
+		metrics.WriteProcessMetrics(w)
+	})
+}
+
+func (m *VictoriaMetrics) IncRequestsTotal() {
+	m.requestsTotal.Inc()
+}
+
+func (m *VictoriaMetrics) UpdateRequestsDuration(route, method string, start time.Time) {
+	m.set.GetOrCreatePrometheusHistogram(series("kono_requests_duration_seconds", "route", route, "method", method)).UpdateDuration(start)
+}
+
+func (m *VictoriaMetrics) IncResponsesTotal(route string, status int, variant string) {
+	m.set.GetOrCreateCounter(series("kono_responses_total", "route", route, "status", strconv.Itoa(status), "variant", variant)).Inc()
+}
+
+func (m *VictoriaMetrics) IncRequestsInFlight() {
+	m.requestsInFlight.Inc()
+}
+
+func (m *VictoriaMetrics) DecRequestsInFlight() {
+	m.requestsInFlight.Dec()
+}
+
+func (m *VictoriaMetrics) IncFailedRequestsTotal(reason FailReason) {
+	m.set.GetOrCreateCounter(series("kono_failed_requests_total", "reason", string(reason))).Inc()
+}
+
+func (m *VictoriaMetrics) UpdateUpstreamLatency(route, method, upstream, variant string, lat time.Duration) {
+	m.set.GetOrCreatePrometheusHistogram(
+		series("kono_upstream_latency", "route", route, "method", method, "upstream", upstream, "variant", variant),
+	).Update(lat.Seconds())
+}
+
+func (m *VictoriaMetrics) IncWebSocketConnections(route string) {
+	m.set.GetOrCreateGauge(series("kono_websocket_connections", "route", route), nil).Inc()
+}
+
+func (m *VictoriaMetrics) DecWebSocketConnections(route string) {
+	m.set.GetOrCreateGauge(series("kono_websocket_connections", "route", route), nil).Dec()
+}
+
+func (m *VictoriaMetrics) IncUpstreamResponsesTotal(route, upstream string, status int, errorKind string) {
+	m.set.GetOrCreateCounter(
+		series("kono_upstream_responses_total", "route", route, "upstream", upstream, "status", strconv.Itoa(status), "error", errorKind),
+	).Inc()
+}
+
+func (m *VictoriaMetrics) AddUpstreamRetries(route, upstream string, retries int) {
+	m.set.GetOrCreateCounter(series("kono_upstream_retries_total", "route", route, "upstream", upstream)).Add(retries)
+}
+
+func (m *VictoriaMetrics) SetCircuitBreakerState(route, upstream string, state int) {
+	m.set.GetOrCreateGauge(series("kono_circuit_breaker_state", "route", route, "upstream", upstream), nil).Set(float64(state))
+}
+
+func (m *VictoriaMetrics) IncCircuitBreakerTransitions(route, upstream, from, to string) {
+	m.set.GetOrCreateCounter(
+		series("kono_circuit_breaker_transitions_total", "route", route, "upstream", upstream, "from", from, "to", to),
+	).Inc()
+}
+
+func (m *VictoriaMetrics) IncRateLimitRejections(route, keyType string) {
+	m.set.GetOrCreateCounter(series("kono_ratelimit_rejections_total", "route", route, "key", keyType)).Inc()
+}
+
+func (m *VictoriaMetrics) ObserveRequestSize(route string, size int) {
+	m.set.GetOrCreatePrometheusHistogramExt(series("kono_request_size_bytes", "route", route), sizeBuckets).Update(float64(size))
+}
+
+func (m *VictoriaMetrics) ObserveResponseSize(route string, size int) {
+	m.set.GetOrCreatePrometheusHistogramExt(series("kono_response_size_bytes", "route", route), sizeBuckets).Update(float64(size))
+}
+
+func (m *VictoriaMetrics) UpdatePluginDuration(route, plugin string, d time.Duration) {
+	m.set.GetOrCreatePrometheusHistogramExt(
+		series("kono_plugin_duration_seconds", "route", route, "plugin", plugin), stageBuckets,
+	).Update(d.Seconds())
+}
+
+func (m *VictoriaMetrics) UpdateMiddlewareDuration(route, middleware string, d time.Duration) {
+	m.set.GetOrCreatePrometheusHistogramExt(
+		series("kono_middleware_duration_seconds", "route", route, "middleware", middleware), stageBuckets,
+	).Update(d.Seconds())
+}
+
+func (m *VictoriaMetrics) IncPartialResponsesTotal(route string) {
+	m.set.GetOrCreateCounter(series("kono_partial_responses_total", "route", route)).Inc()
+}
+
+// series returns the metric name with labels, e.g. kono_responses_total{route="/users",status="200"}.
+// Labels are passed as name/value pairs.
+func series(name string, labels ...string) string {
+	b := make([]byte, 0, len(name)+32*len(labels)/2) //nolint:mnd // rough label size
+
+	b = append(b, name...)
+	b = append(b, '{')
+
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b = append(b, ',')
+		}
+
+		b = append(b, labels[i]...)
+		b = append(b, '=')
+		b = strconv.AppendQuote(b, labels[i+1])
+	}
+
+	b = append(b, '}')
+
+	return string(b)
+}
@@ -1,6 +1,8 @@
 package ratelimit
 
 import (
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
@@ -27,13 +29,21 @@ type RateLimit struct {
 }
 
 func New(cfg map[string]interface{}) *RateLimit {
-	window, err := time.ParseDuration(cfg["window"].(string))
-	if err != nil {
-		window = defaultWindow
+	window := defaultWindow
+
+	if raw, ok := cfg["window"].(string); ok {
+		if parsed, err := time.ParseDuration(raw); err == nil {
+			window = parsed
+		}
 	}
 
+	return NewWithLimit(intFrom(cfg, "limit", defaultLimit), window)
+}
+
+// NewWithLimit creates a fixed window rate limiter with explicit limit and window.
+func NewWithLimit(limit int, window time.Duration) *RateLimit {
 	return &RateLimit{
-		limit:   intFrom(cfg, "limit", defaultLimit),
+		limit:   limit,
 		window:  window,
 		mu:      sync.Mutex{},
 		buckets: make(map[string]*entry),
@@ -76,6 +86,12 @@ func (rl *RateLimit) Stop() error {
 }
 
 func (rl *RateLimit) Allow(key string) bool {
+	return rl.AllowLimit(key, rl.limit, rl.window)
+}
+
+// AllowLimit is like Allow but uses the given limit and window instead of the configured ones.
+// It allows a single store to hold buckets with different limits, e.g. per consumer tier.
+func (rl *RateLimit) AllowLimit(key string, limit int, window time.Duration) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
@@ -84,7 +100,7 @@ func (rl *RateLimit) Allow(key string) bool {
 	ent, ok := rl.buckets[key]
 	if !ok || now.After(ent.resetAt) {
 		// New window
-		reset := now.Add(rl.window)
+		reset := now.Add(window)
 
 		rl.buckets[key] = &entry{
 			count:   1,
@@ -94,7 +110,7 @@ func (rl *RateLimit) Allow(key string) bool {
 		return true
 	}
 
-	if ent.count < rl.limit {
+	if ent.count < limit {
 		ent.count++
 		return true
 	}
@@ -102,6 +118,56 @@ func (rl *RateLimit) Allow(key string) bool {
 	return false
 }
 
+// Bucket is the state of a rate limit key in the current window.
+type Bucket struct {
+	Key     string    `json:"key"`
+	Count   int       `json:"count"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// Limit returns the configured limit and window.
+func (rl *RateLimit) Limit() (int, time.Duration) {
+	return rl.limit, rl.window
+}
+
+// Buckets returns the buckets of the current windows, the fullest first.
+func (rl *RateLimit) Buckets() []Bucket {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	buckets := make([]Bucket, 0, len(rl.buckets))
+
+	for key, ent := range rl.buckets {
+		if now.After(ent.resetAt) {
+			continue
+		}
+
+		buckets = append(buckets, Bucket{Key: key, Count: ent.count, ResetAt: ent.resetAt})
+	}
+
+	slices.SortFunc(buckets, func(a, b Bucket) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return buckets
+}
+
+// Reset removes the bucket of the key, so its next request starts a new window. It reports whether the key existed.
+func (rl *RateLimit) Reset(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	_, ok := rl.buckets[key]
+	delete(rl.buckets, key)
+
+	return ok
+}
+
 func (rl *RateLimit) cleanup() {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
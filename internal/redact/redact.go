@@ -0,0 +1,261 @@
+// Package redact masks sensitive data, e.g. credentials and PII, before headers and bodies are logged.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+// DefaultMaxBodySize is the number of body bytes logged if no limit is configured.
+const DefaultMaxBodySize = 4096
+
+// DefaultHeaders are always redacted, configured headers are added to them.
+var DefaultHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// DefaultQueryParams are always redacted in logged URLs, configured parameters are added to them.
+var DefaultQueryParams = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"key",
+	"password",
+	"secret",
+	"signature",
+	"token",
+}
+
+// Config configures the redactor.
+//
+// JSONPaths are dot separated field paths, e.g. "card.number". A "*" segment matches any nesting of fields,
+// so "*.password" masks password fields at any depth, and a trailing "*" every field, e.g. "user.*".
+// Arrays are traversed transparently. Patterns are regular expressions whose matches are masked in bodies,
+// header values and URLs. QueryParams are query parameters masked in URLs, matched case-insensitively.
+// Bodies longer than MaxBodySize bytes are truncated after masking, a negative MaxBodySize disables truncation.
+type Config struct {
+	Headers     []string
+	QueryParams []string
+	JSONPaths   []string
+	Patterns    []string
+	MaxBodySize int
+}
+
+// Redactor masks headers, URLs and bodies. It is safe for concurrent use.
+type Redactor struct {
+	headers     map[string]struct{}
+	queryParams map[string]struct{}
+	paths       [][]string
+	patterns    []*regexp.Regexp
+	maxBodySize int
+}
+
+// New creates a redactor from the config.
+func New(cfg Config) (*Redactor, error) {
+	r := &Redactor{
+		headers:     make(map[string]struct{}, len(DefaultHeaders)+len(cfg.Headers)),
+		queryParams: make(map[string]struct{}, len(DefaultQueryParams)+len(cfg.QueryParams)),
+		maxBodySize: cfg.MaxBodySize,
+	}
+
+	if r.maxBodySize == 0 {
+		r.maxBodySize = DefaultMaxBodySize
+	}
+
+	for _, h := range DefaultHeaders {
+		r.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+
+	for _, h := range cfg.Headers {
+		r.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+
+	for _, p := range append(slices.Clone(DefaultQueryParams), cfg.QueryParams...) {
+		r.queryParams[strings.ToLower(p)] = struct{}{}
+	}
+
+	for _, path := range cfg.JSONPaths {
+		if path == "" {
+			return nil, errors.New("empty json path")
+		}
+
+		r.paths = append(r.paths, strings.Split(path, "."))
+	}
+
+	for _, pattern := range cfg.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+var defaultRedactor, _ = New(Config{})
+
+// Default returns the shared redactor with the default header deny-list and body size limit.
+func Default() *Redactor {
+	return defaultRedactor
+}
+
+// Header returns a copy of the header with deny-listed values masked and patterns masked in the other values.
+func (r *Redactor) Header(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+
+	redacted := make(http.Header, len(h))
+
+	for name, values := range h {
+		_, deny := r.headers[http.CanonicalHeaderKey(name)]
+
+		masked := make([]string, len(values))
+
+		for i, v := range values {
+			if deny {
+				masked[i] = Mask
+				continue
+			}
+
+			masked[i] = r.String(v)
+		}
+
+		redacted[name] = masked
+	}
+
+	return redacted
+}
+
+// String returns the value with pattern matches masked.
+func (r *Redactor) String(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, Mask)
+	}
+
+	return s
+}
+
+// URL returns the URL or request URI with deny-listed query parameter values and patterns masked.
+func (r *Redactor) URL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.RawQuery == "" {
+		return r.String(raw)
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+
+		name, uerr := url.QueryUnescape(key)
+		if uerr != nil {
+			name = key
+		}
+
+		if _, deny := r.queryParams[strings.ToLower(name)]; deny {
+			params[i] = key + "=" + Mask
+		}
+	}
+
+	u.RawQuery = strings.Join(params, "&")
+
+	return r.String(u.String())
+}
+
+// Body returns the body for logging. JSON fields matching the paths and pattern matches are masked,
+// then the result is truncated to the max body size.
+func (r *Redactor) Body(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if len(r.paths) > 0 {
+		body = r.maskJSON(body)
+	}
+
+	s := r.String(string(body))
+
+	if r.maxBodySize > 0 && len(s) > r.maxBodySize {
+		s = s[:r.maxBodySize] + "...(truncated " + strconv.Itoa(len(s)-r.maxBodySize) + " bytes)"
+	}
+
+	return s
+}
+
+// maskJSON masks the fields matching the paths. Bodies that are not JSON are returned as is.
+func (r *Redactor) maskJSON(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return body
+	}
+
+	for _, path := range r.paths {
+		v = maskPath(v, path)
+	}
+
+	masked, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+
+	return masked
+}
+
+func maskPath(v any, path []string) any {
+	switch node := v.(type) {
+	case []any:
+		for i := range node {
+			node[i] = maskPath(node[i], path)
+		}
+
+		return node
+	case map[string]any:
+		// A "*" followed by more segments matches zero or more levels, the path applies to every nested value
+		// as well as to the node itself.
+		if len(path) > 1 && path[0] == "*" {
+			for key, child := range node {
+				node[key] = maskPath(child, path)
+			}
+
+			return maskPath(node, path[1:])
+		}
+
+		for key, child := range node {
+			if path[0] != "*" && path[0] != key {
+				continue
+			}
+
+			if len(path) == 1 {
+				node[key] = Mask
+				continue
+			}
+
+			node[key] = maskPath(child, path[1:])
+		}
+
+		return node
+	default:
+		return v
+	}
+}
@@ -0,0 +1,122 @@
+package redact
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactor_Header(t *testing.T) {
+	r, err := New(Config{Headers: []string{"x-session"}, Patterns: []string{`token=\w+`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := http.Header{
+		"Authorization": {"Bearer abc"},
+		"X-Session":     {"s1"},
+		"X-Forwarded":   {"token=abc; other=1"},
+	}
+
+	got := r.Header(h)
+
+	if got.Get("Authorization") != Mask || got.Get("X-Session") != Mask {
+		t.Fatalf("deny-listed headers not masked: %v", got)
+	}
+
+	if got.Get("X-Forwarded") != Mask+"; other=1" {
+		t.Fatalf("pattern not masked: %q", got.Get("X-Forwarded"))
+	}
+
+	if h.Get("Authorization") != "Bearer abc" {
+		t.Fatal("original header modified")
+	}
+}
+
+func TestRedactor_URL(t *testing.T) {
+	r, err := New(Config{QueryParams: []string{"session"}, Patterns: []string{`\d{3}-\d{2}-\d{4}`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "/users?id=1", want: "/users?id=1"},
+		{url: "/users?API_KEY=abc&id=1&token", want: "/users?API_KEY=[REDACTED]&id=1&token=[REDACTED]"},
+		{url: "https://example.com/a?session=s1#top", want: "https://example.com/a?session=[REDACTED]#top"},
+		{url: "/ssn/123-45-6789", want: "/ssn/[REDACTED]"},
+	}
+
+	for _, tt := range tests {
+		if got := r.URL(tt.url); got != tt.want {
+			t.Errorf("URL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestRedactor_Body(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		body string
+		want string
+	}{
+		{
+			name: "any depth",
+			cfg:  Config{JSONPaths: []string{"*.password"}},
+			body: `{"password":"a","user":{"password":"b","name":"n"},"list":[{"password":"c"}]}`,
+			want: `{"list":[{"password":"[REDACTED]"}],"password":"[REDACTED]","user":{"name":"n","password":"[REDACTED]"}}`,
+		},
+		{
+			name: "exact path",
+			cfg:  Config{JSONPaths: []string{"card.number"}},
+			body: `{"card":{"number":4111111111111111,"exp":"12/30"},"number":1}`,
+			want: `{"card":{"exp":"12/30","number":"[REDACTED]"},"number":1}`,
+		},
+		{
+			name: "trailing wildcard",
+			cfg:  Config{JSONPaths: []string{"card.*"}},
+			body: `{"card":{"number":"4111","exp":"12/30"}}`,
+			want: `{"card":{"exp":"[REDACTED]","number":"[REDACTED]"}}`,
+		},
+		{
+			name: "pattern in non json body",
+			cfg:  Config{JSONPaths: []string{"*.password"}, Patterns: []string{`[\w.]+@[\w.]+`}},
+			body: `user=john@example.com&x=1`,
+			want: `user=[REDACTED]&x=1`,
+		},
+		{
+			name: "truncated",
+			cfg:  Config{MaxBodySize: 5},
+			body: `0123456789`,
+			want: `01234...(truncated 5 bytes)`,
+		},
+		{
+			name: "truncation disabled",
+			cfg:  Config{MaxBodySize: -1},
+			body: strings.Repeat("a", DefaultMaxBodySize+1),
+			want: strings.Repeat("a", DefaultMaxBodySize+1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := New(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := r.Body([]byte(tt.body)); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidPattern(t *testing.T) {
+	if _, err := New(Config{Patterns: []string{"("}}); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+}
@@ -20,6 +20,12 @@ type JSONError struct {
 const (
 	ErrorCodeRateLimitExceeded   = "RATE_LIMIT_EXCEEDED"
 	ErrorCodePayloadTooLarge     = "PAYLOAD_TOO_LARGE"
+	ErrorCodeOverloaded          = "OVERLOADED"
+	ErrorCodeClientCertRequired  = "CLIENT_CERT_REQUIRED"
+	ErrorCodeForbidden           = "FORBIDDEN"
+	ErrorCodeUnauthorized        = "UNAUTHORIZED"
+	ErrorCodeNotFound            = "NOT_FOUND"
+	ErrorCodeBadRequest          = "BAD_REQUEST"
 	ErrorCodeUpstreamUnavailable = "UPSTREAM_UNAVAILABLE"
 	ErrorCodeUpstreamError       = "UPSTREAM_ERROR"
 	ErrorCodeUpstreamMalformed   = "UPSTREAM_MALFORMED"
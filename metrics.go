@@ -0,0 +1,52 @@
+package kono
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+// NewMetrics creates metrics of the configured provider, a no-op if metrics are disabled.
+// If a VictoriaMetrics push host is set, metrics are pushed to it every interval until ctx is canceled.
+func NewMetrics(ctx context.Context, cfg MetricsConfig) (metric.Metrics, error) {
+	if !cfg.Enabled {
+		return metric.NewNop(), nil
+	}
+
+	switch cfg.Provider {
+	case metric.ProviderPrometheus:
+		return metric.NewPrometheus(), nil
+	case metric.ProviderVictoriaMetrics:
+		m := metric.NewVictoriaMetrics()
+
+		if cfg.VictoriaMetrics.Host != "" {
+			if err := m.StartPush(ctx, victoriaMetricsPushURL(cfg.VictoriaMetrics), cfg.VictoriaMetrics.Interval); err != nil {
+				return nil, err
+			}
+		}
+
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unknown metrics provider %q", cfg.Provider)
+	}
+}
+
+// victoriaMetricsPushURL builds the push URL. The host may include a scheme, http is used otherwise.
+func victoriaMetricsPushURL(cfg VictoriaMetricsConfig) string {
+	host := cfg.Host
+
+	scheme := "http://"
+	if i := strings.Index(host, "://"); i >= 0 {
+		scheme, host = host[:i+3], host[i+3:]
+	}
+
+	if cfg.Port > 0 {
+		host = net.JoinHostPort(host, strconv.Itoa(cfg.Port))
+	}
+
+	return scheme + host + cfg.Path
+}
@@ -0,0 +1,381 @@
+package kono
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+func TestVictoriaMetrics_Handler(t *testing.T) {
+	m, err := NewMetrics(context.Background(), MetricsConfig{Enabled: true, Provider: metric.ProviderVictoriaMetrics})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.IncRequestsTotal()
+	m.IncResponsesTotal("/users/{id}", http.StatusOK, "v2")
+	m.UpdateUpstreamLatency("/users/{id}", http.MethodGet, "users", "", 10*time.Millisecond)
+	m.IncFailedRequestsTotal(metric.FailReasonOverloaded)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	for _, want := range []string{
+		"kono_requests_total 1",
+		`kono_responses_total{route="/users/{id}",status="200",variant="v2"} 1`,
+		`kono_upstream_latency_count{route="/users/{id}",method="GET",upstream="users",variant=""} 1`,
+		`kono_failed_requests_total{reason="overloaded"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("expected %q in metrics:\n%s", want, rec.Body)
+		}
+	}
+}
+
+func TestPrometheus_Handler(t *testing.T) {
+	// Every instance has an own registry, a second one must not panic on duplicate registration.
+	for range 2 {
+		m, err := NewMetrics(context.Background(), MetricsConfig{Enabled: true, Provider: metric.ProviderPrometheus})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m.IncRequestsTotal()
+		m.IncResponsesTotal("/users/{id}", http.StatusOK, "")
+
+		rec := httptest.NewRecorder()
+		m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		for _, want := range []string{
+			"kono_requests_total 1",
+			`kono_responses_total{route="/users/{id}",status="200",variant=""} 1`,
+			"go_goroutines",
+			"process_cpu_seconds_total",
+		} {
+			if !strings.Contains(rec.Body.String(), want) {
+				t.Fatalf("expected %q in metrics:\n%s", want, rec.Body)
+			}
+		}
+	}
+}
+
+func TestVictoriaMetrics_Push(t *testing.T) {
+	pushed := make(chan string, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				return
+			}
+			body = zr
+		}
+
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, body)
+
+		if r.URL.Path == "/api/v1/import/prometheus" {
+			select {
+			case pushed <- buf.String():
+			default:
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	m, err := NewMetrics(ctx, MetricsConfig{
+		Enabled:  true,
+		Provider: metric.ProviderVictoriaMetrics,
+		VictoriaMetrics: VictoriaMetricsConfig{
+			Host:     srv.URL,
+			Path:     defaultMetricsPushPath,
+			Interval: 50 * time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.IncRequestsTotal()
+
+	select {
+	case body := <-pushed:
+		if !strings.Contains(body, "kono_requests_total") {
+			t.Fatalf("unexpected push body:\n%s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("metrics were not pushed")
+	}
+}
+
+func TestVictoriaMetricsPushURL(t *testing.T) {
+	tests := []struct {
+		cfg  VictoriaMetricsConfig
+		want string
+	}{
+		{VictoriaMetricsConfig{Host: "vm", Port: 8428, Path: "/api/v1/import/prometheus"}, "http://vm:8428/api/v1/import/prometheus"},
+		{VictoriaMetricsConfig{Host: "https://vm.example.com", Path: "/push"}, "https://vm.example.com/push"},
+	}
+
+	for _, tt := range tests {
+		if got := victoriaMetricsPushURL(tt.cfg); got != tt.want {
+			t.Fatalf("expected %s, got %s", tt.want, got)
+		}
+	}
+}
+
+func TestMetrics_ConfigValidation(t *testing.T) {
+	const cfg = `
+config_version: v1
+name: test
+version: "1"
+server:
+  port: 8080
+  metrics:
+    enabled: true
+    provider: graphite
+routes:
+  - path: /users
+    method: GET
+    upstreams:
+      - hosts: [http://localhost:8081]
+`
+
+	path := filepath.Join(t.TempDir(), "kono.yaml")
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "metrics.provider") {
+		t.Fatalf("expected validation error for unknown provider, got %v", err)
+	}
+}
+
+// recordingMetrics records the calls of the richer gateway metrics as "name route label..." keys.
+type recordingMetrics struct {
+	metric.Metrics
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{Metrics: metric.NewNop(), calls: make(map[string]int)}
+}
+
+func (m *recordingMetrics) record(n int, parts ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls[strings.Join(parts, " ")] += n
+}
+
+func (m *recordingMetrics) count(parts ...string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.calls[strings.Join(parts, " ")]
+}
+
+func (m *recordingMetrics) IncUpstreamResponsesTotal(route, upstream string, status int, errorKind string) {
+	m.record(1, "upstream_responses", route, upstream, strconv.Itoa(status), errorKind)
+}
+
+func (m *recordingMetrics) AddUpstreamRetries(route, upstream string, retries int) {
+	m.record(retries, "retries", route, upstream)
+}
+
+func (m *recordingMetrics) SetCircuitBreakerState(route, upstream string, state int) {
+	m.record(1, "breaker_state", route, upstream, strconv.Itoa(state))
+}
+
+func (m *recordingMetrics) IncCircuitBreakerTransitions(route, upstream, from, to string) {
+	m.record(1, "breaker_transitions", route, upstream, from, to)
+}
+
+func (m *recordingMetrics) IncRateLimitRejections(route, keyType string) {
+	m.record(1, "ratelimit", route, keyType)
+}
+
+func (m *recordingMetrics) ObserveRequestSize(route string, size int) {
+	m.record(size, "request_size", route)
+}
+
+func (m *recordingMetrics) ObserveResponseSize(route string, size int) {
+	m.record(size, "response_size", route)
+}
+
+func (m *recordingMetrics) UpdatePluginDuration(route, plugin string, _ time.Duration) {
+	m.record(1, "plugin", route, plugin)
+}
+
+func (m *recordingMetrics) UpdateMiddlewareDuration(route, middleware string, _ time.Duration) {
+	m.record(1, "middleware", route, middleware)
+}
+
+func (m *recordingMetrics) IncPartialResponsesTotal(route string) {
+	m.record(1, "partial", route)
+}
+
+func TestRouter_UpstreamMetrics(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	metrics := newRecordingMetrics()
+
+	r := &Router{
+		dispatcher: &defaultDispatcher{log: zap.NewNop(), metrics: metrics},
+		aggregator: &defaultAggregator{log: zap.NewNop()},
+		Routes: []Route{
+			{
+				Path:        "/orders/{id}",
+				Method:      http.MethodPost,
+				Middlewares: []Middleware{&mockMiddleware{}},
+				Plugins:     []Plugin{&mockPlugin{name: "enrich", typ: PluginTypeRequest, fn: func(Context) {}}},
+				Upstreams: initUpstreams([]UpstreamConfig{{
+					Name:    "orders",
+					Hosts:   []string{srv.URL},
+					Method:  http.MethodPost,
+					Timeout: time.Second,
+					Policy: PolicyConfig{
+						RetryConfig: RetryConfig{MaxRetries: 2, RetryOnStatuses: []int{http.StatusServiceUnavailable}},
+					},
+				}}, newTransportRegistry(), zap.NewNop()),
+				Aggregation:          AggregationConfig{Strategy: strategyMerge},
+				MaxParallelUpstreams: 1,
+			},
+		},
+		log:     zap.NewNop(),
+		metrics: metrics,
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/1", strings.NewReader(`{"qty":3}`)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+
+	const route = "/orders/{id}"
+
+	for _, tt := range []struct {
+		key  []string
+		want int
+	}{
+		{[]string{"upstream_responses", route, "orders", "200", ""}, 1},
+		{[]string{"retries", route, "orders"}, 1},
+		{[]string{"request_size", route}, len(`{"qty":3}`)},
+		{[]string{"response_size", route}, rec.Body.Len()},
+		{[]string{"plugin", route, "enrich"}, 1},
+		{[]string{"middleware", route, "mockmw"}, 1},
+	} {
+		if got := metrics.count(tt.key...); got != tt.want {
+			t.Fatalf("expected %v = %d, got %d (%v)", tt.key, tt.want, got, metrics.calls)
+		}
+	}
+}
+
+func TestRouter_BreakerRateLimitAndPartialMetrics(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(failing.Close)
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	t.Cleanup(ok.Close)
+
+	metrics := newRecordingMetrics()
+
+	r := NewRouter(RouterConfigSet{
+		Routes: []RouteConfig{{
+			Path:   "/users/{id}",
+			Method: http.MethodGet,
+			Upstreams: []UpstreamConfig{
+				{Name: "users", Hosts: []string{ok.URL}, Method: http.MethodGet, Timeout: time.Second},
+				{
+					Name:    "profiles",
+					Hosts:   []string{failing.URL},
+					Method:  http.MethodGet,
+					Timeout: time.Second,
+					Policy: PolicyConfig{
+						CircuitBreakerConfig: CircuitBreakerConfig{Enabled: true, MaxFailures: 1, ResetTimeout: time.Minute},
+					},
+				},
+			},
+			Aggregation:          AggregationConfig{Strategy: strategyMerge, AllowPartialResults: true},
+			MaxParallelUpstreams: 2,
+			RateLimits: []RateLimitConfig{{
+				Keys:   []RateLimitKeyConfig{{Type: rateLimitKeyIP}},
+				Limit:  1,
+				Window: time.Minute,
+			}},
+		}},
+		MetricsBackend: metrics,
+	}, zap.NewNop())
+
+	const route = "/users/{id}"
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/2", nil))
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the rate limit to reject the second request, got %d", rec.Code)
+	}
+
+	for _, tt := range []struct {
+		key  []string
+		want int
+	}{
+		{[]string{"breaker_state", route, "profiles", "0"}, 1},
+		{[]string{"breaker_state", route, "profiles", "1"}, 1},
+		{[]string{"breaker_transitions", route, "profiles", "closed", "open"}, 1},
+		{[]string{"upstream_responses", route, "profiles", "500", "bad_status"}, 1},
+		{[]string{"upstream_responses", route, "users", "200", ""}, 1},
+		{[]string{"partial", route}, 1},
+		{[]string{"ratelimit", route, "ip"}, 1},
+	} {
+		if got := metrics.count(tt.key...); got != tt.want {
+			t.Fatalf("expected %v = %d, got %d (%v)", tt.key, tt.want, got, metrics.calls)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package kono
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	defaultMirrorMaxConcurrent = 10
+	maxMirrorDiffs             = 10
+)
+
+// Mirror sends a copy of sampled route traffic to shadow upstreams, e.g. to try a new service version
+// with live traffic. Mirrored calls are fire-and-forget: they do not take part in aggregation, are not
+// canceled with the client request and are dropped when the mirror concurrency limit is reached, so they
+// cannot slow down the main path.
+type Mirror struct {
+	upstreams  []Upstream
+	percentage float64
+	sem        *semaphore.Weighted
+	logDiffs   bool
+	log        *zap.Logger
+}
+
+// newMirror returns nil if the route has no mirror upstreams.
+func newMirror(cfg MirrorConfig, transports *transportRegistry, log *zap.Logger) *Mirror {
+	if len(cfg.Upstreams) == 0 {
+		return nil
+	}
+
+	percentage := cfg.Percentage
+	if percentage == 0 {
+		percentage = 100
+	}
+
+	maxConcurrent := cfg.MaxConcurrent
+	if maxConcurrent == 0 {
+		maxConcurrent = defaultMirrorMaxConcurrent
+	}
+
+	return &Mirror{
+		upstreams:  initUpstreams(cfg.Upstreams, transports, log),
+		percentage: percentage,
+		sem:        semaphore.NewWeighted(maxConcurrent),
+		logDiffs:   cfg.LogDiffs,
+		log:        log.Named("mirror"),
+	}
+}
+
+// start mirrors the request if it is sampled. The returned function must be called with the primary
+// responses once they are available; they are compared with mirror responses when diff logging is on.
+func (m *Mirror) start(original *http.Request, body []byte) func(primary []UpstreamResponse) {
+	if m == nil || !hit(m.percentage) {
+		return func([]UpstreamResponse) {}
+	}
+
+	// The copy outlives the client request, so it must not be canceled with it.
+	req := original.Clone(context.WithoutCancel(original.Context()))
+	primaries := make(chan UpstreamResponse, len(m.upstreams))
+
+	var started int
+
+	for _, u := range m.upstreams {
+		if !m.sem.TryAcquire(1) {
+			m.log.Debug("mirror concurrency limit reached, request dropped", zap.String("upstream", u.Name()))
+			continue
+		}
+
+		started++
+
+		go func(u Upstream) {
+			defer m.sem.Release(1)
+
+			resp := u.Call(req.Context(), req, body)
+
+			if resp.Err != nil {
+				m.log.Debug("mirror request failed", zap.String("upstream", u.Name()), zap.Error(resp.Err.Unwrap()))
+			}
+
+			if m.logDiffs {
+				if primary, ok := <-primaries; ok {
+					m.diff(req, u, primary, resp)
+				}
+			}
+		}(u)
+	}
+
+	return func(primary []UpstreamResponse) {
+		if m.logDiffs && len(primary) > 0 {
+			for range started {
+				primaries <- primary[0]
+			}
+		}
+
+		close(primaries)
+	}
+}
+
+// diff logs the differences between the response of the first route upstream and the mirror response.
+func (m *Mirror) diff(req *http.Request, u Upstream, primary UpstreamResponse, mirrored *UpstreamResponse) {
+	var diffs []string
+
+	if primary.Status != mirrored.Status {
+		diffs = append(diffs, fmt.Sprintf("status: %d != %d", primary.Status, mirrored.Status))
+	}
+
+	if (primary.Err == nil) != (mirrored.Err == nil) {
+		diffs = append(diffs, fmt.Sprintf("error: %v != %v", primary.Err, mirrored.Err))
+	}
+
+	diffs = append(diffs, bodyDiff(primary.Body, mirrored.Body)...)
+
+	if len(diffs) == 0 {
+		return
+	}
+
+	m.log.Info("mirror response differs",
+		zap.String("method", req.Method),
+		zap.String("path", req.URL.Path),
+		zap.String("upstream", u.Name()),
+		zap.Strings("diffs", diffs),
+	)
+}
+
+// bodyDiff compares JSON bodies field by field and other bodies as bytes.
+func bodyDiff(primary, mirrored []byte) []string {
+	var a, b any
+
+	if json.Unmarshal(primary, &a) != nil || json.Unmarshal(mirrored, &b) != nil {
+		if bytes.Equal(primary, mirrored) {
+			return nil
+		}
+
+		return []string{"body"}
+	}
+
+	var diffs []string
+
+	jsonDiff("", a, b, &diffs)
+
+	return diffs
+}
+
+// jsonDiff appends the paths of differing values, up to maxMirrorDiffs.
+func jsonDiff(path string, a, b any, diffs *[]string) {
+	if len(*diffs) >= maxMirrorDiffs {
+		return
+	}
+
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+
+		for k := range bv {
+			if _, ok = av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			jsonDiff(joinDiffPath(path, k), av[k], bv[k], diffs)
+		}
+
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			break
+		}
+
+		for i := range av {
+			jsonDiff(joinDiffPath(path, strconv.Itoa(i)), av[i], bv[i], diffs)
+		}
+
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		if path == "" {
+			path = "body"
+		}
+
+		*diffs = append(*diffs, path)
+	}
+}
+
+func joinDiffPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
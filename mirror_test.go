@@ -0,0 +1,187 @@
+package kono
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type mirroredRequest struct {
+	path   string
+	header string
+	body   string
+}
+
+func newMirrorTestRoute(t *testing.T, primary, shadow http.HandlerFunc, cfg MirrorConfig, log *zap.Logger) Route {
+	t.Helper()
+
+	primarySrv := httptest.NewServer(primary)
+	t.Cleanup(primarySrv.Close)
+
+	shadowSrv := httptest.NewServer(shadow)
+	t.Cleanup(shadowSrv.Close)
+
+	cfg.Upstreams = []UpstreamConfig{
+		{Hosts: []string{shadowSrv.URL + "/v2"}, Method: http.MethodPost, Timeout: 2 * time.Second, ForwardHeaders: []string{"X-Trace"}},
+	}
+
+	return Route{
+		Path:   "/orders",
+		Method: http.MethodPost,
+		Upstreams: initUpstreams([]UpstreamConfig{
+			{Hosts: []string{primarySrv.URL + "/v1"}, Method: http.MethodPost, Timeout: time.Second},
+		}, newTransportRegistry(), zap.NewNop()),
+		Aggregation:          AggregationConfig{Strategy: strategyMerge},
+		MaxParallelUpstreams: 1,
+		Mirror:               newMirror(cfg, newTransportRegistry(), log),
+	}
+}
+
+func postOrder(r *Router) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"item":"book"}`))
+	req.Header.Set("X-Trace", "t1")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestMirror_FireAndForget(t *testing.T) {
+	mirrored := make(chan mirroredRequest, 1)
+
+	route := newMirrorTestRoute(t,
+		func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"id":1}`))
+		},
+		func(w http.ResponseWriter, req *http.Request) {
+			// A slow shadow must not delay the client response.
+			time.Sleep(200 * time.Millisecond)
+
+			body, _ := io.ReadAll(req.Body)
+			mirrored <- mirroredRequest{path: req.URL.Path, header: req.Header.Get("X-Trace"), body: string(body)}
+
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+		MirrorConfig{},
+		zap.NewNop(),
+	)
+	r := newTestRouter(newTestDispatcher(), route)
+
+	start := time.Now()
+	rec := postOrder(r)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"data":{"id":1}}` {
+		t.Fatalf("expected the primary response, got %d %s", rec.Code, rec.Body)
+	}
+
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Fatalf("expected the response not to wait for the mirror, took %s", elapsed)
+	}
+
+	select {
+	case got := <-mirrored:
+		want := mirroredRequest{path: "/v2", header: "t1", body: `{"item":"book"}`}
+		if got != want {
+			t.Fatalf("expected mirrored request %+v, got %+v", want, got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the request to be mirrored after the client response")
+	}
+}
+
+func TestMirror_ConcurrencyLimit(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan struct{}, 2)
+
+	route := newMirrorTestRoute(t,
+		func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{}`))
+		},
+		func(_ http.ResponseWriter, _ *http.Request) {
+			received <- struct{}{}
+			<-release
+		},
+		MirrorConfig{MaxConcurrent: 1},
+		zap.NewNop(),
+	)
+	r := newTestRouter(newTestDispatcher(), route)
+	defer close(release)
+
+	postOrder(r)
+	<-received
+
+	if rec := postOrder(r); rec.Code != http.StatusOK {
+		t.Fatalf("expected the primary response while the mirror is busy, got %d", rec.Code)
+	}
+
+	select {
+	case <-received:
+		t.Fatal("expected the request over the mirror limit not to be mirrored")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMirror_LogsDiffs(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	route := newMirrorTestRoute(t,
+		func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"id":1,"total":10,"items":["book"]}`))
+		},
+		func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"id":1,"total":12,"items":["book"],"currency":"EUR"}`))
+		},
+		MirrorConfig{LogDiffs: true},
+		zap.New(core),
+	)
+	r := newTestRouter(newTestDispatcher(), route)
+
+	postOrder(r)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for logs.FilterMessage("mirror response differs").Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the mirror diff to be logged")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entry := logs.FilterMessage("mirror response differs").All()[0]
+
+	diffs, _ := entry.ContextMap()["diffs"].([]interface{})
+	if !reflect.DeepEqual(diffs, []interface{}{"currency", "total"}) {
+		t.Fatalf("unexpected diffs %v", entry.ContextMap()["diffs"])
+	}
+}
+
+func TestBodyDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		primary  string
+		mirrored string
+		want     []string
+	}{
+		{name: "equal", primary: `{"a":1,"b":[1,2]}`, mirrored: `{"b":[1,2],"a":1}`},
+		{name: "nested", primary: `{"a":{"b":1,"c":[1,2]}}`, mirrored: `{"a":{"b":2,"c":[1,3]}}`, want: []string{"a.b", "a.c.1"}},
+		{name: "array length", primary: `[1]`, mirrored: `[1,2]`, want: []string{"body"}},
+		{name: "not json", primary: `ok`, mirrored: `OK`, want: []string{"body"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bodyDiff([]byte(tt.primary), []byte(tt.mirrored)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
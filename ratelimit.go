@@ -0,0 +1,226 @@
+package kono
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/starwalkn/kono/internal/ratelimit"
+)
+
+const (
+	rateLimitKeyIP        = "ip"
+	rateLimitKeyHeader    = "header"
+	rateLimitKeyClaim     = "claim"
+	rateLimitKeyAPIKey    = "api_key"
+	rateLimitKeyPathParam = "path_param"
+
+	// rateLimitKeyGlobal labels rejections of the gateway rate limiter in metrics.
+	rateLimitKeyGlobal = "global"
+
+	defaultAPIKeyHeader = "X-API-Key"
+	apiKeyQueryParam    = "api_key"
+
+	// missingKeyPart is used for absent key values, so requests without the value share one bucket
+	// instead of bypassing the limit.
+	missingKeyPart = "-"
+)
+
+// keyExtractor returns a single part of a rate limit bucket key.
+type keyExtractor func(req *http.Request) string
+
+// RateLimitRule is a route level rate limit. A request is allowed only if every rule of the route allows it.
+type RateLimitRule struct {
+	name     string
+	keys     []keyExtractor
+	keyTypes []string
+	limit    int
+	window   time.Duration
+
+	tierKey keyExtractor
+	tiers   map[string]RateLimitTierConfig
+
+	limiter *ratelimit.RateLimit
+}
+
+func newRateLimitRule(cfg RateLimitConfig) (*RateLimitRule, error) {
+	rule := &RateLimitRule{
+		name:     cfg.Name,
+		keys:     make([]keyExtractor, 0, len(cfg.Keys)),
+		keyTypes: make([]string, 0, len(cfg.Keys)),
+		limit:    cfg.Limit,
+		window:   cfg.Window,
+		tiers:    make(map[string]RateLimitTierConfig, len(cfg.Tiers)),
+		limiter:  ratelimit.NewWithLimit(cfg.Limit, cfg.Window),
+	}
+
+	for _, kcfg := range cfg.Keys {
+		extractor, err := newKeyExtractor(kcfg)
+		if err != nil {
+			return nil, err
+		}
+
+		rule.keys = append(rule.keys, extractor)
+		rule.keyTypes = append(rule.keyTypes, kcfg.Type)
+	}
+
+	if cfg.TierKey != nil {
+		extractor, err := newKeyExtractor(*cfg.TierKey)
+		if err != nil {
+			return nil, err
+		}
+
+		rule.tierKey = extractor
+	}
+
+	for _, tier := range cfg.Tiers {
+		rule.tiers[tier.Name] = tier
+	}
+
+	if rule.name == "" {
+		rule.name = strings.Join(rule.keyTypes, "+")
+	}
+
+	return rule, nil
+}
+
+// Name returns the configured rule name or the joined key types if the name is not set.
+func (r *RateLimitRule) Name() string { return r.name }
+
+// KeyType returns the key types of the rule joined with "+", e.g. "ip+header".
+func (r *RateLimitRule) KeyType() string { return strings.Join(r.keyTypes, "+") }
+
+// Allow reports whether the request fits into the rule limit and consumes one slot of its bucket.
+func (r *RateLimitRule) Allow(req *http.Request) bool {
+	key, limit, window := r.resolve(req)
+
+	return r.limiter.AllowLimit(key, limit, window)
+}
+
+// resolve builds the bucket key and selects the limit of the consumer tier, if any.
+func (r *RateLimitRule) resolve(req *http.Request) (string, int, time.Duration) {
+	parts := make([]string, 0, len(r.keys)+1)
+
+	limit, window := r.limit, r.window
+
+	if r.tierKey != nil {
+		if tier, ok := r.tiers[r.tierKey(req)]; ok {
+			limit, window = tier.Limit, tier.Window
+			parts = append(parts, tier.Name)
+		}
+	}
+
+	for _, extract := range r.keys {
+		part := extract(req)
+		if part == "" {
+			part = missingKeyPart
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, "|"), limit, window
+}
+
+func (r *RateLimitRule) start() {
+	_ = r.limiter.Start()
+}
+
+func newKeyExtractor(cfg RateLimitKeyConfig) (keyExtractor, error) {
+	name := cfg.Name
+
+	switch cfg.Type {
+	case rateLimitKeyIP:
+		return extractClientIP, nil
+	case rateLimitKeyHeader:
+		return func(req *http.Request) string {
+			return req.Header.Get(name)
+		}, nil
+	case rateLimitKeyClaim:
+		return func(req *http.Request) string {
+			return claimValue(req, name)
+		}, nil
+	case rateLimitKeyAPIKey:
+		if name == "" {
+			name = defaultAPIKeyHeader
+		}
+
+		return func(req *http.Request) string {
+			return apiKeyValue(req, name)
+		}, nil
+	case rateLimitKeyPathParam:
+		return func(req *http.Request) string {
+			return req.PathValue(name)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit key type: %s", cfg.Type)
+	}
+}
+
+// claimValue returns a claim set by the auth middleware. Nested claims are addressed with dots, e.g. "org.id".
+func claimValue(req *http.Request, path string) string {
+	claims, ok := ClaimsFromContext(req.Context())
+	if !ok {
+		return ""
+	}
+
+	var value any = claims
+
+	for _, segment := range strings.Split(path, ".") {
+		obj, isObj := value.(map[string]any)
+		if !isObj {
+			return ""
+		}
+
+		value, ok = obj[segment]
+		if !ok {
+			return ""
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// apiKeyValue returns a hash of the API key taken from the header or the api_key query parameter.
+// Raw keys are never used as bucket keys, so they do not stay in memory longer than the request.
+func apiKeyValue(req *http.Request, header string) string {
+	key := req.Header.Get(header)
+	if key == "" {
+		key = req.URL.Query().Get(apiKeyQueryParam)
+	}
+
+	if key == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+func initRateLimits(cfgs []RateLimitConfig) ([]*RateLimitRule, error) {
+	rules := make([]*RateLimitRule, 0, len(cfgs))
+
+	for _, cfg := range cfgs {
+		rule, err := newRateLimitRule(cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		rule.start()
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
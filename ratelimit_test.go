@@ -0,0 +1,201 @@
+package kono
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRateLimitTestRoute returns a GET route limited by the rules.
+func newRateLimitTestRoute(t *testing.T, path string, cfgs []RateLimitConfig) Route {
+	t.Helper()
+
+	rules, err := initRateLimits(cfgs)
+	if err != nil {
+		t.Fatalf("cannot init rate limits: %v", err)
+	}
+
+	return Route{
+		Path:        path,
+		Method:      http.MethodGet,
+		Aggregation: AggregationConfig{Strategy: strategyMerge},
+		RateLimits:  rules,
+	}
+}
+
+func serveStatus(r http.Handler, req *http.Request) int {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestRateLimit_HeaderKey(t *testing.T) {
+	r := newTestRouter(newOKDispatcher(), newRateLimitTestRoute(t, "/limited", []RateLimitConfig{
+		{
+			Keys:   []RateLimitKeyConfig{{Type: rateLimitKeyHeader, Name: "X-Client"}},
+			Limit:  1,
+			Window: time.Minute,
+		},
+	}))
+
+	newReq := func(client string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/limited", nil)
+		req.Header.Set("X-Client", client)
+
+		return req
+	}
+
+	if got := serveStatus(r, newReq("a")); got != http.StatusOK {
+		t.Fatalf("expected 200 for first request, got %d", got)
+	}
+
+	if got := serveStatus(r, newReq("a")); got != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for second request of the same client, got %d", got)
+	}
+
+	if got := serveStatus(r, newReq("b")); got != http.StatusOK {
+		t.Fatalf("expected 200 for another client, got %d", got)
+	}
+}
+
+func TestRateLimit_PathParamKey(t *testing.T) {
+	r := newTestRouter(newOKDispatcher(), newRateLimitTestRoute(t, "/users/{id}/orders", []RateLimitConfig{
+		{
+			Keys:   []RateLimitKeyConfig{{Type: rateLimitKeyPathParam, Name: "id"}},
+			Limit:  1,
+			Window: time.Minute,
+		},
+	}))
+
+	if got := serveStatus(r, httptest.NewRequest(http.MethodGet, "/users/1/orders", nil)); got != http.StatusOK {
+		t.Fatalf("expected 200, got %d", got)
+	}
+
+	if got := serveStatus(r, httptest.NewRequest(http.MethodGet, "/users/1/orders", nil)); got != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", got)
+	}
+
+	if got := serveStatus(r, httptest.NewRequest(http.MethodGet, "/users/2/orders", nil)); got != http.StatusOK {
+		t.Fatalf("expected 200 for another path param, got %d", got)
+	}
+
+	if got := serveStatus(r, httptest.NewRequest(http.MethodGet, "/users//orders", nil)); got != http.StatusNotFound {
+		t.Fatalf("expected 404 for empty path param, got %d", got)
+	}
+}
+
+func TestRateLimit_ClaimTiers(t *testing.T) {
+	r := newTestRouter(newOKDispatcher(), newRateLimitTestRoute(t, "/tiers", []RateLimitConfig{
+		{
+			Keys:    []RateLimitKeyConfig{{Type: rateLimitKeyClaim, Name: "sub"}},
+			Limit:   1,
+			Window:  time.Minute,
+			TierKey: &RateLimitKeyConfig{Type: rateLimitKeyClaim, Name: "plan.name"},
+			Tiers: []RateLimitTierConfig{
+				{Name: "gold", Limit: 3, Window: time.Minute},
+			},
+		},
+	}))
+
+	newReq := func(sub, plan string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/tiers", nil)
+		claims := map[string]any{"sub": sub, "plan": map[string]any{"name": plan}}
+
+		return req.WithContext(WithClaims(req.Context(), claims))
+	}
+
+	for i := range 3 {
+		if got := serveStatus(r, newReq("gold-user", "gold")); got != http.StatusOK {
+			t.Fatalf("gold request %d: expected 200, got %d", i, got)
+		}
+	}
+
+	if got := serveStatus(r, newReq("gold-user", "gold")); got != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after gold limit, got %d", got)
+	}
+
+	if got := serveStatus(r, newReq("free-user", "free")); got != http.StatusOK {
+		t.Fatalf("expected 200 for first free request, got %d", got)
+	}
+
+	if got := serveStatus(r, newReq("free-user", "free")); got != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after default limit, got %d", got)
+	}
+}
+
+func TestRateLimit_MultipleRules(t *testing.T) {
+	r := newTestRouter(newOKDispatcher(), newRateLimitTestRoute(t, "/multi", []RateLimitConfig{
+		{
+			Name:   "per-key",
+			Keys:   []RateLimitKeyConfig{{Type: rateLimitKeyAPIKey}},
+			Limit:  2,
+			Window: time.Minute,
+		},
+		{
+			Name:   "per-key-and-ip",
+			Keys:   []RateLimitKeyConfig{{Type: rateLimitKeyAPIKey}, {Type: rateLimitKeyIP}},
+			Limit:  1,
+			Window: time.Minute,
+		},
+	}))
+
+	newReq := func(ip string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/multi?api_key=secret", nil)
+		req.RemoteAddr = ip + ":1234"
+
+		return req
+	}
+
+	if got := serveStatus(r, newReq("10.0.0.1")); got != http.StatusOK {
+		t.Fatalf("expected 200, got %d", got)
+	}
+
+	if got := serveStatus(r, newReq("10.0.0.1")); got != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 by per-key-and-ip rule, got %d", got)
+	}
+
+	if got := serveStatus(r, newReq("10.0.0.2")); got != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 by per-key rule, got %d", got)
+	}
+}
+
+func TestRateLimit_ConfigValidation(t *testing.T) {
+	const cfg = `
+config_version: v1
+name: test
+version: "1"
+server:
+  port: 8080
+routes:
+  - path: /users/{id}
+    method: GET
+    aggregation:
+      strategy: merge
+    upstreams:
+      - hosts: ["http://localhost:9000"]
+        method: GET
+    rate_limits:
+      - keys:
+          - type: header
+        limit: 10
+`
+
+	path := filepath.Join(t.TempDir(), "kono.yaml")
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error for header key without name")
+	}
+
+	if !strings.Contains(err.Error(), "rate_limits[0].keys[0].name") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
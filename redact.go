@@ -0,0 +1,108 @@
+package kono
+
+import (
+	"context"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/starwalkn/kono/internal/redact"
+)
+
+// newRedactor creates the redactor of logged headers and bodies from the config.
+func newRedactor(cfg RedactionConfig) (*redact.Redactor, error) {
+	return redact.New(redact.Config{
+		Headers:     cfg.Headers,
+		QueryParams: cfg.QueryParams,
+		JSONPaths:   cfg.JSONPaths,
+		Patterns:    cfg.Patterns,
+		MaxBodySize: cfg.MaxBodySize,
+	})
+}
+
+// redaction returns the router redactor, or the default one for routers created without config.
+func (r *Router) redaction() *redact.Redactor {
+	if r.redactor == nil {
+		return redact.Default()
+	}
+
+	return r.redactor
+}
+
+type ctxKeyRedactor struct{}
+
+// WithRedactor returns a copy of ctx carrying the redactor of logged data.
+func WithRedactor(ctx context.Context, redactor *redact.Redactor) context.Context {
+	return context.WithValue(ctx, ctxKeyRedactor{}, redactor)
+}
+
+// RedactorFromContext returns the redactor built from server.redaction by the router, so that middlewares log
+// with the same masking. It returns the default redactor outside of the router.
+func RedactorFromContext(ctx context.Context) *redact.Redactor {
+	if r, ok := ctx.Value(ctxKeyRedactor{}).(*redact.Redactor); ok && r != nil {
+		return r
+	}
+
+	return redact.Default()
+}
+
+// loggedResponses logs upstream responses with headers and bodies redacted.
+type loggedResponses struct {
+	responses []UpstreamResponse
+	redactor  *redact.Redactor
+}
+
+func (l loggedResponses) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := range l.responses {
+		if err := enc.AppendObject(loggedResponse{resp: &l.responses[i], redactor: l.redactor}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type loggedResponse struct {
+	resp     *UpstreamResponse
+	redactor *redact.Redactor
+}
+
+func (l loggedResponse) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("status", l.resp.Status)
+
+	if l.resp.Variant != "" {
+		enc.AddString("variant", l.resp.Variant)
+	}
+
+	if l.resp.Attempts > 0 {
+		enc.AddInt("attempts", l.resp.Attempts)
+	}
+
+	if err := enc.AddReflected("headers", l.redactor.Header(l.resp.Headers)); err != nil {
+		return err
+	}
+
+	enc.AddString("body", l.redactor.Body(l.resp.Body))
+
+	if l.resp.Err != nil {
+		enc.AddString("error_kind", string(l.resp.Err.Kind))
+
+		if l.resp.Err.Err != nil {
+			enc.AddString("error", l.redactor.String(l.resp.Err.Err.Error()))
+		}
+	}
+
+	return nil
+}
+
+// loggedAggregated logs the aggregated response with the data redacted.
+type loggedAggregated struct {
+	aggregated *AggregatedResponse
+	redactor   *redact.Redactor
+}
+
+func (l loggedAggregated) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("data", l.redactor.Body(l.aggregated.Data))
+	enc.AddBool("partial", l.aggregated.Partial)
+
+	return enc.AddReflected("errors", l.aggregated.Errors)
+}
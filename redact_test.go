@@ -0,0 +1,97 @@
+package kono
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/starwalkn/kono/internal/metric"
+	"github.com/starwalkn/kono/internal/redact"
+)
+
+// redactorMiddleware records the redactor middlewares log with.
+type redactorMiddleware struct {
+	got *redact.Redactor
+}
+
+func (m *redactorMiddleware) Init(_ map[string]interface{}) error { return nil }
+func (m *redactorMiddleware) Name() string                        { return "redactor" }
+func (m *redactorMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.got = RedactorFromContext(r.Context())
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestRouter_DebugLogsRedacted(t *testing.T) {
+	var buf bytes.Buffer
+
+	log := zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(&buf),
+		zapcore.DebugLevel,
+	))
+
+	redactor, err := newRedactor(RedactionConfig{JSONPaths: []string{"*.token"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mw := &redactorMiddleware{}
+
+	r := &Router{
+		dispatcher: &mockDispatcher{
+			results: []UpstreamResponse{
+				{
+					Status:  http.StatusOK,
+					Headers: http.Header{"Set-Cookie": {"session=s3cr3t"}},
+					Body:    []byte(`{"user":{"token":"t0k3n","name":"alice"}}`),
+				},
+			},
+		},
+		aggregator: &defaultAggregator{log: zap.NewNop()},
+		Routes: []Route{
+			{
+				Path:        "/me",
+				Method:      http.MethodGet,
+				Middlewares: []Middleware{mw},
+				Aggregation: AggregationConfig{Strategy: strategyMerge},
+			},
+		},
+		log:      log,
+		metrics:  metric.NewNop(),
+		redactor: redactor,
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/me", nil))
+
+	if !strings.Contains(rec.Body.String(), "t0k3n") {
+		t.Fatalf("response must not be redacted: %s", rec.Body)
+	}
+
+	logs := buf.String()
+
+	if !strings.Contains(logs, "dispatched responses") || !strings.Contains(logs, "aggregated responses") {
+		t.Fatalf("expected debug logs, got: %s", logs)
+	}
+
+	for _, secret := range []string{"s3cr3t", "t0k3n"} {
+		if strings.Contains(logs, secret) {
+			t.Fatalf("expected %q to be redacted, got: %s", secret, logs)
+		}
+	}
+
+	if !strings.Contains(logs, "alice") {
+		t.Fatalf("expected non-sensitive fields in logs, got: %s", logs)
+	}
+
+	if mw.got != redactor {
+		t.Fatal("expected middlewares to log with the router redactor")
+	}
+}
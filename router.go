@@ -2,21 +2,27 @@ package kono
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math"
-	"net"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/oklog/ulid/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
+	"github.com/starwalkn/kono/internal/circuitbreaker"
 	"github.com/starwalkn/kono/internal/metric"
 	"github.com/starwalkn/kono/internal/ratelimit"
+	"github.com/starwalkn/kono/internal/redact"
 )
 
 type Router struct {
@@ -27,7 +33,27 @@ type Router struct {
 	log     *zap.Logger
 	metrics metric.Metrics
 
-	rateLimiter *ratelimit.RateLimit
+	rateLimiter      *ratelimit.RateLimit
+	clientIPResolver *clientIPResolver
+	concurrency      *ConcurrencyLimit
+
+	forwardClientCert bool
+
+	// tracerProvider is nil if tracing is disabled.
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+
+	// accessLog is nil if the access log is disabled.
+	accessLog *AccessLog
+
+	// redactor masks sensitive data in debug logs of upstream responses.
+	redactor *redact.Redactor
+
+	// stats is nil if traffic statistics are disabled.
+	stats *TrafficStats
+
+	// transports are the upstream transports shared by the routes, nil for routers not built by BuildRouter.
+	transports *transportRegistry
 }
 
 type RouterConfigSet struct {
@@ -36,9 +62,52 @@ type RouterConfigSet struct {
 	Middlewares []MiddlewareConfig
 	Features    []FeatureConfig
 	Metrics     MetricsConfig
+
+	// MetricsBackend is used instead of creating metrics from the Metrics config if set, so that metrics
+	// outlive the router and are served by the caller.
+	MetricsBackend metric.Metrics
+
+	TrustedProxies    []string
+	ClientIPHeader    string
+	Concurrency       ConcurrencyConfig
+	ForwardClientCert bool
+
+	// TracerProvider enables tracing of requests if set, Propagator selects the trace headers format.
+	TracerProvider trace.TracerProvider
+	Propagator     propagation.TextMapPropagator
+
+	// AccessLog writes an access log entry per request if set.
+	AccessLog *AccessLog
+
+	Redaction RedactionConfig
+
+	// TrafficStats records rolling per-route statistics if set.
+	TrafficStats *TrafficStats
 }
 
 func NewRouter(routerConfigSet RouterConfigSet, log *zap.Logger) *Router {
+	router, err := BuildRouter(routerConfigSet, log)
+	if err != nil {
+		log.Fatal("failed to init router", zap.Error(err))
+	}
+
+	return router
+}
+
+// BuildRouter is like NewRouter but returns an error instead of exiting, e.g. to build a router of a config
+// applied at runtime.
+func BuildRouter(routerConfigSet RouterConfigSet, log *zap.Logger) (*Router, error) {
+	router := initMinimalRouter(len(routerConfigSet.Routes), log)
+
+	if err := router.init(routerConfigSet, log); err != nil {
+		router.Close()
+		return nil, err
+	}
+
+	return router, nil
+}
+
+func (r *Router) init(routerConfigSet RouterConfigSet, log *zap.Logger) (err error) {
 	var (
 		routeConfigs            = routerConfigSet.Routes
 		globalMiddlewareConfigs = routerConfigSet.Middlewares
@@ -46,27 +115,61 @@ func NewRouter(routerConfigSet RouterConfigSet, log *zap.Logger) *Router {
 		metricsConfig           = routerConfigSet.Metrics
 	)
 
-	router := initMinimalRouter(len(routeConfigs), log)
+	// Route builders panic on invalid plugins and upstreams.
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
 
-	if metricsConfig.Enabled {
-		switch metricsConfig.Provider {
-		case "prometheus":
-			router.metrics = metric.NewPrometheus()
-		default:
-			router.metrics = metric.NewNop()
+	clientIPResolver, err := newClientIPResolver(routerConfigSet.TrustedProxies, routerConfigSet.ClientIPHeader)
+	if err != nil {
+		return fmt.Errorf("failed to parse trusted proxies: %w", err)
+	}
+
+	r.clientIPResolver = clientIPResolver
+	r.concurrency = newConcurrencyLimit(routerConfigSet.Concurrency)
+	r.forwardClientCert = routerConfigSet.ForwardClientCert
+	r.accessLog = routerConfigSet.AccessLog
+	r.stats = routerConfigSet.TrafficStats
+
+	r.redactor, err = newRedactor(routerConfigSet.Redaction)
+	if err != nil {
+		return fmt.Errorf("failed to init log redaction: %w", err)
+	}
+
+	if routerConfigSet.TracerProvider != nil {
+		r.tracerProvider = routerConfigSet.TracerProvider
+
+		r.propagator = routerConfigSet.Propagator
+		if r.propagator == nil {
+			r.propagator = newPropagator([]string{propagatorTraceContext, propagatorB3Multi})
 		}
 	}
 
+	switch {
+	case routerConfigSet.MetricsBackend != nil:
+		r.metrics = routerConfigSet.MetricsBackend
+	case metricsConfig.Enabled:
+		r.metrics, err = NewMetrics(context.Background(), metricsConfig)
+		if err != nil {
+			return fmt.Errorf("failed to init metrics: %w", err)
+		}
+	}
+
+	if d, ok := r.dispatcher.(*defaultDispatcher); ok {
+		d.metrics = r.metrics
+	}
+
 	for _, fcfg := range featureConfigs {
 		//nolint:gocritic // for the future
 		switch fcfg.Name {
 		case "ratelimit":
 			if fcfg.Enabled {
-				router.rateLimiter = ratelimit.New(fcfg.Config)
+				r.rateLimiter = ratelimit.New(fcfg.Config)
 
-				err := router.rateLimiter.Start()
-				if err != nil {
-					log.Fatal("failed to start ratelimit feature", zap.Error(err))
+				if err = r.rateLimiter.Start(); err != nil {
+					return fmt.Errorf("failed to start ratelimit feature: %w", err)
 				}
 			}
 		}
@@ -75,22 +178,70 @@ func NewRouter(routerConfigSet RouterConfigSet, log *zap.Logger) *Router {
 	// Global middlewares.
 	globalMiddlewareIndices, globalMiddlewares := initGlobalMiddlewares(globalMiddlewareConfigs, log)
 
+	// Upstream transports are shared by all routes.
+	r.transports = newTransportRegistry()
+
 	for _, rcfg := range routeConfigs {
-		router.Routes = append(router.Routes, initRoute(rcfg, globalMiddlewares, globalMiddlewareIndices, log))
+		r.Routes = append(r.Routes, initRoute(rcfg, globalMiddlewares, globalMiddlewareIndices, r.transports, log))
 	}
 
-	return router
+	r.observeCircuitBreakers()
+
+	return nil
+}
+
+// Close stops the rate limiter cleanups and closes idle upstream connections of the router, e.g. after it has
+// been replaced by a router of a new config. Requests still served by the router are not affected.
+func (r *Router) Close() {
+	if r.rateLimiter != nil {
+		_ = r.rateLimiter.Stop()
+	}
+
+	for i := range r.Routes {
+		for _, rl := range r.Routes[i].RateLimits {
+			_ = rl.limiter.Stop()
+		}
+	}
+
+	r.transports.closeIdleConnections()
+}
+
+// observeCircuitBreakers reports the state and transitions of upstream circuit breakers to metrics.
+func (r *Router) observeCircuitBreakers() {
+	for i := range r.Routes {
+		route := r.Routes[i].Path
+
+		for _, u := range httpUpstreams(r.Routes[i].Upstreams) {
+			if u.circuitBreaker == nil {
+				continue
+			}
+
+			name := u.name
+
+			r.metrics.SetCircuitBreakerState(route, name, int(u.circuitBreaker.State()))
+			u.circuitBreaker.OnStateChange(func(from, to circuitbreaker.State) {
+				r.metrics.SetCircuitBreakerState(route, name, int(to))
+				r.metrics.IncCircuitBreakerTransitions(route, name, from.String(), to.String())
+			})
+		}
+	}
 }
 
 // ServeHTTP handles incoming HTTP requests through the full router pipeline.
 //
 // The processing steps are:
 //
-// 1. Rate limiting (if enabled) – rejects requests exceeding allowed limits.
+// 0. Client address resolution – the real client IP is resolved through trusted proxies.
+// 1. Rate limiting (if enabled) – rejects requests exceeding allowed limits. The global concurrency limit
+// sheds requests above the in-flight limit with 503 and Retry-After.
 // 2. Route matching – finds a Route that matches the request method and path.
 //   - If no route is found, responds with 404.
+//   - If the route requires a client certificate and the verified one does not match, responds with 401/403.
 //
 // 3. Middleware execution – wraps the route handler with all configured middlewares in reverse order.
+// Route rate limits and the route concurrency limit are applied right after middlewares.
+// Websocket routes proxy the upgraded connection to their upstream at this point instead of steps 4-8,
+// stream routes pass the upstream response through unbuffered after acquiring the route concurrency slot.
 // 4. Request-phase plugins – executed before upstream dispatch. Can modify the request context.
 // 5. Upstream dispatch – sends the request to all configured upstreams via the dispatcher.
 //   - If the dispatch fails (e.g., body too large), responds with an appropriate error.
@@ -108,14 +259,31 @@ func NewRouter(routerConfigSet RouterConfigSet, log *zap.Logger) *Router {
 //
 // The final response always includes a JSON body with `data` and `errors` fields, and a `X-Request-ID` header.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	req = r.clientIPResolver.withForwardingInfo(req, r.forwardClientCert)
+	req = req.WithContext(WithRedactor(req.Context(), r.redaction()))
+
+	w, req, endSpan := r.traceRequest(w, req)
+	defer endSpan()
+
+	// The access log entry is written before the span ends, so it carries the trace ID.
+	w, req, endAccessLog := r.logAccess(w, req)
+	defer endAccessLog()
+
 	r.metrics.IncRequestsTotal()
 
 	r.metrics.IncRequestsInFlight()
 	defer r.metrics.DecRequestsInFlight()
 
+	token, ok := r.concurrency.acquire(w, req, req.Header.Get("X-Request-ID"))
+	if !ok {
+		r.metrics.IncFailedRequestsTotal(metric.FailReasonOverloaded)
+		return
+	}
+	defer releaseSlot(token, false)
+
 	matchedRoute := r.match(req)
 	if matchedRoute == nil {
-		r.log.Error("no route matched", zap.String("request_uri", req.URL.RequestURI()))
+		r.log.Error("no route matched", zap.String("request_uri", r.redaction().URL(req.URL.RequestURI())))
 		r.metrics.IncFailedRequestsTotal(metric.FailReasonNoMatchedRoute)
 
 		http.NotFound(w, req)
@@ -123,8 +291,32 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Spans are named by the route template to keep their cardinality low.
+	span := trace.SpanFromContext(req.Context())
+	span.SetName(req.Method + " " + matchedRoute.Path)
+	span.SetAttributes(attribute.String("http.route", matchedRoute.Path))
+
+	accessEntryFromContext(req.Context()).setRoute(matchedRoute.Path)
+
+	w, endStats := r.recordStats(w, matchedRoute)
+	defer endStats()
+
+	if code, status := checkClientCert(matchedRoute.ClientCert, req); code != "" {
+		r.log.Warn("client certificate rejected", zap.String("route", matchedRoute.Path), zap.String("code", code))
+		WriteError(w, code, "client certificate rejected", req.Header.Get("X-Request-ID"), status)
+
+		return
+	}
+
+	// Websocket connections and streams are long-lived. Websockets are limited by the route max connections
+	// and streams by the route concurrency limit instead.
+	if matchedRoute.Type == RouteTypeWebSocket || matchedRoute.Type == RouteTypeStream {
+		releaseSlot(token, false)
+	}
+
 	if r.rateLimiter != nil {
 		if !r.rateLimiter.Allow(extractClientIP(req)) {
+			r.metrics.IncRateLimitRejections(matchedRoute.Path, rateLimitKeyGlobal)
 			WriteError(w, ErrorCodeRateLimitExceeded, "rate limit exceeded", req.Header.Get("X-Request-ID"), http.StatusTooManyRequests)
 			return
 		}
@@ -139,6 +331,46 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 		requestID := getOrCreateRequestID(req)
 
+		if r.accessLog != nil {
+			accessEntryFromContext(req.Context()).setRequest(req, requestID, r.accessLog.userClaim)
+		}
+
+		// Route rate limits are checked after middlewares, so keys can use data set by them (e.g. JWT claims).
+		for _, rl := range matchedRoute.RateLimits {
+			if !rl.Allow(req) {
+				r.metrics.IncRateLimitRejections(matchedRoute.Path, rl.KeyType())
+				r.log.Debug("route rate limit exceeded", zap.String("rule", rl.Name()), zap.String("route", matchedRoute.Path))
+				WriteError(w, ErrorCodeRateLimitExceeded, "rate limit exceeded", requestID, http.StatusTooManyRequests)
+
+				return
+			}
+		}
+
+		if matchedRoute.Type == RouteTypeWebSocket {
+			r.serveWebSocket(w, req, matchedRoute, requestID)
+			return
+		}
+
+		routeToken, ok := matchedRoute.Concurrency.acquire(w, req, requestID)
+		if !ok {
+			r.log.Warn("route concurrency limit exceeded, request shed", zap.String("route", matchedRoute.Path))
+			r.metrics.IncFailedRequestsTotal(metric.FailReasonOverloaded)
+
+			return
+		}
+
+		if matchedRoute.Type == RouteTypeStream {
+			// Stream durations do not reflect upstream load, so they never shrink an adaptive limit.
+			defer releaseSlot(routeToken, false)
+
+			r.serveStream(w, req, matchedRoute, requestID)
+
+			return
+		}
+
+		var overloaded bool
+		defer func() { releaseSlot(routeToken, overloaded) }()
+
 		// Request-phase plugins
 		for _, p := range matchedRoute.Plugins {
 			if p.Type() != PluginTypeRequest {
@@ -147,7 +379,7 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 			r.log.Debug("executing request plugin", zap.String("name", p.Info().Name))
 
-			if err := p.Execute(tctx); err != nil {
+			if err := r.executePlugin(req.Context(), matchedRoute, p, tctx); err != nil {
 				r.log.Error("failed to execute request plugin", zap.String("name", p.Info().Name), zap.Error(err))
 				WriteError(w, ErrorCodeInternal, "internal error", requestID, http.StatusInternalServerError)
 
@@ -165,6 +397,8 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 
+		overloaded = isOverloadResponse(responses)
+
 		headers := http.Header{
 			"X-Request-ID": []string{requestID},
 			// TODO: Think about several encoding options
@@ -179,15 +413,24 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			}
 		}
 
-		r.log.Debug("dispatched responses", zap.Any("responses", responses))
+		r.log.Debug("dispatched responses", zap.Array("responses", loggedResponses{responses: responses, redactor: r.redaction()}))
 
 		// Aggregate upstream responses
+		_, aggregateSpan := startSpan(req.Context(), "aggregate",
+			trace.WithAttributes(attribute.String("kono.aggregation.strategy", matchedRoute.Aggregation.Strategy)),
+		)
 		aggregated := r.aggregator.aggregate(responses, matchedRoute.Aggregation)
+		aggregateSpan.SetAttributes(attribute.Bool("kono.aggregation.partial", aggregated.Partial))
+		aggregateSpan.End()
+
+		if aggregated.Partial {
+			r.metrics.IncPartialResponsesTotal(matchedRoute.Path)
+		}
 		attachRequestID(aggregated.Errors, requestID)
 
 		r.log.Debug("aggregated responses",
 			zap.String("strategy", matchedRoute.Aggregation.Strategy),
-			zap.Any("aggregated", aggregated),
+			zap.Object("aggregated", loggedAggregated{aggregated: &aggregated, redactor: r.redaction()}),
 		)
 
 		var responseBody []byte
@@ -233,7 +476,7 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 			r.log.Debug("executing response plugin", zap.String("name", p.Info().Name))
 
-			if err := p.Execute(tctx); err != nil {
+			if err := r.executePlugin(req.Context(), matchedRoute, p, tctx); err != nil {
 				r.log.Error("failed to execute response plugin", zap.String("name", p.Info().Name), zap.Error(err))
 				WriteError(w, ErrorCodeInternal, "internal error", requestID, http.StatusInternalServerError)
 
@@ -241,20 +484,26 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			}
 		}
 
-		r.metrics.IncResponsesTotal(matchedRoute.Path, tctx.Response().StatusCode) //nolint:bodyclose // body closes in copyResponse
+		r.metrics.IncResponsesTotal(matchedRoute.Path, tctx.Response().StatusCode, responseVariants(responses)) //nolint:bodyclose // body closes in copyResponse
 
 		// Write final output.
-		copyResponse(w, tctx.Response()) //nolint:bodyclose // body closes in copyResponse
+		written := copyResponse(w, tctx.Response()) //nolint:bodyclose // body closes in copyResponse
+		r.metrics.ObserveResponseSize(matchedRoute.Path, int(written))
 	})
 
 	for i := len(matchedRoute.Middlewares) - 1; i >= 0; i-- {
-		routeHandler = matchedRoute.Middlewares[i].Handler(routeHandler)
+		routeHandler = r.measureMiddleware(matchedRoute, matchedRoute.Middlewares[i], routeHandler)
+
+		if r.tracerProvider != nil {
+			routeHandler = traceMiddleware(matchedRoute.Middlewares[i].Name(), routeHandler)
+		}
 	}
 
 	routeHandler.ServeHTTP(w, req)
 }
 
-// match matches the given request to a route.
+// match matches the given request to a route. Path parameters of the matched route
+// are set to the request and available via req.PathValue.
 func (r *Router) match(req *http.Request) *Route {
 	for i := range r.Routes {
 		route := &r.Routes[i]
@@ -263,16 +512,62 @@ func (r *Router) match(req *http.Request) *Route {
 			continue
 		}
 
-		if route.Path != "" && req.URL.Path == route.Path {
-			return route
+		if route.Path == "" {
+			continue
+		}
+
+		params, ok := matchPath(route.Path, req.URL.Path)
+		if !ok {
+			continue
 		}
+
+		for name, value := range params {
+			req.SetPathValue(name, value)
+		}
+
+		return route
 	}
 
 	return nil
 }
 
-// copyResponse copies the *http.Response to the http.ResponseWriter.
-func copyResponse(w http.ResponseWriter, resp *http.Response) {
+// matchPath reports whether the path matches the route template. A template segment in braces,
+// e.g. {id} in /users/{id}, matches any non-empty path segment and is returned as a parameter.
+func matchPath(template, path string) (map[string]string, bool) {
+	if !strings.Contains(template, "{") {
+		return nil, template == path
+	}
+
+	templateSegments := strings.Split(template, "/")
+	pathSegments := strings.Split(path, "/")
+
+	if len(templateSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+
+	for i, ts := range templateSegments {
+		if strings.HasPrefix(ts, "{") && strings.HasSuffix(ts, "}") {
+			if pathSegments[i] == "" {
+				return nil, false
+			}
+
+			params[ts[1:len(ts)-1]] = pathSegments[i]
+
+			continue
+		}
+
+		if ts != pathSegments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// copyResponse copies the *http.Response to the http.ResponseWriter and returns the number of body bytes written.
+func copyResponse(w http.ResponseWriter, resp *http.Response) int64 {
 	for k, vv := range resp.Header {
 		for _, v := range vv {
 			w.Header().Set(k, v)
@@ -280,10 +575,49 @@ func copyResponse(w http.ResponseWriter, resp *http.Response) {
 	}
 
 	w.WriteHeader(resp.StatusCode)
+
+	var written int64
 	if resp.Body != nil {
-		_, _ = io.Copy(w, resp.Body)
+		written, _ = io.Copy(w, resp.Body)
 		_ = resp.Body.Close()
 	}
+
+	return written
+}
+
+// executePlugin executes the plugin in a span and records its duration.
+func (r *Router) executePlugin(ctx context.Context, route *Route, p Plugin, tctx Context) error {
+	_, span := startSpan(ctx, "plugin "+p.Info().Name)
+
+	start := time.Now()
+	err := p.Execute(tctx)
+	r.metrics.UpdatePluginDuration(route.Path, p.Info().Name, time.Since(start))
+
+	endSpanWithError(span, err)
+
+	return err
+}
+
+// measureMiddleware wraps the middleware handler, recording the duration of the middleware itself
+// without the rest of the chain. The chain is built per request, so the handler is not shared.
+func (r *Router) measureMiddleware(route *Route, mw Middleware, next http.Handler) http.Handler {
+	var nextDuration atomic.Int64
+
+	handler := mw.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		defer func() { nextDuration.Store(int64(time.Since(start))) }()
+
+		next.ServeHTTP(w, req)
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		defer func() {
+			r.metrics.UpdateMiddlewareDuration(route.Path, mw.Name(), time.Since(start)-time.Duration(nextDuration.Load()))
+		}()
+
+		handler.ServeHTTP(w, req)
+	})
 }
 
 func attachRequestID(errs []JSONError, requestID string) {
@@ -302,22 +636,14 @@ func mustMarshal(v any) []byte {
 	return b
 }
 
+// extractClientIP returns the client address resolved by the router with respect to trusted proxies.
+// Requests that did not pass the router are attributed to the immediate peer.
 func extractClientIP(r *http.Request) string {
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		parts := strings.Split(xff, ",")
-		return strings.TrimSpace(parts[0])
-	}
-
-	if xrip := r.Header.Get("X-Real-IP"); xrip != "" {
-		return xrip
-	}
-
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err == nil {
-		return host
+	if ip, ok := ClientIPFromContext(r.Context()); ok {
+		return ip
 	}
 
-	return r.RemoteAddr
+	return remoteHost(r.RemoteAddr)
 }
 
 func getOrCreateRequestID(r *http.Request) string {
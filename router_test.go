@@ -10,6 +10,7 @@ import (
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -35,6 +36,28 @@ func (m *mockDispatcher) dispatch(_ *Route, _ *http.Request) []UpstreamResponse
 	return m.results
 }
 
+// newTestRouter returns a router serving the routes through the dispatcher with the default aggregator,
+// discarding logs and metrics. Feature tests set the remaining router fields themselves.
+func newTestRouter(d dispatcher, routes ...Route) *Router {
+	return &Router{
+		dispatcher: d,
+		aggregator: &defaultAggregator{log: zap.NewNop()},
+		Routes:     routes,
+		log:        zap.NewNop(),
+		metrics:    metric.NewNop(),
+	}
+}
+
+// newOKDispatcher returns a dispatcher answering every request with a successful upstream response.
+func newOKDispatcher() *mockDispatcher {
+	return &mockDispatcher{results: []UpstreamResponse{{Status: http.StatusOK, Body: []byte(`{"ok":true}`)}}}
+}
+
+// newTestDispatcher returns the default dispatcher, discarding logs and metrics.
+func newTestDispatcher() *defaultDispatcher {
+	return &defaultDispatcher{log: zap.NewNop(), metrics: metric.NewNop()}
+}
+
 type mockPlugin struct {
 	name string
 	typ  PluginType
@@ -386,3 +409,118 @@ func TestRouter_ServeHTTP_WithMiddleware(t *testing.T) {
 		t.Errorf("middleware not executed, header=%q", got)
 	}
 }
+
+func TestBuildRouter_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		set  RouterConfigSet
+		want string
+	}{
+		{
+			name: "trusted proxies",
+			set:  RouterConfigSet{TrustedProxies: []string{"not-a-cidr"}},
+			want: "trusted proxies",
+		},
+		{
+			name: "upstream transport",
+			set: RouterConfigSet{
+				Features: []FeatureConfig{{Name: "ratelimit", Enabled: true}},
+				Routes: []RouteConfig{
+					{
+						Path:   "/users",
+						Method: http.MethodGet,
+						Upstreams: []UpstreamConfig{
+							{Name: "users", Hosts: []string{"https://users.local"}, TLS: UpstreamTLSConfig{CAFile: "/nonexistent/ca.pem"}},
+						},
+					},
+				},
+			},
+			want: "cannot initialize upstream transport",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := BuildRouter(tt.set, zap.NewNop())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %q error, got %v", tt.want, err)
+			}
+
+			if router != nil {
+				t.Fatal("expected no router")
+			}
+		})
+	}
+}
+
+func TestRouter_Close(t *testing.T) {
+	srv, _ := newCountingUpstream(t, http.StatusOK)
+
+	router, err := BuildRouter(RouterConfigSet{
+		Features: []FeatureConfig{{Name: "ratelimit", Enabled: true}},
+		Routes: []RouteConfig{
+			{
+				Path:                 "/users",
+				Method:               http.MethodGet,
+				Upstreams:            []UpstreamConfig{{Name: "users", Hosts: []string{srv.URL}, Method: http.MethodGet, Timeout: time.Second}},
+				Aggregation:          AggregationConfig{Strategy: strategyMerge},
+				MaxParallelUpstreams: 1,
+				RateLimits: []RateLimitConfig{
+					{Name: "per-ip", Keys: []RateLimitKeyConfig{{Type: rateLimitKeyIP}}, Limit: 10},
+				},
+			},
+		},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+
+	router.Close()
+
+	// Closing twice and closing routers without transports is safe.
+	router.Close()
+	(&Router{}).Close()
+
+	// A closed router still serves requests in flight during its retirement.
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status after close %d", rec.Code)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	cfg := Config{
+		ConfigVersion: "v1",
+		Name:          "test",
+		Version:       "1",
+		Server:        ServerConfig{Port: 8080},
+		Routes: []RouteConfig{
+			{Path: "/users", Method: http.MethodGet, Upstreams: []UpstreamConfig{{Hosts: []string{"http://localhost:8081"}}}},
+		},
+	}
+
+	err := ValidateConfig(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "routes[0].upstreams[0].method: field is required") {
+		t.Fatalf("expected validation error with json field names, got %v", err)
+	}
+
+	if cfg.Server.Timeout != defaultServerTimeout {
+		t.Fatalf("expected defaults to be applied, got timeout %s", cfg.Server.Timeout)
+	}
+
+	cfg.Routes[0].Upstreams[0].Method = http.MethodGet
+	cfg.Routes[0].Aggregation.Strategy = strategyMerge
+
+	if err = ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+}
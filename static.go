@@ -0,0 +1,161 @@
+package kono
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// staticUpstream returns a configured response without calling a backend, e.g. to mock services during
+// frontend development or to serve fallback data during an incident. It takes part in aggregation like
+// any other upstream.
+type staticUpstream struct {
+	name     string
+	policy   Policy
+	timeout  time.Duration
+	status   int
+	headers  map[string]string
+	body     string
+	template bool
+	latency  time.Duration
+
+	// jsonBody is set if the body is JSON, which is the default without a Content-Type header. Templated values
+	// are escaped in JSON bodies.
+	jsonBody bool
+}
+
+func newStaticUpstream(name string, timeout time.Duration, policy Policy, cfg StaticConfig) (*staticUpstream, error) {
+	body := cfg.Body
+
+	if cfg.BodyFile != "" {
+		data, err := os.ReadFile(cfg.BodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read static body: %w", err)
+		}
+
+		body = string(data)
+	}
+
+	status := cfg.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	var contentType string
+
+	for name, value := range cfg.Headers {
+		if strings.EqualFold(name, "Content-Type") {
+			contentType = strings.ToLower(value)
+		}
+	}
+
+	return &staticUpstream{
+		name:     name,
+		policy:   policy,
+		timeout:  timeout,
+		status:   status,
+		headers:  cfg.Headers,
+		body:     body,
+		template: cfg.Template,
+		latency:  cfg.Latency,
+		jsonBody: contentType == "" || strings.Contains(contentType, "json"),
+	}, nil
+}
+
+func (s *staticUpstream) Name() string   { return s.name }
+func (s *staticUpstream) Policy() Policy { return s.policy }
+
+func (s *staticUpstream) Call(ctx context.Context, original *http.Request, originalBody []byte) *UpstreamResponse {
+	uresp := &UpstreamResponse{
+		Headers: make(http.Header),
+	}
+
+	if uerr := s.wait(ctx); uerr != nil {
+		uresp.Err = uerr
+		return uresp
+	}
+
+	uresp.Status = s.status
+
+	// Server errors fail the upstream the same way as real ones.
+	if s.status >= http.StatusInternalServerError {
+		uresp.Err = &UpstreamError{
+			Kind: UpstreamBadStatus,
+			Err:  errors.New("upstream error"),
+		}
+
+		return uresp
+	}
+
+	data := newRequestData(original, originalBody)
+
+	for name, value := range s.headers {
+		rendered, err := s.render(data, value)
+		if err != nil {
+			uresp.Err = &UpstreamError{Kind: UpstreamInternal, Err: err}
+			return uresp
+		}
+
+		uresp.Headers.Set(name, rendered)
+	}
+
+	body, err := s.renderBody(data)
+	if err != nil {
+		uresp.Err = &UpstreamError{Kind: UpstreamInternal, Err: err}
+		return uresp
+	}
+
+	if body != "" {
+		uresp.Body = []byte(body)
+	}
+
+	return uresp
+}
+
+// wait simulates the configured latency. Latency exceeding the upstream timeout fails like a slow backend.
+func (s *staticUpstream) wait(ctx context.Context) *UpstreamError {
+	if s.latency <= 0 {
+		return nil
+	}
+
+	delay, timedOut := s.latency, false
+	if s.timeout > 0 && s.timeout < delay {
+		delay, timedOut = s.timeout, true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return &UpstreamError{Kind: UpstreamCanceled, Err: ctx.Err()}
+	case <-timer.C:
+	}
+
+	if timedOut {
+		return &UpstreamError{Kind: UpstreamTimeout, Err: context.DeadlineExceeded}
+	}
+
+	return nil
+}
+
+func (s *staticUpstream) render(data *requestData, value string) (string, error) {
+	if !s.template {
+		return value, nil
+	}
+
+	return data.renderString(value)
+}
+
+// renderBody renders the body template. JSON bodies escape the interpolated values.
+func (s *staticUpstream) renderBody(data *requestData) (string, error) {
+	if s.template && s.jsonBody {
+		return data.renderJSON(s.body)
+	}
+
+	return s.render(data, s.body)
+}
@@ -0,0 +1,229 @@
+package kono
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+func newTestStaticUpstream(t *testing.T, timeout time.Duration, cfg StaticConfig) Upstream {
+	t.Helper()
+
+	return initUpstreams([]UpstreamConfig{
+		{Type: UpstreamTypeStatic, Timeout: timeout, Static: cfg},
+	}, newTransportRegistry(), zap.NewNop())[0]
+}
+
+func TestStaticUpstream_TemplatedResponse(t *testing.T) {
+	upstream := newTestStaticUpstream(t, time.Second, StaticConfig{
+		Status:   http.StatusCreated,
+		Headers:  map[string]string{"X-User": "{{ path.id }}"},
+		Body:     `{"id":"{{ path.id }}","tags":{{ body.tags }},"source":"mock"}`,
+		Template: true,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/42", nil)
+	req.SetPathValue("id", "42")
+
+	resp := upstream.Call(req.Context(), req, []byte(`{"tags":["a","b"]}`))
+	if resp.Err != nil {
+		t.Fatalf("unexpected error: %v", resp.Err.Unwrap())
+	}
+
+	if resp.Status != http.StatusCreated || resp.Headers.Get("X-User") != "42" {
+		t.Fatalf("unexpected response %d %v", resp.Status, resp.Headers)
+	}
+
+	if string(resp.Body) != `{"id":"42","tags":["a","b"],"source":"mock"}` {
+		t.Fatalf("unexpected body %s", resp.Body)
+	}
+
+	if upstream.Name() != "STATIC" {
+		t.Fatalf("unexpected name %q", upstream.Name())
+	}
+}
+
+func TestStaticUpstream_TemplateEscapesJSON(t *testing.T) {
+	const injection = `"}, "admin": true, "x": "`
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    string
+		want    string
+	}{
+		{
+			name: "inside string",
+			body: `{"id":"{{ path.id }}"}`,
+			want: `{"id":"\"}, \"admin\": true, \"x\": \""}`,
+		},
+		{
+			name: "bare value",
+			body: `{"id":{{ path.id }}}`,
+			want: `{"id":"\"}, \"admin\": true, \"x\": \""}`,
+		},
+		{
+			name:    "plain text",
+			headers: map[string]string{"Content-Type": "text/plain"},
+			body:    `id={{ path.id }}`,
+			want:    "id=" + injection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := newTestStaticUpstream(t, time.Second, StaticConfig{
+				Headers:  tt.headers,
+				Body:     tt.body,
+				Template: true,
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/users/x", nil)
+			req.SetPathValue("id", injection)
+
+			resp := upstream.Call(req.Context(), req, nil)
+			if resp.Err != nil {
+				t.Fatalf("unexpected error: %v", resp.Err.Unwrap())
+			}
+
+			if string(resp.Body) != tt.want {
+				t.Fatalf("unexpected body %s", resp.Body)
+			}
+		})
+	}
+}
+
+func TestStaticUpstream_BodyFileWithoutTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(path, []byte(`{"id":"{{ path.id }}"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	upstream := newTestStaticUpstream(t, 0, StaticConfig{BodyFile: path})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err != nil || resp.Status != http.StatusOK || string(resp.Body) != `{"id":"{{ path.id }}"}` {
+		t.Fatalf("expected the file as is, got %d %s %v", resp.Status, resp.Body, resp.Err)
+	}
+}
+
+func TestStaticUpstream_Latency(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	upstream := newTestStaticUpstream(t, time.Second, StaticConfig{Body: `{}`, Latency: 50 * time.Millisecond})
+
+	start := time.Now()
+	if resp := upstream.Call(req.Context(), req, nil); resp.Err != nil {
+		t.Fatalf("unexpected error: %v", resp.Err.Unwrap())
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected the response to be delayed, took %s", elapsed)
+	}
+
+	upstream = newTestStaticUpstream(t, 20*time.Millisecond, StaticConfig{Body: `{}`, Latency: time.Second})
+
+	if resp := upstream.Call(req.Context(), req, nil); resp.Err == nil || resp.Err.Kind != UpstreamTimeout {
+		t.Fatalf("expected a timeout, got %+v", resp.Err)
+	}
+}
+
+func TestStaticUpstream_ServerErrorStatus(t *testing.T) {
+	upstream := newTestStaticUpstream(t, 0, StaticConfig{Status: http.StatusServiceUnavailable, Body: `{}`})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	resp := upstream.Call(req.Context(), req, nil)
+	if resp.Err == nil || resp.Err.Kind != UpstreamBadStatus || resp.Status != http.StatusServiceUnavailable {
+		t.Fatalf("expected bad_status 503, got %d %+v", resp.Status, resp.Err)
+	}
+}
+
+func TestStaticUpstream_AggregatesWithMerge(t *testing.T) {
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"orders":[1,2]}`))
+	}))
+	defer rest.Close()
+
+	upstreams := initUpstreams([]UpstreamConfig{
+		{Hosts: []string{rest.URL}, Method: http.MethodGet, Timeout: time.Second},
+		{Type: UpstreamTypeMock, Static: StaticConfig{Body: `{"user":{"id":"{{ path.id }}"}}`, Template: true}},
+	}, newTransportRegistry(), zap.NewNop())
+
+	r := &Router{
+		dispatcher: &defaultDispatcher{log: zap.NewNop(), metrics: metric.NewNop()},
+		aggregator: &defaultAggregator{log: zap.NewNop()},
+		Routes: []Route{
+			{
+				Path:                 "/users/{id}",
+				Method:               http.MethodGet,
+				Upstreams:            upstreams,
+				Aggregation:          AggregationConfig{Strategy: strategyMerge},
+				MaxParallelUpstreams: 2,
+			},
+		},
+		log:     zap.NewNop(),
+		metrics: metric.NewNop(),
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/5", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var out JSONResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+
+	if string(out.Data) != `{"orders":[1,2],"user":{"id":"5"}}` {
+		t.Fatalf("unexpected merged data %s", out.Data)
+	}
+}
+
+func TestStaticUpstream_ConfigValidation(t *testing.T) {
+	const cfg = `
+config_version: v1
+name: test
+version: "1"
+server:
+  port: 8080
+routes:
+  - path: /users/{id}
+    method: GET
+    aggregation:
+      strategy: merge
+    upstreams:
+      - type: static
+        static:
+          body: '{"id":1}'
+      - method: GET
+`
+
+	path := filepath.Join(t.TempDir(), "kono.yaml")
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error for http upstream without hosts")
+	}
+
+	if !strings.Contains(err.Error(), "upstreams[1].hosts") || strings.Contains(err.Error(), "upstreams[0]") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,254 @@
+package kono
+
+import (
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// StatsWindow is the period covered by route statistics.
+	StatsWindow = time.Minute
+
+	statsBuckets = int64(StatsWindow / time.Second)
+
+	// statsLatencySamples bounds the latency samples kept per route and second.
+	statsLatencySamples = 128
+)
+
+// TrafficStats keeps rolling per-route request statistics of the last StatsWindow in memory, e.g. for the dashboard.
+// It is created by the caller, so that statistics outlive the router. Routes without traffic during the last
+// StatsWindow are dropped, e.g. those removed by a config reload.
+type TrafficStats struct {
+	mu     sync.RWMutex
+	routes map[string]*routeStats
+
+	now func() time.Time
+}
+
+func NewTrafficStats() *TrafficStats {
+	return &TrafficStats{
+		routes: make(map[string]*routeStats),
+		now:    time.Now,
+	}
+}
+
+// RouteStats describes the traffic of a route during the last StatsWindow with the health of its upstreams.
+// RequestRate is per second, ErrorRate is the share of responses with 5xx status. Latencies of websocket and
+// stream routes are not tracked, as they reflect the connection lifetime.
+type RouteStats struct {
+	Path        string         `json:"path"`
+	Method      string         `json:"method"`
+	Requests    int64          `json:"requests"`
+	Errors      int64          `json:"errors"`
+	RequestRate float64        `json:"request_rate"`
+	ErrorRate   float64        `json:"error_rate"`
+	Latency     LatencyStats   `json:"latency_ms"`
+	Upstreams   []UpstreamInfo `json:"upstreams"`
+}
+
+// LatencyStats are latency percentiles in milliseconds.
+type LatencyStats struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// RoutesStats returns the statistics of every route. Counters are zero if the router has no traffic stats.
+func (r *Router) RoutesStats() []RouteStats {
+	stats := make([]RouteStats, 0, len(r.Routes))
+
+	for i := range r.Routes {
+		route := &r.Routes[i]
+
+		rs := RouteStats{
+			Path:      route.Path,
+			Method:    route.Method,
+			Upstreams: make([]UpstreamInfo, 0, len(route.Upstreams)),
+		}
+
+		if r.stats != nil {
+			if s := r.stats.lookup(route.Method, route.Path); s != nil {
+				s.snapshot(r.stats.now(), &rs)
+			}
+		}
+
+		for _, u := range route.Upstreams {
+			rs.Upstreams = append(rs.Upstreams, upstreamInfos(u)...)
+		}
+
+		stats = append(stats, rs)
+	}
+
+	return stats
+}
+
+// recordStats wraps the writer to record the response of the route when the returned function is called.
+func (r *Router) recordStats(w http.ResponseWriter, route *Route) (http.ResponseWriter, func()) {
+	if r.stats == nil {
+		return w, func() {}
+	}
+
+	start := r.stats.now()
+	sw := &statusWriter{ResponseWriter: w}
+	timed := route.Type != RouteTypeWebSocket && route.Type != RouteTypeStream
+
+	return sw, func() {
+		status := sw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		now := r.stats.now()
+		r.stats.route(route.Method, route.Path).record(now, status >= http.StatusInternalServerError, now.Sub(start), timed)
+	}
+}
+
+// lookup returns the statistics of the route, nil if it had no traffic during the last StatsWindow.
+func (s *TrafficStats) lookup(method, path string) *routeStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.routes[method+" "+path]
+}
+
+// route returns the statistics of the route to record into, creating them if needed. Creation drops the
+// statistics of routes without traffic during the last StatsWindow, so the map stays bounded by live routes.
+func (s *TrafficStats) route(method, path string) *routeStats {
+	key := method + " " + path
+
+	if rs := s.lookup(method, path); rs != nil {
+		return rs
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rs, ok := s.routes[key]
+	if ok {
+		return rs
+	}
+
+	second := s.now().Unix()
+
+	for k, stale := range s.routes {
+		if stale.idle(second) {
+			delete(s.routes, k)
+		}
+	}
+
+	rs = &routeStats{last: second}
+	s.routes[key] = rs
+
+	return rs
+}
+
+// routeStats is a ring of per-second buckets.
+type routeStats struct {
+	mu      sync.Mutex
+	buckets [statsBuckets]statsBucket
+	last    int64 // Second of the latest record.
+}
+
+type statsBucket struct {
+	second   int64
+	requests int64
+	errors   int64
+
+	// latencies is a uniform sample of the second's latencies, offered counts the sampled requests.
+	latencies []time.Duration
+	offered   int64
+}
+
+func (s *routeStats) record(now time.Time, failed bool, latency time.Duration, timed bool) {
+	second := now.Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := &s.buckets[second%statsBuckets]
+	if b.second != second {
+		b.second = second
+		b.requests, b.errors, b.offered = 0, 0, 0
+		b.latencies = b.latencies[:0]
+	}
+
+	s.last = max(s.last, second)
+
+	b.requests++
+	if failed {
+		b.errors++
+	}
+
+	if !timed {
+		return
+	}
+
+	// Reservoir sampling keeps the memory per bucket bounded under heavy traffic.
+	b.offered++
+
+	if len(b.latencies) < statsLatencySamples {
+		b.latencies = append(b.latencies, latency)
+	} else if i := rand.Int64N(b.offered); i < statsLatencySamples { //nolint:gosec // sampling
+		b.latencies[i] = latency
+	}
+}
+
+// idle reports whether the route had no traffic during the window ending at the second.
+func (s *routeStats) idle(second int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return second-s.last >= statsBuckets
+}
+
+func (s *routeStats) snapshot(now time.Time, rs *RouteStats) {
+	second := now.Unix()
+
+	var latencies []time.Duration
+
+	s.mu.Lock()
+
+	for i := range s.buckets {
+		b := &s.buckets[i]
+
+		if age := second - b.second; age < 0 || age >= statsBuckets {
+			continue
+		}
+
+		rs.Requests += b.requests
+		rs.Errors += b.errors
+		latencies = append(latencies, b.latencies...)
+	}
+
+	s.mu.Unlock()
+
+	rs.RequestRate = float64(rs.Requests) / StatsWindow.Seconds()
+	if rs.Requests > 0 {
+		rs.ErrorRate = float64(rs.Errors) / float64(rs.Requests)
+	}
+
+	slices.Sort(latencies)
+
+	rs.Latency = LatencyStats{
+		P50: percentile(latencies, 0.5),
+		P90: percentile(latencies, 0.9),
+		P95: percentile(latencies, 0.95),
+		P99: percentile(latencies, 0.99),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies in milliseconds.
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+
+	return milliseconds(sorted[min(max(rank, 0), len(sorted)-1)])
+}
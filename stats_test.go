@@ -0,0 +1,123 @@
+package kono
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRouter_RoutesStats(t *testing.T) {
+	healthy, _ := newCountingUpstream(t, http.StatusOK)
+	failing, _ := newCountingUpstream(t, http.StatusBadGateway)
+
+	r := newTestRouter(newTestDispatcher(), newAdminTestRoute(t, healthy.URL, failing.URL))
+	r.Routes[0].RateLimits = nil
+	r.stats = NewTrafficStats()
+
+	for range 4 {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	}
+
+	stats := r.RoutesStats()
+	if len(stats) != 1 {
+		t.Fatalf("expected 1 route, got %d", len(stats))
+	}
+
+	rs := stats[0]
+	if rs.Path != "/users" || rs.Requests != 4 || rs.Errors != 2 {
+		t.Fatalf("unexpected stats: %+v", rs)
+	}
+
+	if rs.ErrorRate != 0.5 || rs.RequestRate != 4/StatsWindow.Seconds() {
+		t.Fatalf("unexpected rates: %+v", rs)
+	}
+
+	if rs.Latency.P50 <= 0 || rs.Latency.P99 < rs.Latency.P50 {
+		t.Fatalf("unexpected latency: %+v", rs.Latency)
+	}
+
+	if len(rs.Upstreams) != 1 || len(rs.Upstreams[0].Hosts) != 2 {
+		t.Fatalf("expected upstream health, got %+v", rs.Upstreams)
+	}
+}
+
+func TestRouteStats_Window(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	var rs routeStats
+
+	for i := range 10 {
+		rs.record(now, i == 0, time.Duration(i+1)*time.Millisecond, true)
+	}
+
+	rs.record(now.Add(30*time.Second), true, 100*time.Millisecond, true)
+
+	var snap RouteStats
+	rs.snapshot(now.Add(30*time.Second), &snap)
+
+	if snap.Requests != 11 || snap.Errors != 2 {
+		t.Fatalf("unexpected counters: %+v", snap)
+	}
+
+	if snap.Latency.P50 != 6 || snap.Latency.P90 != 10 || snap.Latency.P99 != 100 {
+		t.Fatalf("unexpected latency: %+v", snap.Latency)
+	}
+
+	// The first second left the window.
+	snap = RouteStats{}
+	rs.snapshot(now.Add(StatsWindow), &snap)
+
+	if snap.Requests != 1 || snap.Latency.P50 != 100 {
+		t.Fatalf("expected expired buckets to be skipped, got %+v", snap)
+	}
+}
+
+func TestRouteStats_SamplesBounded(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	var rs routeStats
+
+	for range statsLatencySamples * 4 {
+		rs.record(now, false, time.Millisecond, true)
+	}
+
+	rs.record(now, false, 0, false)
+
+	b := rs.buckets[now.Unix()%statsBuckets]
+	if b.requests != statsLatencySamples*4+1 || len(b.latencies) != statsLatencySamples {
+		t.Fatalf("unexpected bucket: %d requests, %d samples", b.requests, len(b.latencies))
+	}
+}
+
+func TestRouter_RoutesStatsWithoutStats(t *testing.T) {
+	r := newTestRouter(newTestDispatcher(), newAdminTestRoute(t, "http://users.local"))
+
+	stats := r.RoutesStats()
+	if len(stats) != 1 || stats[0].Requests != 0 || len(stats[0].Upstreams) != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestTrafficStats_DropsIdleRoutes(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	stats := NewTrafficStats()
+	stats.now = func() time.Time { return now }
+
+	stats.route(http.MethodGet, "/old").record(now, false, time.Millisecond, true)
+
+	now = now.Add(StatsWindow / 2)
+	stats.route(http.MethodGet, "/users").record(now, false, time.Millisecond, true)
+
+	if len(stats.routes) != 2 {
+		t.Fatalf("expected routes with traffic in the window to be kept, got %d", len(stats.routes))
+	}
+
+	now = now.Add(StatsWindow / 2)
+	stats.route(http.MethodGet, "/new").record(now, false, time.Millisecond, true)
+
+	if stats.lookup(http.MethodGet, "/old") != nil || stats.lookup(http.MethodGet, "/users") == nil {
+		t.Fatalf("expected only the idle route to be dropped, got %d routes", len(stats.routes))
+	}
+}
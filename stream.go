@@ -0,0 +1,130 @@
+package kono
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+const streamBufferSize = 32 * 1024
+
+// hopByHopHeaders are connection specific and are not copied from streamed upstream responses.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// streamer is implemented by upstreams able to stream responses without buffering.
+type streamer interface {
+	stream(ctx context.Context, original *http.Request) (*http.Response, *UpstreamError)
+}
+
+// serveStream passes the response of the route upstream through to the client. The request body is streamed
+// to the upstream and every chunk of the response is flushed to the client as soon as it is read, so
+// server-sent events and chunked responses are delivered without delay. The upstream request is bound to the
+// client request context and is aborted when the client disconnects.
+func (r *Router) serveStream(w http.ResponseWriter, req *http.Request, route *Route, requestID string) {
+	if len(route.Upstreams) == 0 {
+		WriteError(w, ErrorCodeInternal, "internal error", requestID, http.StatusInternalServerError)
+		return
+	}
+
+	upstream := route.Upstreams[0]
+
+	s, ok := upstream.(streamer)
+	if !ok {
+		r.log.Error("upstream does not support streaming", zap.String("upstream", upstream.Name()))
+		WriteError(w, ErrorCodeInternal, "internal error", requestID, http.StatusInternalServerError)
+
+		return
+	}
+
+	start := time.Now()
+
+	resp, uerr := s.stream(req.Context(), req)
+	if uerr != nil {
+		if uerr.Kind == UpstreamCanceled {
+			r.log.Debug("client disconnected before upstream responded", zap.String("upstream", upstream.Name()))
+			return
+		}
+
+		r.log.Error("upstream stream failed", zap.String("upstream", upstream.Name()), zap.Error(uerr.Err))
+		r.metrics.IncFailedRequestsTotal(metric.FailReasonUpstreamError)
+		r.metrics.IncUpstreamResponsesTotal(route.Path, upstream.Name(), 0, errorKindLabel(uerr))
+
+		switch uerr.Kind {
+		case UpstreamCircuitOpen:
+			WriteError(w, ErrorCodeUpstreamUnavailable, "upstream unavailable", requestID, http.StatusServiceUnavailable)
+		case UpstreamTimeout:
+			WriteError(w, ErrorCodeUpstreamError, "upstream timeout", requestID, http.StatusGatewayTimeout)
+		default:
+			WriteError(w, ErrorCodeUpstreamError, "upstream error", requestID, http.StatusBadGateway)
+		}
+
+		return
+	}
+	defer resp.Body.Close()
+
+	r.metrics.UpdateUpstreamLatency(route.Path, route.Method, upstream.Name(), "", time.Since(start))
+	r.metrics.IncUpstreamResponsesTotal(route.Path, upstream.Name(), resp.StatusCode, "")
+
+	for k, vv := range resp.Header {
+		w.Header()[k] = vv
+	}
+
+	for _, h := range hopByHopHeaders {
+		w.Header().Del(h)
+	}
+
+	w.Header().Set("X-Request-ID", requestID)
+
+	// The server write timeout would cut long streams, they end when the upstream or the client closes.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.WriteHeader(resp.StatusCode)
+
+	r.metrics.IncResponsesTotal(route.Path, resp.StatusCode, "")
+
+	if err := copyFlushing(w, resp.Body); err != nil && req.Context().Err() == nil {
+		r.log.Warn("upstream stream interrupted", zap.String("upstream", upstream.Name()), zap.Error(err))
+	}
+}
+
+// copyFlushing copies src to w and flushes after every read. Writers that cannot flush are written to as is.
+func copyFlushing(w http.ResponseWriter, src io.Reader) error {
+	rc := http.NewResponseController(w)
+	buf := make([]byte, streamBufferSize)
+
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+
+			if ferr := rc.Flush(); ferr != nil && !errors.Is(ferr, http.ErrNotSupported) {
+				return ferr
+			}
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
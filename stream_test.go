@@ -0,0 +1,163 @@
+package kono
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newStreamTestRoute(upstream *httptest.Server, timeout time.Duration) Route {
+	upstreams := initUpstreams([]UpstreamConfig{
+		{Hosts: []string{upstream.URL}, Timeout: timeout, ForwardHeaders: []string{"Authorization"}},
+	}, newTransportRegistry(), zap.NewNop())
+
+	return Route{
+		Path:        "/events",
+		Method:      http.MethodPost,
+		Type:        RouteTypeStream,
+		Upstreams:   upstreams,
+		Middlewares: []Middleware{&mockMiddleware{}},
+	}
+}
+
+func TestStream_FlushesChunksAsTheyArrive(t *testing.T) {
+	next := make(chan struct{})
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+
+		for _, event := range []string{"first", string(body)} {
+			_, _ = io.WriteString(w, "data: "+event+"\n\n")
+			_ = http.NewResponseController(w).Flush()
+
+			<-next
+		}
+	}))
+	defer upstream.Close()
+
+	srv := httptest.NewServer(newTestRouter(&mockDispatcher{}, newStreamTestRoute(upstream, time.Second)))
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Post(srv.URL+"/events", "application/json", strings.NewReader(`prompt`))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected upstream content type, got %q", resp.Header.Get("Content-Type"))
+	}
+
+	if resp.Header.Get("X-Middleware") != "ok" || resp.Header.Get("X-Request-ID") == "" {
+		t.Fatalf("expected middleware and request id headers, got %v", resp.Header)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+
+	// The first event must arrive while the upstream is still blocked before the second one.
+	for _, want := range []string{"data: first\n", "\n"} {
+		line, err := reader.ReadString('\n')
+		if err != nil || line != want {
+			t.Fatalf("expected %q, got %q (%v)", want, line, err)
+		}
+	}
+
+	next <- struct{}{}
+
+	line, _ := reader.ReadString('\n')
+	if line != "data: prompt\n" {
+		t.Fatalf("expected the request body to be streamed upstream, got %q", line)
+	}
+
+	close(next)
+}
+
+func TestStream_ClientDisconnectCancelsUpstream(t *testing.T) {
+	canceled := make(chan struct{})
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "data: started\n\n")
+		_ = http.NewResponseController(w).Flush()
+
+		<-r.Context().Done()
+		close(canceled)
+	}))
+	defer upstream.Close()
+
+	srv := httptest.NewServer(newTestRouter(&mockDispatcher{}, newStreamTestRoute(upstream, time.Second)))
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Post(srv.URL+"/events", "application/json", nil)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+
+	if _, err = bufio.NewReader(resp.Body).ReadString('\n'); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	_ = resp.Body.Close()
+
+	select {
+	case <-canceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the upstream request to be canceled after the client disconnected")
+	}
+}
+
+func TestStream_TimeoutAppliesToHeadersOnly(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_ = http.NewResponseController(w).Flush()
+
+		time.Sleep(150 * time.Millisecond)
+		_, _ = io.WriteString(w, "done")
+	}))
+	defer upstream.Close()
+
+	srv := httptest.NewServer(newTestRouter(&mockDispatcher{}, newStreamTestRoute(upstream, 100*time.Millisecond)))
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Post(srv.URL+"/events", "application/json", nil)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || string(body) != "done" {
+		t.Fatalf("expected the stream to outlive the timeout, got %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestStream_HeadersTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	srv := httptest.NewServer(newTestRouter(&mockDispatcher{}, newStreamTestRoute(upstream, 50*time.Millisecond)))
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Post(srv.URL+"/events", "application/json", nil)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,281 @@
+package kono
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// templatePattern matches placeholders like {{ path.id }} or {{ query.limit | int }}.
+var templatePattern = regexp.MustCompile(`\{\{\s*([a-z]+)\.([^}|\s]+)\s*(?:\|\s*([a-z]+)\s*)?\}\}`)
+
+// requestData resolves template placeholders from the request. Supported sources are
+//
+//   - path.<name>   – route path parameter;
+//   - query.<name>  – query string parameter;
+//   - header.<name> – request header;
+//   - claim.<path>  – JWT claim set by the auth middleware, dots for nested claims;
+//   - body.<path>   – field of the JSON request body, dots for nested fields and array indices.
+//
+// Path, query and header values are strings; claims and body fields keep their JSON type.
+// The optional "| int", "| float", "| bool" and "| string" filters convert the value.
+type requestData struct {
+	req     *http.Request
+	rawBody []byte
+
+	body       any
+	bodyParsed bool
+}
+
+func newRequestData(req *http.Request, body []byte) *requestData {
+	return &requestData{
+		req:     req,
+		rawBody: body,
+	}
+}
+
+// render resolves placeholders in value. A string consisting of a single placeholder is replaced by
+// the resolved value with its type; placeholders inside longer strings are interpolated. Maps and slices
+// are rendered recursively, other values are returned as is. Missing values render as null or empty strings.
+func (d *requestData) render(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if m := templatePattern.FindStringSubmatch(v); m != nil && m[0] == strings.TrimSpace(v) {
+			return d.resolve(m[1], m[2], m[3])
+		}
+
+		return d.renderString(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+
+		for key, item := range v {
+			rendered, err := d.render(item)
+			if err != nil {
+				return nil, err
+			}
+
+			out[key] = rendered
+		}
+
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+
+		for i, item := range v {
+			rendered, err := d.render(item)
+			if err != nil {
+				return nil, err
+			}
+
+			out[i] = rendered
+		}
+
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+// renderString interpolates placeholders into s. Non-string values are written as JSON.
+func (d *requestData) renderString(s string) (string, error) {
+	var renderErr error
+
+	out := templatePattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		m := templatePattern.FindStringSubmatch(placeholder)
+
+		value, err := d.resolve(m[1], m[2], m[3])
+		if err != nil {
+			renderErr = err
+			return ""
+		}
+
+		return templateText(value)
+	})
+
+	return out, renderErr
+}
+
+// renderJSON interpolates placeholders into the JSON document s, so that request values cannot inject fields.
+// Inside string literals values are escaped as string content, elsewhere they are written as JSON values,
+// strings included.
+func (d *requestData) renderJSON(s string) (string, error) {
+	var (
+		out      strings.Builder
+		inString bool
+		last     int
+	)
+
+	for _, loc := range templatePattern.FindAllStringSubmatchIndex(s, -1) {
+		literal := s[last:loc[0]]
+		out.WriteString(literal)
+
+		inString = jsonStringState(literal, inString)
+
+		var filter string
+		if loc[6] >= 0 {
+			filter = s[loc[6]:loc[7]]
+		}
+
+		value, err := d.resolve(s[loc[2]:loc[3]], s[loc[4]:loc[5]], filter)
+		if err != nil {
+			return "", err
+		}
+
+		if inString {
+			value = templateText(value)
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("cannot encode template value: %w", err)
+		}
+
+		if inString {
+			// The quotes of the encoded string are part of the template.
+			encoded = encoded[1 : len(encoded)-1]
+		}
+
+		out.Write(encoded)
+
+		last = loc[1]
+	}
+
+	out.WriteString(s[last:])
+
+	return out.String(), nil
+}
+
+// jsonStringState reports whether a JSON document is inside a string literal after the text, given whether it
+// was before.
+func jsonStringState(text string, inString bool) bool {
+	for i := 0; i < len(text); i++ {
+		switch {
+		case inString && text[i] == '\\':
+			i++
+		case text[i] == '"':
+			inString = !inString
+		}
+	}
+
+	return inString
+}
+
+// templateText returns the text of a value interpolated into a string. Non-string values are written as JSON.
+func templateText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+func (d *requestData) resolve(source, name, filter string) (any, error) {
+	value, ok := d.lookup(source, name)
+	if !ok {
+		return nil, nil //nolint:nilnil // missing values render as null
+	}
+
+	return applyTemplateFilter(value, filter)
+}
+
+func (d *requestData) lookup(source, name string) (any, bool) {
+	switch source {
+	case "path":
+		value := d.req.PathValue(name)
+		return value, value != ""
+	case "query":
+		if !d.req.URL.Query().Has(name) {
+			return nil, false
+		}
+
+		return d.req.URL.Query().Get(name), true
+	case "header":
+		value := d.req.Header.Get(name)
+		return value, value != ""
+	case "claim":
+		claims, ok := ClaimsFromContext(d.req.Context())
+		if !ok {
+			return nil, false
+		}
+
+		return lookupPath(claims, name)
+	case "body":
+		if !d.bodyParsed {
+			d.bodyParsed = true
+			_ = json.Unmarshal(d.rawBody, &d.body)
+		}
+
+		return lookupPath(d.body, name)
+	default:
+		return nil, false
+	}
+}
+
+// lookupPath walks a decoded JSON value by a dotted path. Numeric segments index arrays.
+func lookupPath(value any, path string) (any, bool) {
+	for _, segment := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+
+			value = next
+		case []any:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+
+			value = v[idx]
+		default:
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+func applyTemplateFilter(value any, filter string) (any, error) {
+	if filter == "" {
+		return value, nil
+	}
+
+	s := fmt.Sprint(value)
+
+	switch filter {
+	case "string":
+		return s, nil
+	case "int":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %q to int", s)
+		}
+
+		return n, nil
+	case "float":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %q to float", s)
+		}
+
+		return f, nil
+	case "bool":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %q to bool", s)
+		}
+
+		return b, nil
+	default:
+		return nil, fmt.Errorf("unknown template filter %q", filter)
+	}
+}
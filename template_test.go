@@ -0,0 +1,63 @@
+package kono
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRequestData_Render(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users/42?limit=10&active=true", nil)
+	req.SetPathValue("id", "42")
+	req.Header.Set("X-Tenant", "acme")
+	req = req.WithContext(WithClaims(req.Context(), map[string]any{
+		"sub":  "u1",
+		"org":  map[string]any{"id": float64(7)},
+		"role": "admin",
+	}))
+
+	body := []byte(`{"user":{"name":"ann","tags":["a","b"]},"count":3}`)
+
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{name: "path", value: "{{ path.id }}", want: "42"},
+		{name: "query with filter", value: "{{query.limit|int}}", want: int64(10)},
+		{name: "bool filter", value: "{{ query.active | bool }}", want: true},
+		{name: "header", value: "{{ header.X-Tenant }}", want: "acme"},
+		{name: "nested claim keeps type", value: "{{ claim.org.id }}", want: float64(7)},
+		{name: "body object", value: "{{ body.user }}", want: map[string]any{"name": "ann", "tags": []any{"a", "b"}}},
+		{name: "body array index", value: "{{ body.user.tags.1 }}", want: "b"},
+		{name: "interpolation", value: "{{ claim.sub }}@{{ header.X-Tenant }}:{{ body.count }}", want: "u1@acme:3"},
+		{name: "missing", value: "{{ query.missing }}", want: nil},
+		{name: "missing interpolated", value: "id-{{ path.missing }}", want: "id-"},
+		{name: "literal", value: 5, want: 5},
+		{name: "nested", value: map[string]any{"ids": []any{"{{ path.id }}"}}, want: map[string]any{"ids": []any{"42"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newRequestData(req, body).render(tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRequestData_RenderInvalidFilter(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?limit=ten", nil)
+
+	for _, value := range []string{"{{ query.limit | int }}", "{{ query.limit | upper }}"} {
+		if _, err := newRequestData(req, nil).render(value); err == nil {
+			t.Fatalf("expected an error for %q", value)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package kono
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/starwalkn/kono"
+
+	propagatorTraceContext = "tracecontext"
+	propagatorBaggage      = "baggage"
+	propagatorB3           = "b3"
+	propagatorB3Multi      = "b3multi"
+)
+
+// NewTracerProvider creates a tracer provider exporting spans over OTLP/HTTP and the propagator of the
+// configured header formats. The provider must be shut down to flush pending spans.
+func NewTracerProvider(cfg TracingConfig, serviceName, serviceVersion string) (*sdktrace.TracerProvider, propagation.TextMapPropagator, error) {
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpointURL(cfg.Endpoint),
+	}
+
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+
+	if cfg.Timeout > 0 {
+		opts = append(opts, otlptracehttp.WithTimeout(cfg.Timeout))
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot create otlp exporter: %w", err)
+	}
+
+	if cfg.ServiceName != "" {
+		serviceName = cfg.ServiceName
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", serviceVersion),
+	))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Sampling decisions of upstream callers are respected, new traces are sampled by ratio.
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+
+	return provider, newPropagator(cfg.Propagators), nil
+}
+
+func newPropagator(names []string) propagation.TextMapPropagator {
+	propagators := make([]propagation.TextMapPropagator, 0, len(names))
+
+	for _, name := range names {
+		switch name {
+		case propagatorTraceContext:
+			propagators = append(propagators, propagation.TraceContext{})
+		case propagatorBaggage:
+			propagators = append(propagators, propagation.Baggage{})
+		case propagatorB3:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case propagatorB3Multi:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		}
+	}
+
+	return propagation.NewCompositeTextMapPropagator(propagators...)
+}
+
+type ctxKeyPropagator struct{}
+
+// traceRequest starts the server span of the request, continuing the trace from incoming propagation
+// headers. The returned function ends the span with the response status. Without tracing it is a no-op.
+func (r *Router) traceRequest(w http.ResponseWriter, req *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	if r.tracerProvider == nil {
+		return w, req, func() {}
+	}
+
+	ctx := r.propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+	ctx = context.WithValue(ctx, ctxKeyPropagator{}, r.propagator)
+
+	ctx, span := r.tracerProvider.Tracer(tracerName).Start(ctx, "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.path", req.URL.Path),
+			attribute.String("client.address", extractClientIP(req)),
+		),
+	)
+
+	sw := &statusWriter{ResponseWriter: w}
+
+	return sw, req.WithContext(ctx), func() {
+		if sw.status > 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", sw.status))
+
+			if sw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(sw.status))
+			}
+		}
+
+		span.End()
+	}
+}
+
+// traceMiddleware wraps a middleware handler with a span. The span includes the rest of the chain.
+func traceMiddleware(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, span := startSpan(req.Context(), "middleware "+name)
+		defer span.End()
+
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// startSpan starts a child of the span in ctx with the same tracer provider. Without a recording span
+// in ctx the returned span is a no-op.
+func startSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// endUpstreamSpan records the outcome of an upstream attempt and ends the span.
+func endUpstreamSpan(span trace.Span, resp *UpstreamResponse) {
+	if resp.Status > 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.Status))
+	}
+
+	if resp.Err != nil {
+		span.SetAttributes(attribute.String("error.type", string(resp.Err.Kind)))
+		span.SetStatus(codes.Error, string(resp.Err.Kind))
+	}
+
+	span.End()
+}
+
+// endSpanWithError marks the span failed if err is not nil and ends it.
+func endSpanWithError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// injectTraceHeaders writes the propagation headers of the span in ctx into an upstream request.
+func injectTraceHeaders(ctx context.Context, header http.Header) {
+	if p, ok := ctx.Value(ctxKeyPropagator{}).(propagation.TextMapPropagator); ok {
+		p.Inject(ctx, propagation.HeaderCarrier(header))
+	}
+}
+
+// statusWriter records the response status and body size for the request span and the access log.
+type statusWriter struct {
+	http.ResponseWriter
+
+	status  int
+	written int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+
+	return n, err
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer, e.g. to flush or hijack.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
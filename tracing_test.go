@@ -0,0 +1,203 @@
+package kono
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+const testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+// testCollector is an OTLP/HTTP collector stand-in recording exported spans by name.
+type testCollector struct {
+	mu    sync.Mutex
+	spans map[string]string // name -> trace id
+}
+
+func newTestCollector(t *testing.T) (*testCollector, string) {
+	t.Helper()
+
+	c := &testCollector{spans: make(map[string]string)}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var req coltracepb.ExportTraceServiceRequest
+		if err = proto.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		c.mu.Lock()
+		for _, rs := range req.GetResourceSpans() {
+			for _, ss := range rs.GetScopeSpans() {
+				for _, span := range ss.GetSpans() {
+					c.spans[span.GetName()] = hex.EncodeToString(span.GetTraceId())
+				}
+			}
+		}
+		c.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	return c, srv.URL + "/v1/traces"
+}
+
+func TestTracing_SpansAndPropagation(t *testing.T) {
+	collector, endpoint := newTestCollector(t)
+
+	var upstreamHeaders http.Header
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHeaders = r.Header.Clone()
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	t.Cleanup(upstream.Close)
+
+	provider, propagator, err := NewTracerProvider(TracingConfig{
+		Endpoint:    endpoint,
+		SampleRatio: 1,
+		Propagators: []string{propagatorTraceContext, propagatorB3Multi},
+	}, "kono-test", "1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Router{
+		dispatcher: &defaultDispatcher{log: zap.NewNop(), metrics: metric.NewNop()},
+		aggregator: &defaultAggregator{log: zap.NewNop()},
+		Routes: []Route{
+			{
+				Path:        "/orders/{id}",
+				Method:      http.MethodGet,
+				Middlewares: []Middleware{&mockMiddleware{}},
+				Plugins:     []Plugin{&mockPlugin{name: "enrich", typ: PluginTypeRequest, fn: func(Context) {}}},
+				Upstreams: initUpstreams([]UpstreamConfig{
+					{Name: "orders", Hosts: []string{upstream.URL}, Method: http.MethodGet, Timeout: time.Second},
+				}, newTransportRegistry(), zap.NewNop()),
+				Aggregation:          AggregationConfig{Strategy: strategyMerge},
+				MaxParallelUpstreams: 1,
+			},
+		},
+		log:            zap.NewNop(),
+		metrics:        metric.NewNop(),
+		tracerProvider: provider,
+		propagator:     propagator,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+	req.Header.Set("Traceparent", "00-"+testTraceID+"-00f067aa0ba902b7-01")
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err = provider.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"GET /orders/{id}", "middleware mockmw", "plugin enrich", "dispatch", "upstream orders", "aggregate"} {
+		traceID, ok := collector.spans[name]
+		if !ok {
+			t.Fatalf("span %q not exported, got %v", name, collector.spans)
+		}
+
+		if traceID != testTraceID {
+			t.Fatalf("span %q is not part of the incoming trace: %s", name, traceID)
+		}
+	}
+
+	if !strings.HasPrefix(upstreamHeaders.Get("Traceparent"), "00-"+testTraceID+"-") {
+		t.Fatalf("traceparent not propagated: %q", upstreamHeaders.Get("Traceparent"))
+	}
+
+	if upstreamHeaders.Get("X-B3-Traceid") != testTraceID || upstreamHeaders.Get("X-B3-Spanid") == "" {
+		t.Fatalf("b3 headers not propagated: %v", upstreamHeaders)
+	}
+}
+
+func TestTracing_Disabled(t *testing.T) {
+	var upstreamHeaders http.Header
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHeaders = r.Header.Clone()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(upstream.Close)
+
+	u := initUpstreams([]UpstreamConfig{
+		{Hosts: []string{upstream.URL}, Method: http.MethodGet, Timeout: time.Second},
+	}, newTransportRegistry(), zap.NewNop())[0]
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if resp := u.Call(req.Context(), req, nil); resp.Err != nil {
+		t.Fatalf("unexpected error: %v", resp.Err.Unwrap())
+	}
+
+	for name := range upstreamHeaders {
+		if slices.Contains([]string{"Traceparent", "X-B3-Traceid", "B3"}, name) {
+			t.Fatalf("unexpected trace header %s without tracing", name)
+		}
+	}
+}
+
+func TestTracing_ConfigValidation(t *testing.T) {
+	const cfg = `
+config_version: v1
+name: test
+version: "1"
+server:
+  port: 8080
+  tracing:
+    enabled: true
+    propagators: [tracecontext, jaeger]
+routes:
+  - path: /users
+    method: GET
+    upstreams:
+      - hosts: [http://localhost:8081]
+`
+
+	path := filepath.Join(t.TempDir(), "kono.yaml")
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error for tracing config")
+	}
+
+	if !strings.Contains(err.Error(), "tracing.endpoint") || !strings.Contains(err.Error(), "tracing.propagators[1]") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package kono
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/starwalkn/kono/internal/certs"
+)
+
+// transportKey identifies a transport profile. Upstreams with equal keys share a connection pool.
+type transportKey struct {
+	TLS       UpstreamTLSConfig
+	Transport TransportConfig
+}
+
+// transportRegistry builds and caches upstream transports by profile. A single registry is shared
+// by all routes of a router.
+type transportRegistry struct {
+	mu         sync.Mutex
+	transports map[transportKey]*http.Transport
+}
+
+func newTransportRegistry() *transportRegistry {
+	return &transportRegistry{
+		transports: make(map[transportKey]*http.Transport),
+	}
+}
+
+// closeIdleConnections closes idle connections of every transport.
+func (r *transportRegistry) closeIdleConnections() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, transport := range r.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+func (r *transportRegistry) get(tlsCfg UpstreamTLSConfig, transportCfg TransportConfig) (*http.Transport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Normalize, so configs relying on defaults share a transport with explicit ones.
+	ensureTransportDefaults(&transportCfg)
+
+	key := transportKey{TLS: tlsCfg, Transport: transportCfg}
+
+	if transport, ok := r.transports[key]; ok {
+		return transport, nil
+	}
+
+	transport, err := newTransport(tlsCfg, transportCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.transports[key] = transport
+
+	return transport, nil
+}
+
+func newTransport(tlsCfg UpstreamTLSConfig, cfg TransportConfig) (*http.Transport, error) {
+	tlsClientConfig, err := newUpstreamTLSConfig(tlsCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		DisableKeepAlives:     cfg.DisableKeepAlives,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+		TLSClientConfig:       tlsClientConfig,
+	}
+
+	switch {
+	case cfg.ProxyURL != "":
+		proxyURL, perr := url.Parse(cfg.ProxyURL)
+		if perr != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", perr)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	case cfg.ProxyFromEnvironment:
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+
+	protocols := new(http.Protocols)
+
+	switch {
+	case cfg.UnencryptedHTTP2:
+		// HTTP/1 must be disabled, otherwise plain http:// hosts are still called over HTTP/1.
+		protocols.SetUnencryptedHTTP2(true)
+		protocols.SetHTTP2(true)
+	case cfg.DisableHTTP2:
+		protocols.SetHTTP1(true)
+	default:
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	}
+
+	transport.Protocols = protocols
+
+	return transport, nil
+}
+
+// newUpstreamTLSConfig builds the client TLS configuration of an upstream.
+// The zero config returns nil, i.e. the default transport TLS settings.
+func newUpstreamTLSConfig(cfg UpstreamTLSConfig) (*tls.Config, error) {
+	if cfg == (UpstreamTLSConfig{}) {
+		return nil, nil //nolint:nilnil // nil config means the transport defaults
+	}
+
+	minVersion, err := certs.ParseVersion(cfg.MinVersion, tls.VersionTLS12)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         minVersion,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec // explicitly enabled for development setups
+	}
+
+	if cfg.CAFile != "" {
+		pool, perr := certs.LoadCertPool(cfg.CAFile)
+		if perr != nil {
+			return nil, perr
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert, cerr := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if cerr != nil {
+			return nil, fmt.Errorf("cannot load upstream client certificate: %w", cerr)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
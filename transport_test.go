@@ -0,0 +1,189 @@
+package kono
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+func writePEM(t *testing.T, path, blockType string, der []byte) string {
+	t.Helper()
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestInitUpstreams_TLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+
+	var gotClientCN string
+
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotClientCN = r.TLS.PeerCertificates[0].Subject.CommonName
+		w.Write([]byte(`{"secure":true}`))
+	}))
+	upstream.TLS = &tls.Config{
+		ClientCAs:  ca.pool,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+	}
+	upstream.Config.ErrorLog = log.New(io.Discard, "", 0)
+	upstream.StartTLS()
+	defer upstream.Close()
+
+	clientCert := ca.issue(t, "kono", 10)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(clientCert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tlsCfg := UpstreamTLSConfig{
+		CAFile:     writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", upstream.Certificate().Raw),
+		CertFile:   writePEM(t, filepath.Join(dir, "client.crt"), "CERTIFICATE", clientCert.Certificate[0]),
+		KeyFile:    writePEM(t, filepath.Join(dir, "client.key"), "PRIVATE KEY", keyDER),
+		ServerName: "example.com",
+	}
+
+	upstreams := initUpstreams([]UpstreamConfig{
+		{Name: "secure", Hosts: []string{upstream.URL}, Method: http.MethodGet, Timeout: time.Second, TLS: tlsCfg},
+		{Name: "secure-2", Hosts: []string{upstream.URL}, Method: http.MethodGet, Timeout: time.Second, TLS: tlsCfg},
+		{Name: "default", Hosts: []string{upstream.URL}, Method: http.MethodGet, Timeout: time.Second},
+	}, newTransportRegistry(), zap.NewNop())
+
+	transportOf := func(u Upstream) http.RoundTripper {
+		// Upstreams wrap the shared transport to track the health of their hosts.
+		return u.(*httpUpstream).client.Transport.(*healthTransport).next
+	}
+
+	if transportOf(upstreams[0]) != transportOf(upstreams[1]) {
+		t.Error("expected upstreams with equal TLS config to share a transport")
+	}
+
+	if transportOf(upstreams[0]) == transportOf(upstreams[2]) {
+		t.Error("expected distinct transports for distinct TLS configs")
+	}
+
+	d := &defaultDispatcher{log: zap.NewNop(), metrics: metric.NewNop()}
+	route := &Route{Upstreams: upstreams, MaxParallelUpstreams: maxParallelUpstreams}
+
+	results := d.dispatch(route, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	for i, res := range results[:2] {
+		if res.Err != nil {
+			t.Fatalf("upstream %d: unexpected error: %v", i, res.Err.Unwrap())
+		}
+	}
+
+	if gotClientCN != "kono" {
+		t.Errorf("expected client certificate to be presented, got CN %q", gotClientCN)
+	}
+
+	// The default transport neither trusts the private CA nor presents a client certificate.
+	if results[2].Err == nil || results[2].Err.Kind != UpstreamConnection {
+		t.Fatalf("expected connection error for the default TLS profile, got %+v", results[2])
+	}
+}
+
+func TestNewUpstreamTLSConfig_MissingCA(t *testing.T) {
+	_, err := newUpstreamTLSConfig(UpstreamTLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	if err == nil {
+		t.Fatal("expected error for missing CA bundle")
+	}
+}
+
+func TestTransportRegistry_SharesByProfile(t *testing.T) {
+	registry := newTransportRegistry()
+
+	a, err := registry.get(UpstreamTLSConfig{}, TransportConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := registry.get(UpstreamTLSConfig{}, TransportConfig{MaxIdleConns: defaultMaxIdleConns})
+	if a != b {
+		t.Error("expected defaults and explicit default values to share a transport")
+	}
+
+	c, _ := registry.get(UpstreamTLSConfig{}, TransportConfig{MaxConnsPerHost: 5, ResponseHeaderTimeout: time.Second})
+	if a == c {
+		t.Fatal("expected a distinct transport for distinct settings")
+	}
+
+	if c.MaxConnsPerHost != 5 || c.ResponseHeaderTimeout != time.Second {
+		t.Errorf("settings not applied: max conns %d, response header timeout %s", c.MaxConnsPerHost, c.ResponseHeaderTimeout)
+	}
+
+	if c.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost || c.IdleConnTimeout != defaultIdleConnTimeout {
+		t.Errorf("defaults not applied: idle per host %d, idle timeout %s", c.MaxIdleConnsPerHost, c.IdleConnTimeout)
+	}
+}
+
+func TestTransport_ProxyURL(t *testing.T) {
+	var proxiedURL string
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedURL = r.URL.String()
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	transport, err := newTransportRegistry().get(UpstreamTLSConfig{}, TransportConfig{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Get("http://backend.internal/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if proxiedURL != "http://backend.internal/users" {
+		t.Fatalf("expected request through proxy, got %q", proxiedURL)
+	}
+}
+
+func TestTransport_UnencryptedHTTP2(t *testing.T) {
+	var proto int
+
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto = r.ProtoMajor
+		w.Write([]byte(`{}`))
+	}))
+	upstream.Config.Protocols = new(http.Protocols)
+	upstream.Config.Protocols.SetHTTP1(true)
+	upstream.Config.Protocols.SetUnencryptedHTTP2(true)
+	upstream.Start()
+	defer upstream.Close()
+
+	transport, err := newTransportRegistry().get(UpstreamTLSConfig{}, TransportConfig{UnencryptedHTTP2: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if proto != 2 {
+		t.Fatalf("expected HTTP/2 with prior knowledge, got HTTP/%d", proto)
+	}
+}
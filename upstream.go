@@ -5,13 +5,14 @@ import (
 	"context"
 	"errors"
 	"io"
-	"net"
 	"net/http"
 	"slices"
 	"strings"
 	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
 	"github.com/starwalkn/kono/internal/circuitbreaker"
@@ -24,15 +25,18 @@ type Upstream interface {
 }
 
 type UpstreamResponse struct {
-	Status  int
-	Headers http.Header
-	Body    []byte
-	Err     *UpstreamError
+	Status   int
+	Headers  http.Header
+	Body     []byte
+	Err      *UpstreamError
+	Variant  string // Canary variant that served the request, empty for plain upstreams.
+	Attempts int    // Number of upstream attempts including retries.
 }
 
 type UpstreamError struct {
-	Kind UpstreamErrorKind // Error kind for aggregator.
-	Err  error             // Original error. Not for client!
+	Kind    UpstreamErrorKind // Error kind for aggregator.
+	Err     error             // Original error. Not for client!
+	Details []JSONError       // Errors reported by the upstream itself, returned to the client instead of the mapped kind.
 }
 
 // Error returns the upstream error kind. Error kind is a custom string type, not error interface!
@@ -47,15 +51,28 @@ func (ue *UpstreamError) Unwrap() error {
 
 type UpstreamErrorKind string
 
+const (
+	UpstreamTypeHTTP    = "http"
+	UpstreamTypeGRPC    = "grpc"
+	UpstreamTypeGRPCWeb = "grpc-web"
+	UpstreamTypeGraphQL = "graphql"
+	UpstreamTypeStatic  = "static"
+	UpstreamTypeMock    = "mock" // Alias of UpstreamTypeStatic.
+)
+
 const (
 	UpstreamTimeout      UpstreamErrorKind = "timeout"
 	UpstreamCanceled     UpstreamErrorKind = "canceled"
 	UpstreamConnection   UpstreamErrorKind = "connection"
 	UpstreamBadStatus    UpstreamErrorKind = "bad_status"
+	UpstreamClientError  UpstreamErrorKind = "client_error" // The upstream rejected the request itself.
 	UpstreamReadError    UpstreamErrorKind = "read_error"
 	UpstreamBodyTooLarge UpstreamErrorKind = "body_too_large"
 	UpstreamCircuitOpen  UpstreamErrorKind = "circuit_open"
 	UpstreamInternal     UpstreamErrorKind = "internal"
+	// UpstreamPartial means the upstream returned data along with errors, e.g. a GraphQL response
+	// with both "data" and "errors". The response body holds the data.
+	UpstreamPartial UpstreamErrorKind = "partial"
 )
 
 // httpUpstream is an implementation of Upstream interface.
@@ -63,6 +80,7 @@ type httpUpstream struct {
 	id                  string // UUID for internal usage.
 	name                string // For logs.
 	hosts               []string
+	health              []*hostHealth // Parallel to hosts, nil if not tracked.
 	currentHostIdx      uint64
 	method              string
 	timeout             time.Duration
@@ -71,6 +89,7 @@ type httpUpstream struct {
 	policy              Policy
 
 	circuitBreaker *circuitbreaker.CircuitBreaker
+	faults         *faultInjector
 
 	log    *zap.Logger
 	client *http.Client
@@ -80,13 +99,28 @@ func (u *httpUpstream) Name() string   { return u.name }
 func (u *httpUpstream) Policy() Policy { return u.policy }
 
 func (u *httpUpstream) Call(ctx context.Context, original *http.Request, originalBody []byte) *UpstreamResponse {
+	return u.callWithPolicy(ctx, original, func(ctx context.Context, log *zap.Logger) *UpstreamResponse {
+		return u.call(ctx, original, originalBody, log)
+	})
+}
+
+// callWithPolicy runs attempt with the retry policy, the circuit breaker and the fault injection of
+// the upstream. Upstream types wrapping httpUpstream use it to share the resilience behaviour.
+func (u *httpUpstream) callWithPolicy(
+	ctx context.Context,
+	original *http.Request,
+	attempt func(ctx context.Context, log *zap.Logger) *UpstreamResponse,
+) (resp *UpstreamResponse) {
 	log := u.log.With(zap.String("upstream", u.name))
 
-	resp := &UpstreamResponse{}
+	resp = &UpstreamResponse{}
+
+	var attempts int
+	defer func() { resp.Attempts = attempts }()
 
 	retryPolicy := u.policy.RetryPolicy
 
-	for attempt := 0; attempt <= retryPolicy.MaxRetries; attempt++ {
+	for range retryPolicy.MaxRetries + 1 {
 		select {
 		case <-ctx.Done():
 			resp.Err = &UpstreamError{
@@ -109,7 +143,23 @@ func (u *httpUpstream) Call(ctx context.Context, original *http.Request, origina
 				}
 			}
 
-			resp = u.call(ctx, original, originalBody, log)
+			attempts++
+
+			attemptCtx, span := startSpan(ctx, "upstream "+u.name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("kono.upstream", u.name),
+					attribute.Int("kono.upstream.attempt", attempts),
+				),
+			)
+
+			if u.faults != nil && u.faults.applies(original) {
+				resp = u.faults.attempt(attemptCtx, log, u.timeout, attempt)
+			} else {
+				resp = attempt(attemptCtx, log)
+			}
+
+			endUpstreamSpan(span, resp)
 
 			if u.circuitBreaker != nil {
 				if resp.Err != nil && u.isBreakerFailure(resp.Err) {
@@ -121,7 +171,12 @@ func (u *httpUpstream) Call(ctx context.Context, original *http.Request, origina
 			}
 
 			if resp.Err == nil && !slices.Contains(retryPolicy.RetryOnStatuses, resp.Status) {
-				break
+				return resp
+			}
+
+			// A rejected request fails the same way when it is sent again.
+			if resp.Err != nil && resp.Err.Kind == UpstreamClientError {
+				return resp
 			}
 
 			if retryPolicy.BackoffDelay > 0 {
@@ -142,6 +197,29 @@ func (u *httpUpstream) Call(ctx context.Context, original *http.Request, origina
 	return resp
 }
 
+// httpUpstreams returns the HTTP upstreams behind the given upstreams, including those wrapped by
+// gRPC, GraphQL and canary upstreams.
+func httpUpstreams(upstreams []Upstream) []*httpUpstream {
+	var out []*httpUpstream
+
+	for _, u := range upstreams {
+		switch u := u.(type) {
+		case *httpUpstream:
+			out = append(out, u)
+		case *grpcUpstream:
+			out = append(out, u.http)
+		case *graphqlUpstream:
+			out = append(out, u.http)
+		case *canaryUpstream:
+			for _, v := range u.variants {
+				out = append(out, httpUpstreams([]Upstream{v.upstream})...)
+			}
+		}
+	}
+
+	return out
+}
+
 func (u *httpUpstream) call(ctx context.Context, original *http.Request, originalBody []byte, log *zap.Logger) *UpstreamResponse {
 	uresp := &UpstreamResponse{
 		Headers: make(http.Header),
@@ -228,6 +306,10 @@ func (u *httpUpstream) call(ctx context.Context, original *http.Request, origina
 }
 
 func (u *httpUpstream) newRequest(ctx context.Context, original *http.Request, originalBody []byte) (*http.Request, error) {
+	return u.newRequestWithBody(ctx, original, bytes.NewReader(originalBody))
+}
+
+func (u *httpUpstream) newRequestWithBody(ctx context.Context, original *http.Request, body io.Reader) (*http.Request, error) {
 	method := u.method
 	if method == "" {
 		// Fallback method.
@@ -236,10 +318,10 @@ func (u *httpUpstream) newRequest(ctx context.Context, original *http.Request, o
 
 	// Send request body only for body-acceptable methods requests.
 	if method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch {
-		originalBody = nil
+		body = http.NoBody
 	}
 
-	target, err := http.NewRequestWithContext(ctx, method, u.selectHost(), bytes.NewReader(originalBody))
+	target, err := http.NewRequestWithContext(ctx, method, u.selectHost(), body)
 	if err != nil {
 		return nil, err
 	}
@@ -250,6 +332,165 @@ func (u *httpUpstream) newRequest(ctx context.Context, original *http.Request, o
 	return target, nil
 }
 
+// stream sends the request with the original body streamed to the next host of the upstream and returns
+// the response without reading its body, which must be closed by the caller. The upstream timeout bounds
+// waiting for the response headers only; canceling ctx (e.g. on client disconnect) aborts the upstream request.
+// Streamed requests are not retried because the body cannot be replayed.
+func (u *httpUpstream) stream(ctx context.Context, original *http.Request) (*http.Response, *UpstreamError) {
+	if u.circuitBreaker != nil && !u.circuitBreaker.Allow() {
+		return nil, &UpstreamError{
+			Kind: UpstreamCircuitOpen,
+			Err:  errors.New("upstream circuit breaker is open"),
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	headersTimer := time.AfterFunc(u.timeout, cancel)
+
+	target, err := u.newRequestWithBody(ctx, original, original.Body)
+	if err != nil {
+		cancel()
+
+		return nil, &UpstreamError{
+			Kind: UpstreamInternal,
+			Err:  err,
+		}
+	}
+
+	if target.Body != http.NoBody {
+		target.ContentLength = original.ContentLength
+	}
+
+	hresp, err := u.client.Do(target)
+	timedOut := !headersTimer.Stop()
+
+	if err != nil {
+		uerr := u.streamError(ctx, err, timedOut)
+		cancel()
+
+		return nil, uerr
+	}
+
+	if u.circuitBreaker != nil {
+		if hresp.StatusCode >= http.StatusInternalServerError {
+			u.circuitBreaker.OnFailure()
+		} else {
+			u.circuitBreaker.OnSuccess()
+		}
+	}
+
+	hresp.Body = &cancelOnClose{ReadCloser: hresp.Body, cancel: cancel}
+
+	return hresp, nil
+}
+
+func (u *httpUpstream) streamError(ctx context.Context, err error, timedOut bool) *UpstreamError {
+	kind := UpstreamConnection
+
+	switch {
+	case timedOut:
+		kind = UpstreamTimeout
+		err = errors.Join(context.DeadlineExceeded, err)
+	case ctx.Err() != nil:
+		kind = UpstreamCanceled
+	}
+
+	if u.circuitBreaker != nil && kind == UpstreamConnection {
+		u.circuitBreaker.OnFailure()
+	}
+
+	return &UpstreamError{
+		Kind: kind,
+		Err:  err,
+	}
+}
+
+// cancelOnClose releases the request context of a streamed response when its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// upgrade performs the websocket handshake with the next host of the upstream. If the upstream switches
+// protocols, the response body is the upgraded connection and must be closed by the caller.
+// The upstream timeout bounds the handshake only.
+func (u *httpUpstream) upgrade(ctx context.Context, original *http.Request) (*http.Response, *UpstreamError) {
+	if u.circuitBreaker != nil && !u.circuitBreaker.Allow() {
+		return nil, &UpstreamError{
+			Kind: UpstreamCircuitOpen,
+			Err:  errors.New("upstream circuit breaker is open"),
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	handshakeTimer := time.AfterFunc(u.timeout, cancel)
+
+	target, err := http.NewRequestWithContext(ctx, http.MethodGet, u.selectHost(), nil)
+	if err != nil {
+		cancel()
+
+		return nil, &UpstreamError{
+			Kind: UpstreamInternal,
+			Err:  err,
+		}
+	}
+
+	u.resolveQueryStrings(target, original)
+	u.resolveHeaders(target, original)
+
+	if target.Header.Get("Content-Type") == "" {
+		target.Header.Del("Content-Type")
+	}
+
+	for _, name := range websocketHandshakeHeaders {
+		if values := original.Header.Values(name); len(values) > 0 {
+			target.Header[name] = values
+		}
+	}
+
+	target.Header.Set("Connection", "Upgrade")
+
+	hresp, err := u.client.Transport.RoundTrip(target)
+	if !handshakeTimer.Stop() && err != nil {
+		err = errors.Join(context.DeadlineExceeded, err)
+	}
+
+	if err != nil {
+		cancel()
+
+		if u.circuitBreaker != nil {
+			u.circuitBreaker.OnFailure()
+		}
+
+		kind := UpstreamConnection
+		if errors.Is(err, context.DeadlineExceeded) {
+			kind = UpstreamTimeout
+		}
+
+		return nil, &UpstreamError{
+			Kind: kind,
+			Err:  err,
+		}
+	}
+
+	if u.circuitBreaker != nil {
+		if hresp.StatusCode >= http.StatusInternalServerError {
+			u.circuitBreaker.OnFailure()
+		} else {
+			u.circuitBreaker.OnSuccess()
+		}
+	}
+
+	// The handshake context is not canceled here because the upgraded connection must outlive the handshake.
+	// It is released with the original request context.
+	return hresp, nil
+}
+
 func (u *httpUpstream) selectHost() string {
 	if len(u.hosts) == 1 {
 		return u.hosts[0]
@@ -258,11 +499,25 @@ func (u *httpUpstream) selectHost() string {
 	idx := atomic.AddUint64(&u.currentHostIdx, 1)
 	host := u.hosts[idx%uint64(len(u.hosts))]
 
+	// Drained hosts are skipped. If every host is drained, they are used anyway rather than failing requests.
+	for i := range uint64(len(u.hosts)) {
+		j := (idx + i) % uint64(len(u.hosts))
+
+		if !u.isDrained(int(j)) { //nolint:gosec // bounded by len(u.hosts)
+			host = u.hosts[j]
+			break
+		}
+	}
+
 	u.log.Debug("new host selected", zap.String("host", host), zap.String("upstream", u.name))
 
 	return host
 }
 
+func (u *httpUpstream) isDrained(i int) bool {
+	return i < len(u.health) && u.health[i].drained.Load()
+}
+
 func (u *httpUpstream) resolveQueryStrings(target, original *http.Request) {
 	q := target.URL.Query()
 
@@ -313,9 +568,66 @@ func (u *httpUpstream) resolveHeaders(target, original *http.Request) {
 	target.Header.Set("Content-Type", original.Header.Get("Content-Type"))
 	target.Header.Set("Host", target.URL.Host)
 
-	if ip, _, err := net.SplitHostPort(original.RemoteAddr); err == nil {
-		target.Header.Add("X-Forwarded-For", ip)
+	resolveForwardingHeaders(target, original)
+	injectTraceHeaders(target.Context(), target.Header)
+}
+
+// resolveForwardingHeaders appends the peer address to the chain of the configured client IP header, sent on as
+// X-Forwarded-For, appends the gateway's element to Forwarded and sets X-Forwarded-Proto and X-Forwarded-Host.
+// Forwarding headers of the incoming request are kept only if the peer is a trusted proxy, otherwise they are
+// replaced because the client could have spoofed them.
+func resolveForwardingHeaders(target, original *http.Request) {
+	info, _ := forwardingInfoFromContext(original.Context())
+
+	scheme := "http"
+	if original.TLS != nil {
+		scheme = "https"
 	}
+
+	proto, host := scheme, original.Host
+
+	var chain []string
+
+	if info.peerTrusted {
+		for _, hop := range info.chain {
+			// Forwarded hops may carry ports and brackets, X-Forwarded-For holds bare addresses.
+			if addr, ok := parseIP(hop); ok {
+				hop = addr.String()
+			}
+
+			if hop != "" {
+				chain = append(chain, hop)
+			}
+		}
+
+		if xfp := original.Header.Get("X-Forwarded-Proto"); xfp != "" {
+			proto = xfp
+		}
+
+		if xfh := original.Header.Get("X-Forwarded-Host"); xfh != "" {
+			host = xfh
+		}
+	} else {
+		target.Header.Del("Forwarded")
+	}
+
+	peer := remoteHost(original.RemoteAddr)
+	if peer != "" {
+		chain = append(chain, peer)
+	}
+
+	if len(chain) > 0 {
+		target.Header.Set("X-Forwarded-For", strings.Join(chain, ", "))
+	}
+
+	target.Header.Set("X-Forwarded-Proto", proto)
+	target.Header.Set("X-Forwarded-Host", host)
+
+	// The gateway's own Forwarded element describes the request as it received it.
+	elements := append(target.Header.Values("Forwarded"), forwardedElement(peer, scheme, original.Host))
+	target.Header.Set("Forwarded", strings.Join(elements, ", "))
+
+	setClientCertHeaders(target.Header, info.clientCert)
 }
 
 func (u *httpUpstream) isBreakerFailure(uerr *UpstreamError) bool {
@@ -0,0 +1,288 @@
+package kono
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/starwalkn/kono/internal/metric"
+)
+
+const websocketBufferSize = 32 * 1024
+
+// websocketHandshakeHeaders are forwarded to the upstream regardless of the forward_headers setting,
+// the handshake cannot succeed without them.
+var websocketHandshakeHeaders = []string{
+	"Upgrade",
+	"Sec-Websocket-Key",
+	"Sec-Websocket-Version",
+	"Sec-Websocket-Protocol",
+	"Sec-Websocket-Extensions",
+	"Origin",
+}
+
+// upgrader is implemented by upstreams able to proxy websocket connections.
+type upgrader interface {
+	upgrade(ctx context.Context, original *http.Request) (*http.Response, *UpstreamError)
+}
+
+// WebSocketProxy holds the limits and the open connections counter of a websocket route.
+type WebSocketProxy struct {
+	idleTimeout    time.Duration
+	maxConnections int64
+
+	open atomic.Int64
+}
+
+func newWebSocketProxy(cfg WebSocketConfig) *WebSocketProxy {
+	return &WebSocketProxy{
+		idleTimeout:    cfg.IdleTimeout,
+		maxConnections: cfg.MaxConnections,
+	}
+}
+
+// OpenConnections returns the number of currently proxied connections.
+func (p *WebSocketProxy) OpenConnections() int64 {
+	return p.open.Load()
+}
+
+// tryOpen reserves a connection slot. It fails if max connections are reached.
+func (p *WebSocketProxy) tryOpen() bool {
+	if p.open.Add(1) > p.maxConnections && p.maxConnections > 0 {
+		p.open.Add(-1)
+		return false
+	}
+
+	return true
+}
+
+func (p *WebSocketProxy) release() {
+	p.open.Add(-1)
+}
+
+// serveWebSocket proxies a websocket connection of the route to its upstream. The handshake is forwarded
+// to a host selected by the upstream balancer; if the upstream switches protocols, the client connection
+// is hijacked and bytes are copied in both directions until either side closes or the connection is idle.
+func (r *Router) serveWebSocket(w http.ResponseWriter, req *http.Request, route *Route, requestID string) {
+	if !isWebSocketUpgrade(req) {
+		WriteError(w, ErrorCodeBadRequest, "websocket upgrade required", requestID, http.StatusBadRequest)
+		return
+	}
+
+	if len(route.Upstreams) == 0 {
+		WriteError(w, ErrorCodeInternal, "internal error", requestID, http.StatusInternalServerError)
+		return
+	}
+
+	upstream, ok := route.Upstreams[0].(upgrader)
+	if !ok {
+		r.log.Error("upstream does not support websocket", zap.String("upstream", route.Upstreams[0].Name()))
+		WriteError(w, ErrorCodeInternal, "internal error", requestID, http.StatusInternalServerError)
+
+		return
+	}
+
+	proxy := route.WebSocket
+
+	if !proxy.tryOpen() {
+		r.log.Warn("websocket connection limit reached", zap.String("route", route.Path))
+		r.metrics.IncFailedRequestsTotal(metric.FailReasonOverloaded)
+		WriteError(w, ErrorCodeOverloaded, "too many connections", requestID, http.StatusServiceUnavailable)
+
+		return
+	}
+	defer proxy.release()
+
+	resp, uerr := upstream.upgrade(req.Context(), req)
+	if uerr != nil {
+		r.log.Error("websocket handshake failed", zap.String("upstream", route.Upstreams[0].Name()), zap.Error(uerr.Err))
+		r.metrics.IncFailedRequestsTotal(metric.FailReasonUpstreamError)
+
+		if uerr.Kind == UpstreamCircuitOpen {
+			WriteError(w, ErrorCodeUpstreamUnavailable, "upstream unavailable", requestID, http.StatusServiceUnavailable)
+			return
+		}
+
+		WriteError(w, ErrorCodeUpstreamError, "upstream error", requestID, http.StatusBadGateway)
+
+		return
+	}
+	defer resp.Body.Close()
+
+	// The upstream refused the upgrade, its response is passed through as is.
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Header.Set("X-Request-ID", requestID)
+		r.metrics.IncResponsesTotal(route.Path, resp.StatusCode, "")
+		copyResponse(w, resp)
+
+		return
+	}
+
+	backend, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		r.log.Error("upstream switched protocols without a writable body", zap.String("upstream", route.Upstreams[0].Name()))
+		WriteError(w, ErrorCodeUpstreamMalformed, "upstream error", requestID, http.StatusBadGateway)
+
+		return
+	}
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		r.log.Error("cannot hijack websocket connection", zap.Error(err))
+		WriteError(w, ErrorCodeInternal, "internal error", requestID, http.StatusInternalServerError)
+
+		return
+	}
+	defer conn.Close()
+
+	// Server read and write timeouts must not apply to the upgraded connection.
+	_ = conn.SetDeadline(time.Time{})
+
+	resp.Header.Set("X-Request-ID", requestID)
+
+	if err = writeSwitchingProtocols(brw.Writer, resp); err != nil {
+		r.log.Error("cannot write websocket handshake response", zap.Error(err))
+		return
+	}
+
+	r.metrics.IncResponsesTotal(route.Path, resp.StatusCode, "")
+	r.metrics.IncWebSocketConnections(route.Path)
+	defer r.metrics.DecWebSocketConnections(route.Path)
+
+	r.log.Debug("websocket connection opened", zap.String("route", route.Path))
+
+	proxyWebSocket(conn, brw.Reader, backend, proxy.idleTimeout)
+
+	r.log.Debug("websocket connection closed", zap.String("route", route.Path))
+}
+
+// isWebSocketUpgrade reports whether the request is a websocket opening handshake.
+func isWebSocketUpgrade(req *http.Request) bool {
+	return req.Method == http.MethodGet &&
+		headerContainsToken(req.Header, "Connection", "upgrade") &&
+		strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+}
+
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// writeSwitchingProtocols writes the upstream handshake response to the hijacked client connection.
+func writeSwitchingProtocols(w *bufio.Writer, resp *http.Response) error {
+	if _, err := fmt.Fprintf(w, "HTTP/1.1 %d %s\r\n", resp.StatusCode, http.StatusText(resp.StatusCode)); err != nil {
+		return err
+	}
+
+	if err := resp.Header.Write(w); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "\r\n"); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// proxyWebSocket copies data between the client and the backend until either side closes.
+// Buffered client data is read from clientReader first. If idleTimeout is positive,
+// both connections are closed when no data passed in either direction for that long.
+func proxyWebSocket(client net.Conn, clientReader io.Reader, backend io.ReadWriteCloser, idleTimeout time.Duration) {
+	var (
+		lastActivity atomic.Int64
+		closeOnce    sync.Once
+		done         = make(chan struct{})
+	)
+
+	closeBoth := func() {
+		closeOnce.Do(func() {
+			close(done)
+			_ = client.Close()
+			_ = backend.Close()
+		})
+	}
+	defer closeBoth()
+
+	touch := func() { lastActivity.Store(time.Now().UnixNano()) }
+	touch()
+
+	if idleTimeout > 0 {
+		go func() {
+			ticker := time.NewTicker(max(idleTimeout/4, time.Millisecond)) //nolint:mnd // check often enough
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if time.Since(time.Unix(0, lastActivity.Load())) >= idleTimeout {
+						closeBoth()
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Add(2) //nolint:mnd // both directions
+
+	go func() {
+		defer wg.Done()
+		defer closeBoth()
+
+		_ = copyWithActivity(backend, clientReader, touch)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer closeBoth()
+
+		_ = copyWithActivity(client, backend, touch)
+	}()
+
+	wg.Wait()
+}
+
+func copyWithActivity(dst io.Writer, src io.Reader, touch func()) error {
+	buf := make([]byte, websocketBufferSize)
+
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			touch()
+
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package kono
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint:gosec // required by the websocket handshake
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// newEchoWebSocketServer starts an upstream that completes the handshake and echoes everything back
+// prefixed with its name, so tests can tell which host served the connection.
+func newEchoWebSocketServer(t *testing.T, name string, handshakes chan<- http.Header) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handshakes != nil {
+			handshakes <- r.Header.Clone()
+		}
+
+		if !isWebSocketUpgrade(r) {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + websocketGUID)) //nolint:gosec // handshake
+
+		_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+		_ = brw.Flush()
+
+		buf := make([]byte, 1024)
+
+		for {
+			n, err := brw.Read(buf)
+			if err != nil {
+				return
+			}
+
+			if _, err = conn.Write(append([]byte(name+":"), buf[:n]...)); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newWebSocketTestRoute(hosts []string, cfg WebSocketConfig, middlewares ...Middleware) Route {
+	upstreams := initUpstreams([]UpstreamConfig{
+		{Hosts: hosts, Timeout: time.Second, ForwardHeaders: []string{"Authorization"}},
+	}, newTransportRegistry(), zap.NewNop())
+
+	return Route{
+		Path:        "/ws",
+		Method:      http.MethodGet,
+		Type:        RouteTypeWebSocket,
+		Upstreams:   upstreams,
+		Middlewares: middlewares,
+		WebSocket:   newWebSocketProxy(cfg),
+	}
+}
+
+// dialWebSocket performs the opening handshake against the gateway and returns the connection
+// with the handshake response.
+func dialWebSocket(t *testing.T, srv *httptest.Server, header http.Header) (net.Conn, *bufio.Reader, *http.Response) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	if err = req.Write(conn); err != nil {
+		t.Fatalf("write handshake: %v", err)
+	}
+
+	br := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatalf("read handshake response: %v", err)
+	}
+
+	return conn, br, resp
+}
+
+func echo(t *testing.T, conn net.Conn, br *bufio.Reader, msg string) string {
+	t.Helper()
+
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	buf := make([]byte, 1024)
+
+	n, err := br.Read(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	return string(buf[:n])
+}
+
+type requireTokenMiddleware struct{}
+
+func (m *requireTokenMiddleware) Init(_ map[string]interface{}) error { return nil }
+func (m *requireTokenMiddleware) Name() string                        { return "requiretoken" }
+func (m *requireTokenMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(&wrappingWriter{ResponseWriter: w}, r)
+	})
+}
+
+// wrappingWriter hides the Hijacker of the underlying writer the way middlewares usually do.
+type wrappingWriter struct {
+	http.ResponseWriter
+}
+
+func (w *wrappingWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestWebSocket_ProxiesFramesAfterMiddlewares(t *testing.T) {
+	handshakes := make(chan http.Header, 1)
+	upstream := newEchoWebSocketServer(t, "a", handshakes)
+	route := newWebSocketTestRoute([]string{upstream.URL}, WebSocketConfig{}, &requireTokenMiddleware{})
+
+	srv := httptest.NewServer(newTestRouter(&mockDispatcher{}, route))
+	t.Cleanup(srv.Close)
+
+	_, _, resp := dialWebSocket(t, srv, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", resp.StatusCode)
+	}
+
+	conn, br, resp := dialWebSocket(t, srv, http.Header{"Authorization": {"Bearer token"}})
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+
+	if resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", resp.Header.Get("Sec-WebSocket-Accept"))
+	}
+
+	header := <-handshakes
+	if header.Get("Sec-WebSocket-Key") == "" || header.Get("Authorization") == "" {
+		t.Fatalf("handshake headers not forwarded: %v", header)
+	}
+
+	if header.Get("X-Forwarded-For") == "" {
+		t.Fatal("expected X-Forwarded-For on the handshake")
+	}
+
+	for _, msg := range []string{"hello", "world"} {
+		if got := echo(t, conn, br, msg); got != "a:"+msg {
+			t.Fatalf("expected echo a:%s, got %q", msg, got)
+		}
+	}
+}
+
+func TestWebSocket_BalancesHosts(t *testing.T) {
+	a := newEchoWebSocketServer(t, "a", nil)
+	b := newEchoWebSocketServer(t, "b", nil)
+	route := newWebSocketTestRoute([]string{a.URL, b.URL}, WebSocketConfig{})
+
+	srv := httptest.NewServer(newTestRouter(&mockDispatcher{}, route))
+	t.Cleanup(srv.Close)
+
+	seen := make(map[string]bool)
+
+	for range 2 {
+		conn, br, resp := dialWebSocket(t, srv, nil)
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			t.Fatalf("expected 101, got %d", resp.StatusCode)
+		}
+
+		host, _, _ := strings.Cut(echo(t, conn, br, "ping"), ":")
+		seen[host] = true
+	}
+
+	if !seen["a"] || !seen["b"] {
+		t.Fatalf("expected both hosts to be selected, got %v", seen)
+	}
+}
+
+func TestWebSocket_MaxConnections(t *testing.T) {
+	upstream := newEchoWebSocketServer(t, "a", nil)
+	route := newWebSocketTestRoute([]string{upstream.URL}, WebSocketConfig{MaxConnections: 1})
+
+	srv := httptest.NewServer(newTestRouter(&mockDispatcher{}, route))
+	t.Cleanup(srv.Close)
+
+	conn, br, resp := dialWebSocket(t, srv, nil)
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+
+	echo(t, conn, br, "ping")
+
+	_, _, resp = dialWebSocket(t, srv, nil)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 above max connections, got %d", resp.StatusCode)
+	}
+
+	_ = conn.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		_, _, resp = dialWebSocket(t, srv, nil)
+		if resp.StatusCode == http.StatusSwitchingProtocols {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("expected the slot to be released after the connection is closed")
+}
+
+func TestWebSocket_IdleTimeout(t *testing.T) {
+	upstream := newEchoWebSocketServer(t, "a", nil)
+	route := newWebSocketTestRoute([]string{upstream.URL}, WebSocketConfig{IdleTimeout: 50 * time.Millisecond})
+
+	srv := httptest.NewServer(newTestRouter(&mockDispatcher{}, route))
+	t.Cleanup(srv.Close)
+
+	conn, br, resp := dialWebSocket(t, srv, nil)
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	if _, err := br.ReadByte(); err != io.EOF {
+		t.Fatalf("expected the idle connection to be closed, got %v", err)
+	}
+}
+
+func TestWebSocket_RequiresUpgrade(t *testing.T) {
+	upstream := newEchoWebSocketServer(t, "a", nil)
+	route := newWebSocketTestRoute([]string{upstream.URL}, WebSocketConfig{})
+
+	srv := httptest.NewServer(newTestRouter(&mockDispatcher{}, route))
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/ws")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+}